@@ -0,0 +1,104 @@
+package gsm
+
+import (
+	"context"
+	"maps"
+	"time"
+)
+
+// AuditEvent describes a successful secret access or write.
+type AuditEvent struct {
+	// Time is when the operation completed.
+	Time time.Time
+	// Identity is the attested identity of this instance, or nil unless the
+	// client was created with WithInstanceIdentity.
+	Identity *InstanceIdentity
+	// ServiceAccount is the email of the service account the client
+	// authenticates as, or empty if it has none or it could not be determined.
+	// It is looked up on the client's first audit event and then reused.
+	ServiceAccount string
+	// Labels are the caller's labels for the operation; see ContextWithAuditLabels.
+	Labels map[string]string
+	// Operation is "fetch", "cache" for a fetch served from the client's cache
+	// (see WithCache and WithDiskCache), "store", or "stale" for a previously
+	// fetched value served because fetching failed; see WithStaleIfError.
+	Operation string
+	// Project is the project ID containing the secret.
+	Project string
+	// Secret is the secret name.
+	Secret string
+	// Version is the version ID that was read or written.
+	Version string
+}
+
+// AuditFunc receives audit events. It is called synchronously, so slow
+// handlers delay the operation being audited.
+type AuditFunc func(ctx context.Context, ev AuditEvent)
+
+// WithAuditHook registers fn to be called after every successful Fetch and Store,
+// including fetches served from cache, and whenever a stale value is served.
+func WithAuditHook(fn AuditFunc) Option {
+	return func(c *Client) {
+		c.audit = fn
+	}
+}
+
+type auditLabelsKey struct{}
+
+// ContextWithAuditLabels returns a copy of ctx whose operations carry labels in
+// their audit events, e.g. the request ID or end user on whose behalf a secret
+// is accessed. Labels already in ctx are kept unless labels replaces them.
+func ContextWithAuditLabels(ctx context.Context, labels map[string]string) context.Context {
+	merged := maps.Clone(auditLabels(ctx))
+	if merged == nil {
+		merged = make(map[string]string, len(labels))
+	}
+	maps.Copy(merged, labels)
+	return context.WithValue(ctx, auditLabelsKey{}, merged)
+}
+
+// auditLabels returns the labels in ctx, which must not be modified.
+func auditLabels(ctx context.Context) map[string]string {
+	labels, _ := ctx.Value(auditLabelsKey{}).(map[string]string) //nolint:errcheck // absent without labels
+	return labels
+}
+
+// emitAudit sends an audit event to the configured hook, if any.
+func (c *Client) emitAudit(ctx context.Context, op, pid, name, version string) {
+	if c.audit == nil {
+		return
+	}
+
+	ev := AuditEvent{
+		Time:      time.Now(),
+		Labels:    maps.Clone(auditLabels(ctx)),
+		Operation: op,
+		Project:   pid,
+		Secret:    name,
+		Version:   version,
+	}
+	ev.ServiceAccount = c.auditAccount(ctx)
+	if c.identityAudience != "" {
+		id, _, err := c.instanceIdentity(ctx)
+		if err != nil {
+			c.log().Warn("unable to attest instance identity for audit event", "error", err)
+		}
+		ev.Identity = id
+	}
+	c.audit(ctx, ev)
+}
+
+// auditAccount returns the service account recorded in audit events. It is
+// looked up once per client, and a failed lookup is remembered as no account,
+// so audited operations, including cache hits, never wait on the metadata server
+// after the first.
+func (c *Client) auditAccount(ctx context.Context) string {
+	c.auditOnce.Do(func() {
+		email, err := c.ServiceAccountEmail(ctx)
+		if err != nil {
+			c.log().Debug("unable to determine service account for audit events", "error", err)
+		}
+		c.auditEmail = email
+	})
+	return c.auditEmail
+}
@@ -0,0 +1,82 @@
+package gsm
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// SecretStore is a backend that secrets can be read from and written to by name.
+// Use Open to select a backend by URL, so services can consume Secret Manager
+// and other stores through one API.
+type SecretStore interface {
+	// Fetch retrieves the latest value of a secret.
+	Fetch(ctx context.Context, name string) (string, error)
+	// Store writes a new value of a secret, creating it if needed.
+	Store(ctx context.Context, name, value string) error
+}
+
+// Open returns the SecretStore identified by rawURL:
+//
+//	gsm://                     Secret Manager, current project (default client)
+//	gsm://PROJECT              Secret Manager, a specific project
+//	vault://HOST[:PORT]/MOUNT  Vault KV v2 over HTTPS, token from $VAULT_TOKEN
+//	vault+http://HOST[:PORT]/MOUNT  the same over plain HTTP, e.g. a dev server
+func Open(rawURL string) (SecretStore, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret store URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "gsm":
+		if u.Host != "" && !projectIDRegex.MatchString(u.Host) {
+			return nil, fmt.Errorf("invalid project ID format: %q", u.Host)
+		}
+		return defaultClient.SecretStore(u.Host), nil
+	case "vault", "vault+http":
+		scheme := "https"
+		if u.Scheme == "vault+http" {
+			scheme = "http"
+		}
+		mount := strings.Trim(u.Path, "/")
+		if u.Host == "" || mount == "" {
+			return nil, fmt.Errorf("vault URL %q must name a host and KV mount", rawURL)
+		}
+		token := os.Getenv("VAULT_TOKEN")
+		if token == "" {
+			return nil, fmt.Errorf("opening %s: $VAULT_TOKEN is not set", rawURL)
+		}
+		return NewVaultStore(scheme+"://"+u.Host, mount, token), nil
+	default:
+		return nil, fmt.Errorf("unsupported secret store scheme %q", u.Scheme)
+	}
+}
+
+// SecretStore returns c as a SecretStore for project pid.
+// An empty pid means the current project.
+func (c *Client) SecretStore(pid string) SecretStore {
+	return &projectStore{c: c, pid: pid}
+}
+
+// projectStore adapts a Client to SecretStore.
+type projectStore struct {
+	c   *Client
+	pid string
+}
+
+func (s *projectStore) Fetch(ctx context.Context, name string) (string, error) {
+	if s.pid == "" {
+		return s.c.Fetch(ctx, name)
+	}
+	return s.c.FetchFromProject(ctx, s.pid, name)
+}
+
+func (s *projectStore) Store(ctx context.Context, name, value string) error {
+	if s.pid == "" {
+		return s.c.Store(ctx, name, value)
+	}
+	return s.c.StoreInProject(ctx, s.pid, name, value)
+}
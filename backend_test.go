@@ -0,0 +1,121 @@
+package gsm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	t.Setenv("VAULT_TOKEN", "vault-token")
+
+	tests := []struct {
+		url         string
+		want        string // backend type
+		errContains string
+	}{
+		{url: "gsm://", want: "*gsm.projectStore"},
+		{url: "gsm://my-project", want: "*gsm.projectStore"},
+		{url: "gsm://BAD", errContains: "invalid project ID format"},
+		{url: "vault://vault.example.com:8200/secret", want: "*gsm.VaultStore"},
+		{url: "vault+http://127.0.0.1:8200/kv/", want: "*gsm.VaultStore"},
+		{url: "vault://vault.example.com", errContains: "must name a host and KV mount"},
+		{url: "s3://bucket", errContains: `unsupported secret store scheme "s3"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got, err := Open(tt.url)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("Open() error = %v, want error containing %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Open() unexpected error = %v", err)
+			}
+			if typ := fmt.Sprintf("%T", got); typ != tt.want {
+				t.Errorf("Open() = %s, want %s", typ, tt.want)
+			}
+		})
+	}
+
+	t.Setenv("VAULT_TOKEN", "")
+	if _, err := Open("vault://vault.example.com/secret"); err == nil || !strings.Contains(err.Error(), "VAULT_TOKEN") {
+		t.Errorf("Open() error = %v, want error naming $VAULT_TOKEN", err)
+	}
+}
+
+func TestVaultStore(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		data = map[string]map[string]any{"app/db": {"value": "pw", "user": "admin"}}
+	)
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		path, ok := strings.CutPrefix(r.URL.Path, "/v1/kv/data/")
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			d, ok := data[path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"data": d}}) //nolint:errcheck // test mock server
+		case http.MethodPost:
+			var body struct {
+				Data map[string]any `json:"data"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			data[path] = body.Data
+			_, _ = w.Write([]byte(`{"data":{"version":2}}`)) //nolint:errcheck // test mock server
+		}
+	}))
+	t.Cleanup(vault.Close)
+
+	ctx := context.Background()
+	var s SecretStore = NewVaultStore(vault.URL+"/", "/kv/", "vault-token")
+
+	if got, err := s.Fetch(ctx, "app/db"); err != nil || got != "pw" {
+		t.Errorf("Fetch(app/db) = %q, %v, want %q", got, err, "pw")
+	}
+	if got, err := s.Fetch(ctx, "app/db#user"); err != nil || got != "admin" {
+		t.Errorf("Fetch(app/db#user) = %q, %v, want %q", got, err, "admin")
+	}
+	if err := s.Store(ctx, "app/api", "key"); err != nil {
+		t.Fatalf("Store() unexpected error = %v", err)
+	}
+	if got, err := s.Fetch(ctx, "app/api"); err != nil || got != "key" {
+		t.Errorf("Fetch(app/api) = %q, %v, want %q", got, err, "key")
+	}
+
+	for _, name := range []string{"missing", "app/db#nokey", "../sys/x", "app//db"} {
+		if _, err := s.Fetch(ctx, name); err == nil {
+			t.Errorf("Fetch(%q) succeeded, want error", name)
+		}
+	}
+	if err := s.Store(ctx, "app/db#user", "x"); err == nil {
+		t.Error("Store() of a single key succeeded, want error")
+	}
+	if _, err := NewVaultStore(vault.URL, "kv", "wrong").Fetch(ctx, "app/db"); err == nil || !strings.Contains(err.Error(), "status 403") {
+		t.Errorf("Fetch() with a bad token error = %v, want status 403", err)
+	}
+}
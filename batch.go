@@ -0,0 +1,403 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// maxConcurrentFetches bounds the number of secrets FetchMany and StoreMany
+// process at once.
+const maxConcurrentFetches = 8
+
+// FetchMany retrieves the latest versions of several secrets from the current project
+// concurrently using the default client, returning values keyed by secret name.
+// The project ID is auto-detected from the GCP metadata server.
+func FetchMany(ctx context.Context, names ...string) (map[string]string, error) {
+	return defaultClient.FetchMany(ctx, names...)
+}
+
+// FetchManyFromProject retrieves the latest versions of several secrets from a specific
+// project concurrently using the default client, returning values keyed by secret name.
+func FetchManyFromProject(ctx context.Context, pid string, names ...string) (map[string]string, error) {
+	return defaultClient.FetchManyFromProject(ctx, pid, names...)
+}
+
+// FetchMany retrieves the latest versions of several secrets from the current project
+// concurrently, returning values keyed by secret name.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) FetchMany(ctx context.Context, names ...string) (map[string]string, error) {
+	for _, name := range names {
+		if !secretNameRegex.MatchString(name) {
+			return nil, errors.New("invalid secret name format")
+		}
+	}
+
+	p, err := c.projectID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.FetchManyFromProject(ctx, p, names...)
+}
+
+// FetchManyFromProject retrieves the latest versions of several secrets from a specific
+// project concurrently, returning values keyed by secret name. All fetches share one
+// access token, so loading many secrets at startup costs about as much as loading one.
+// If any fetch fails, the errors for every failed secret are returned together.
+func (c *Client) FetchManyFromProject(ctx context.Context, pid string, names ...string) (map[string]string, error) {
+	if !projectIDRegex.MatchString(pid) {
+		return nil, fmt.Errorf("invalid project ID format: %q", pid)
+	}
+	for _, name := range names {
+		if !secretNameRegex.MatchString(name) {
+			return nil, errors.New("invalid secret name format")
+		}
+	}
+
+	return c.fetchMany(ctx, "", pid, names)
+}
+
+// fetchMany fetches names concurrently, serving what it can from the cache.
+// If t is empty, an access token is fetched only when something is not cached.
+func (c *Client) fetchMany(ctx context.Context, t, pid string, names []string) (map[string]string, error) {
+	values, failed := c.fetchEach(ctx, t, pid, names)
+	if len(failed) == 0 {
+		return values, nil
+	}
+	errs := make([]error, 0, len(failed))
+	for _, name := range slices.Sorted(maps.Keys(failed)) {
+		errs = append(errs, fmt.Errorf("secret %s: %w", name, failed[name]))
+	}
+	return nil, errors.Join(errs...)
+}
+
+// fetchEach fetches names concurrently like fetchMany, returning the values
+// fetched and the error for each secret that failed.
+func (c *Client) fetchEach(ctx context.Context, t, pid string, names []string) (map[string]string, map[string]error) {
+	values := make(map[string]string, len(names))
+	failed := map[string]error{}
+	pending := map[string]bool{}
+	for _, name := range names {
+		value, ok, err := c.cached(ctx, pid, name)
+		switch {
+		case err != nil:
+			failed[name] = err
+		case ok:
+			values[name] = value
+		default:
+			pending[name] = true
+		}
+	}
+	if len(pending) == 0 {
+		return values, failed
+	}
+
+	if t == "" {
+		var err error
+		if t, err = c.accessToken(ctx); err != nil {
+			for name := range pending {
+				if value, ok := c.staleValue(ctx, pid, name, err); ok {
+					values[name] = value
+				} else {
+					failed[name] = err
+				}
+			}
+			return values, failed
+		}
+	}
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, maxConcurrentFetches)
+	)
+	for name := range pending {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			value, err := c.fetch(ctx, t, pid, name, fetchOptions{})
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed[name] = err
+				return
+			}
+			values[name] = value
+		}()
+	}
+	wg.Wait()
+
+	// Only fetches that were attempted fall back to stale values; a cached miss
+	// is returned as it is.
+	for name := range pending {
+		err, ok := failed[name]
+		if !ok {
+			continue
+		}
+		if value, ok := c.staleValue(ctx, pid, name, err); ok {
+			values[name] = value
+			delete(failed, name)
+		}
+	}
+	return values, failed
+}
+
+// FetchAll retrieves the latest versions of several secrets from the current project
+// concurrently using the default client, tolerating failures. See Client.FetchAllFromProject.
+// The project ID is auto-detected from the GCP metadata server.
+func FetchAll(ctx context.Context, names ...string) (map[string]string, map[string]error) {
+	return defaultClient.FetchAll(ctx, names...)
+}
+
+// FetchAllFromProject retrieves the latest versions of several secrets from a specific
+// project concurrently using the default client, tolerating failures. See
+// Client.FetchAllFromProject.
+func FetchAllFromProject(ctx context.Context, pid string, names ...string) (map[string]string, map[string]error) {
+	return defaultClient.FetchAllFromProject(ctx, pid, names...)
+}
+
+// FetchAll retrieves the latest versions of several secrets from the current project
+// concurrently, tolerating failures. See FetchAllFromProject.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) FetchAll(ctx context.Context, names ...string) (map[string]string, map[string]error) {
+	p, err := c.projectID(ctx)
+	if err != nil {
+		failed := make(map[string]error, len(names))
+		for _, name := range names {
+			failed[name] = err
+		}
+		return map[string]string{}, failed
+	}
+
+	return c.FetchAllFromProject(ctx, p, names...)
+}
+
+// FetchAllFromProject retrieves the latest versions of several secrets from a specific
+// project concurrently, like FetchManyFromProject, but one failure doesn't discard the
+// rest: it returns the values fetched, keyed by secret name, and the error for each
+// secret that couldn't be fetched, such as a missing optional secret (test with
+// ErrNotFound). Every name appears in exactly one of the two maps; if the project or
+// an access token can't be resolved, every name maps to that error.
+func (c *Client) FetchAllFromProject(ctx context.Context, pid string, names ...string) (map[string]string, map[string]error) {
+	failed := map[string]error{}
+	var valid []string
+	for _, name := range names {
+		switch {
+		case !projectIDRegex.MatchString(pid):
+			failed[name] = fmt.Errorf("invalid project ID format: %q", pid)
+		case !secretNameRegex.MatchString(name):
+			failed[name] = errors.New("invalid secret name format")
+		default:
+			valid = append(valid, name)
+		}
+	}
+	if len(valid) == 0 {
+		return map[string]string{}, failed
+	}
+
+	values, errs := c.fetchEach(ctx, "", pid, valid)
+	maps.Copy(failed, errs)
+	return values, failed
+}
+
+// FetchAllWithPrefix retrieves the latest values of every secret in the current project
+// whose name starts with prefix using the default client, keyed by full secret name.
+// The project ID is auto-detected from the GCP metadata server.
+func FetchAllWithPrefix(ctx context.Context, prefix string) (map[string]string, error) {
+	return defaultClient.FetchAllWithPrefix(ctx, prefix)
+}
+
+// FetchAllWithPrefixFromProject retrieves the latest values of every secret in a specific
+// project whose name starts with prefix using the default client, keyed by full secret name.
+func FetchAllWithPrefixFromProject(ctx context.Context, pid, prefix string) (map[string]string, error) {
+	return defaultClient.FetchAllWithPrefixFromProject(ctx, pid, prefix)
+}
+
+// FetchAllWithPrefix retrieves the latest values of every secret in the current project
+// whose name starts with prefix, keyed by full secret name, so an application can load
+// its whole config namespace (e.g. "myapp-") in one call.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) FetchAllWithPrefix(ctx context.Context, prefix string) (map[string]string, error) {
+	p, err := c.projectID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.FetchAllWithPrefixFromProject(ctx, p, prefix)
+}
+
+// FetchAllWithPrefixFromProject retrieves the latest values of every secret in a specific
+// project whose name starts with prefix, keyed by full secret name. Listing requires
+// secretmanager.secrets.list. If any fetch fails, the errors are returned together.
+func (c *Client) FetchAllWithPrefixFromProject(ctx context.Context, pid, prefix string) (map[string]string, error) {
+	if !projectIDRegex.MatchString(pid) {
+		return nil, fmt.Errorf("invalid project ID format: %q", pid)
+	}
+	if prefix != "" && !secretNameRegex.MatchString(prefix) {
+		return nil, errors.New("invalid secret name prefix format")
+	}
+
+	t, err := c.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The name filter matches substrings, so prefixes are enforced client-side.
+	filter := ""
+	if prefix != "" {
+		filter = "name:" + prefix
+	}
+	secrets, err := c.listSecrets(ctx, t, pid, filter)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, s := range secrets {
+		if strings.HasPrefix(s.Name, prefix) {
+			names = append(names, s.Name)
+		}
+	}
+
+	return c.fetchMany(ctx, t, pid, names)
+}
+
+// StoreMany creates or updates several secrets in the current project concurrently
+// using the default client. See Client.StoreManyInProject.
+// The project ID is auto-detected from the GCP metadata server.
+func StoreMany(ctx context.Context, values map[string]string, opts ...StoreOption) (map[string]error, error) {
+	return defaultClient.StoreMany(ctx, values, opts...)
+}
+
+// StoreManyInProject creates or updates several secrets in a specific project
+// concurrently using the default client. See Client.StoreManyInProject.
+func StoreManyInProject(ctx context.Context, pid string, values map[string]string, opts ...StoreOption) (map[string]error, error) {
+	return defaultClient.StoreManyInProject(ctx, pid, values, opts...)
+}
+
+// StoreMany creates or updates several secrets in the current project concurrently.
+// See StoreManyInProject.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) StoreMany(ctx context.Context, values map[string]string, opts ...StoreOption) (map[string]error, error) {
+	p, err := c.projectID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.StoreManyInProject(ctx, p, values, opts...)
+}
+
+// StoreManyInProject creates or updates several secrets, given as name to value, in a
+// specific project with bounded concurrency and a single access token. Each secret is
+// stored as with StoreInProject, and one failing secret does not stop the others.
+// The result maps every name to its outcome: nil on success, otherwise the error,
+// typically a *StoreError. The error return is non-nil only if the batch could not
+// start at all, such as when no access token is available.
+func (c *Client) StoreManyInProject(ctx context.Context, pid string, values map[string]string, opts ...StoreOption) (map[string]error, error) {
+	if !projectIDRegex.MatchString(pid) {
+		return nil, fmt.Errorf("invalid project ID format: %q", pid)
+	}
+	return c.storeMany(ctx, pid, values, newStoreOptions(opts), storeAlways)
+}
+
+// storeMany stores values concurrently with a single access token, pacing writes
+// to the configured budget; see StoreManyInProject and WithCheckpoint.
+func (c *Client) storeMany(ctx context.Context, pid string, values map[string]string, o storeOptions, mode storeMode) (map[string]error, error) {
+	var cp *checkpoint
+	if o.checkpoint != "" {
+		var err error
+		if cp, err = openCheckpoint(o.checkpoint, c.log()); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make(map[string]error, len(values))
+	var valid []string
+	for name, value := range values {
+		sizeErr := checkPayloadSize(name, value)
+		switch {
+		case !secretNameRegex.MatchString(name):
+			results[name] = errors.New("invalid secret name format")
+		case sizeErr != nil:
+			results[name] = sizeErr
+		case cp != nil && cp.stored(name, value):
+			results[name] = nil
+		default:
+			valid = append(valid, name)
+		}
+	}
+	if len(valid) == 0 {
+		if cp != nil {
+			cp.close(len(results) == len(values) && !hasErrors(results))
+		}
+		return results, nil
+	}
+
+	sctx, cancel := o.stepContext(ctx, StepToken)
+	t, err := c.accessToken(sctx)
+	cancel()
+	if err != nil {
+		if cp != nil {
+			cp.close(false)
+		}
+		return nil, err
+	}
+
+	if skipped := len(values) - len(valid); cp != nil && skipped > 0 {
+		c.log().Info("resuming batch store from checkpoint", "skipped", skipped, "remaining", len(valid))
+	}
+	pacer := newWritePacer(o.writeBudget)
+	writes := 2
+	if mode == storeResume {
+		writes = 1
+	}
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, maxConcurrentFetches)
+	)
+	for _, name := range valid {
+		value := values[name]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			err := pacer.wait(ctx, writes)
+			if err == nil {
+				_, err = c.storeWithToken(ctx, t, pid, name, value, o, mode)
+			}
+			if err == nil && cp != nil {
+				cp.record(name, value)
+			}
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if cp != nil {
+		cp.close(!hasErrors(results))
+	}
+	return results, nil
+}
+
+// hasErrors reports whether any result is an error.
+func hasErrors(results map[string]error) bool {
+	for _, err := range results {
+		if err != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFetchMany(t *testing.T) {
+	var requests atomic.Int32
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		name := strings.Split(r.URL.Path, "/")[4]
+		if name == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writePayload(w, "projects/test-project/secrets/"+name+"/versions/1", "value-of-"+name)
+	})
+
+	ctx := context.Background()
+	got, err := FetchMany(ctx, "a", "b", "c", "a")
+	if err != nil {
+		t.Fatalf("FetchMany() unexpected error = %v", err)
+	}
+	want := map[string]string{"a": "value-of-a", "b": "value-of-b", "c": "value-of-c"}
+	if len(got) != len(want) {
+		t.Errorf("FetchMany() = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("FetchMany()[%q] = %q, want %q", k, got[k], v)
+		}
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("API requests = %d, want 3 (duplicates fetched once)", n)
+	}
+
+	_, err = FetchManyFromProject(ctx, "test-project", "a", "missing")
+	if err == nil || !strings.Contains(err.Error(), "secret missing") {
+		t.Errorf("FetchManyFromProject() error = %v, want error naming the missing secret", err)
+	}
+}
+
+func TestStoreMany(t *testing.T) {
+	var tokenRequests atomic.Int32
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("secretId") == "denied" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"projects/test-project/secrets/x/versions/1"}`)) //nolint:errcheck // test mock server
+	})
+	metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/project/project-id") {
+			_, _ = w.Write([]byte("test-project")) //nolint:errcheck // test mock server
+			return
+		}
+		tokenRequests.Add(1)
+		_, _ = w.Write([]byte(`{"access_token":"test-token"}`)) //nolint:errcheck // test mock server
+	}))
+	t.Cleanup(metadataServer.Close)
+	metadataURL = metadataServer.URL
+
+	results, err := StoreMany(context.Background(), map[string]string{
+		"a":        "1",
+		"b":        "2",
+		"denied":   "3",
+		"bad name": "4",
+	})
+	if err != nil {
+		t.Fatalf("StoreMany() unexpected error = %v", err)
+	}
+	if len(results) != 4 || results["a"] != nil || results["b"] != nil {
+		t.Errorf("StoreMany() results = %v, want a and b to succeed", results)
+	}
+	var se *StoreError
+	if !errors.As(results["denied"], &se) || se.Step != StepCreate {
+		t.Errorf("StoreMany() denied = %v, want create step StoreError", results["denied"])
+	}
+	if results["bad name"] == nil {
+		t.Error("StoreMany() accepted an invalid secret name")
+	}
+	if n := tokenRequests.Load(); n != 1 {
+		t.Errorf("token requests = %d, want 1", n)
+	}
+}
+
+func TestFetchAllWithPrefix(t *testing.T) {
+	var filters []string
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/projects/test-project/secrets" {
+			filters = append(filters, r.URL.Query().Get("filter"))
+			if r.URL.Query().Get("pageToken") == "" {
+				_, _ = w.Write([]byte(`{"secrets":[{"name":"projects/test-project/secrets/myapp-db"},{"name":"projects/test-project/secrets/other-myapp-x"}],"nextPageToken":"p2"}`)) //nolint:errcheck // test mock server
+				return
+			}
+			_, _ = w.Write([]byte(`{"secrets":[{"name":"projects/test-project/secrets/myapp-api"}]}`)) //nolint:errcheck // test mock server
+			return
+		}
+		name := strings.Split(r.URL.Path, "/")[4]
+		writePayload(w, "projects/test-project/secrets/"+name+"/versions/1", "value-of-"+name)
+	})
+
+	got, err := FetchAllWithPrefix(context.Background(), "myapp-")
+	if err != nil {
+		t.Fatalf("FetchAllWithPrefix() unexpected error = %v", err)
+	}
+	if len(got) != 2 || got["myapp-db"] != "value-of-myapp-db" || got["myapp-api"] != "value-of-myapp-api" {
+		t.Errorf("FetchAllWithPrefix() = %v, want myapp-db and myapp-api only", got)
+	}
+	if len(filters) != 2 || filters[0] != "name:myapp-" {
+		t.Errorf("list filters = %q, want two pages filtered by name:myapp-", filters)
+	}
+}
+
+func TestFetchAll(t *testing.T) {
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		name := strings.Split(r.URL.Path, "/")[4]
+		if name == "optional" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writePayload(w, "projects/test-project/secrets/"+name+"/versions/1", "value-of-"+name)
+	})
+
+	ctx := context.Background()
+	values, errs := FetchAll(ctx, "a", "optional", "b", "bad/name")
+	if len(values) != 2 || values["a"] != "value-of-a" || values["b"] != "value-of-b" {
+		t.Errorf("FetchAll() values = %v, want a and b", values)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("FetchAll() errors = %v, want optional and bad/name", errs)
+	}
+	if !errors.Is(errs["optional"], ErrNotFound) {
+		t.Errorf("FetchAll() error for optional = %v, want ErrNotFound", errs["optional"])
+	}
+	if err := errs["bad/name"]; err == nil || !strings.Contains(err.Error(), "invalid secret name format") {
+		t.Errorf("FetchAll() error for bad/name = %v, want invalid name", err)
+	}
+
+	values, errs = FetchAllFromProject(ctx, "Bad Project", "a")
+	if len(values) != 0 || errs["a"] == nil || !strings.Contains(errs["a"].Error(), "invalid project ID format") {
+		t.Errorf("FetchAllFromProject() = %v, %v, want invalid project error for a", values, errs)
+	}
+}
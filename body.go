@@ -0,0 +1,47 @@
+package gsm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+)
+
+// readBody reads up to maxBodySize bytes of a response body. If ctx is done
+// mid-read, the body is closed to unblock the read and ctx's error is returned
+// at once, rather than after a slow server finishes sending or the transport
+// times out. The caller still closes the body.
+func readBody(ctx context.Context, body io.ReadCloser) ([]byte, error) {
+	stop := context.AfterFunc(ctx, func() {
+		body.Close() //nolint:errcheck,gosec // best effort close
+	})
+	defer stop()
+
+	data, err := io.ReadAll(&ctxReader{ctx: ctx, r: io.LimitReader(body, maxBodySize)})
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return data, err
+}
+
+// decodeBody reads a JSON response body into v; see readBody.
+func decodeBody(ctx context.Context, body io.ReadCloser, v any) error {
+	data, err := readBody(ctx, body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// ctxReader stops reading once ctx is done, even if the underlying reader
+// still has buffered data.
+type ctxReader struct {
+	ctx context.Context //nolint:containedctx // scoped to a single read
+	r   io.Reader
+}
+
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
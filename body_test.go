@@ -0,0 +1,46 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadBody(t *testing.T) {
+	data, err := readBody(context.Background(), io.NopCloser(strings.NewReader(strings.Repeat("x", maxBodySize+10))))
+	if err != nil || len(data) != maxBodySize {
+		t.Errorf("readBody() = %d bytes, %v, want %d bytes", len(data), err, maxBodySize)
+	}
+
+	var v struct{ Name string }
+	if err := decodeBody(context.Background(), io.NopCloser(strings.NewReader(`{"Name":"x"}`)), &v); err != nil || v.Name != "x" {
+		t.Errorf("decodeBody() = %+v, %v", v, err)
+	}
+}
+
+func TestReadBodyCanceled(t *testing.T) {
+	// A body that sends a little and then stalls, like a slow server.
+	pr, pw := io.Pipe()
+	go func() {
+		_, _ = pw.Write([]byte("partial")) //nolint:errcheck // test writer
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := readBody(ctx, pr)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("readBody() error = %v, want context.Canceled", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("readBody() returned after %v, want prompt abort", d)
+	}
+
+	if _, err := readBody(ctx, io.NopCloser(strings.NewReader("buffered"))); !errors.Is(err, context.Canceled) {
+		t.Errorf("readBody() with done context = %v, want context.Canceled", err)
+	}
+}
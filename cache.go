@@ -0,0 +1,255 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// valueCache holds fetched secret values for a fixed TTL.
+type valueCache struct {
+	entries map[string]cacheEntry
+	ttl     time.Duration
+	mu      sync.Mutex
+}
+
+type cacheEntry struct {
+	expires time.Time
+	value   string
+	version string
+}
+
+// WithCache caches fetched secret values for ttl, so repeated fetches of the
+// same secret are served from memory instead of calling Secret Manager.
+// Values written through the client invalidate the cached entry.
+func WithCache(ttl time.Duration) Option {
+	return func(c *Client) {
+		c.cache = &valueCache{ttl: ttl, entries: map[string]cacheEntry{}}
+	}
+}
+
+func cacheKey(pid, name string) string {
+	return pid + "/" + name
+}
+
+// get returns the cached entry for key if it has not expired.
+func (vc *valueCache) get(key string) (cacheEntry, bool) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	e, ok := vc.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		return cacheEntry{}, false
+	}
+	return e, true
+}
+
+// stale returns the entry for key, expired or not, if it expired less than maxAge ago.
+func (vc *valueCache) stale(key string, maxAge time.Duration) (cacheEntry, bool) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	e, ok := vc.entries[key]
+	if !ok || time.Since(e.expires) > maxAge {
+		return cacheEntry{}, false
+	}
+	return e, true
+}
+
+func (vc *valueCache) put(key, value, version string) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	vc.entries[key] = cacheEntry{expires: time.Now().Add(vc.ttl), value: value, version: version}
+}
+
+func (vc *valueCache) invalidate(key string) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	delete(vc.entries, key)
+}
+
+// WithStaleIfError serves the last value fetched for a secret, for up to maxAge
+// after its cache entry expired, when fetching it again fails because Secret
+// Manager or the token source is unavailable, keeping services running through
+// transient incidents. Client errors such as not found or permission denied are
+// still returned. Stale values are logged as warnings and audited with the
+// "stale" operation. Without WithCache, values are remembered but not reused
+// while fetches succeed.
+func WithStaleIfError(maxAge time.Duration) Option {
+	return func(c *Client) {
+		c.staleFor = maxAge
+	}
+}
+
+// staleValue records a failed fetch and returns the last value fetched for the
+// secret if it failed with err and the client serves stale values.
+func (c *Client) staleValue(ctx context.Context, pid, name string, err error) (string, bool) {
+	c.stats.refreshFailures.Add(1)
+	if c.staleFor <= 0 || c.cache == nil || ctx.Err() != nil {
+		return "", false
+	}
+	var se *APIError
+	if errors.As(err, &se) {
+		return "", false
+	}
+	e, ok := c.cache.stale(cacheKey(pid, name), c.staleFor)
+	if !ok && c.disk != nil {
+		var de diskEntry
+		if de, ok = c.disk.stale(cacheKey(pid, name), c.staleFor); ok {
+			e = cacheEntry{expires: de.Expires, value: de.Value, version: de.Version}
+		}
+	}
+	if !ok {
+		return "", false
+	}
+	c.stats.staleServes.Add(1)
+	c.log().Warn("serving stale secret value", "project", pid, "secret", name, "version", e.version, "expired", e.expires, "error", err)
+	c.emitAudit(ctx, "stale", pid, name, e.version)
+	return e.value, true
+}
+
+// CacheStats counts how fetches were served, for tuning cache TTLs. Counts
+// cover FetchFromProject, FetchMany, and FetchAll, since the client was created.
+type CacheStats struct {
+	// Hits are fetches served from memory by WithCache.
+	Hits uint64
+	// DiskHits are fetches served from disk by WithDiskCache.
+	DiskHits uint64
+	// NegativeHits are fetches answered with a cached not-found error by
+	// WithNegativeCache.
+	NegativeHits uint64
+	// Misses are fetches that called Secret Manager.
+	Misses uint64
+	// RefreshFailures are misses where calling Secret Manager failed.
+	RefreshFailures uint64
+	// StaleServes are failed misses answered with a stale value by WithStaleIfError.
+	StaleServes uint64
+}
+
+// cacheStats holds the counters behind CacheStats.
+type cacheStats struct {
+	hits, diskHits, negativeHits, misses, refreshFailures, staleServes atomic.Uint64
+}
+
+// CacheStats returns a snapshot of the client's cache counters.
+func (c *Client) CacheStats() CacheStats {
+	s := &c.stats
+	return CacheStats{
+		Hits:            s.hits.Load(),
+		DiskHits:        s.diskHits.Load(),
+		NegativeHits:    s.negativeHits.Load(),
+		Misses:          s.misses.Load(),
+		RefreshFailures: s.refreshFailures.Load(),
+		StaleServes:     s.staleServes.Load(),
+	}
+}
+
+// missCache remembers secrets that were not found for a fixed TTL.
+type missCache struct {
+	entries map[string]missEntry
+	ttl     time.Duration
+	mu      sync.Mutex
+}
+
+type missEntry struct {
+	expires time.Time
+	err     error
+}
+
+// WithNegativeCache remembers secrets that don't exist for ttl, so repeated
+// fetches of an absent optional secret, such as a feature flag or per-tenant
+// override, return the cached not-found error (see ErrNotFound) instead of
+// calling Secret Manager each time. Keep ttl short: secrets created elsewhere
+// are seen only once it lapses. Writes through the client clear the entry.
+func WithNegativeCache(ttl time.Duration) Option {
+	return func(c *Client) {
+		c.misses = &missCache{ttl: ttl, entries: map[string]missEntry{}}
+	}
+}
+
+// get returns the cached not-found error for key, or nil if there is none.
+func (mc *missCache) get(key string) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	e, ok := mc.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		return nil
+	}
+	return e.err
+}
+
+func (mc *missCache) put(key string, err error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.entries[key] = missEntry{expires: time.Now().Add(mc.ttl), err: err}
+}
+
+func (mc *missCache) invalidate(key string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	delete(mc.entries, key)
+}
+
+// cached returns a secret's cached value, reporting whether there was one, or
+// its cached not-found error.
+func (c *Client) cached(ctx context.Context, pid, name string) (string, bool, error) {
+	key := cacheKey(pid, name)
+	if c.cache != nil {
+		if e, ok := c.cache.get(key); ok {
+			c.stats.hits.Add(1)
+			c.emitAudit(ctx, "cache", pid, name, e.version)
+			return e.value, true, nil
+		}
+	}
+	if c.misses != nil {
+		if err := c.misses.get(key); err != nil {
+			c.stats.negativeHits.Add(1)
+			return "", false, err
+		}
+	}
+	if c.disk != nil {
+		if e, ok := c.disk.get(key); ok {
+			c.stats.diskHits.Add(1)
+			if c.cache != nil {
+				c.cache.put(key, e.Value, e.Version)
+			}
+			c.emitAudit(ctx, "cache", pid, name, e.Version)
+			return e.Value, true, nil
+		}
+	}
+	c.stats.misses.Add(1)
+	return "", false, nil
+}
+
+// remember caches a fetched value in memory and on disk, as configured.
+func (c *Client) remember(pid, name, value, version string) {
+	key := cacheKey(pid, name)
+	if c.cache != nil {
+		c.cache.put(key, value, version)
+	}
+	if c.disk != nil {
+		if err := c.disk.put(key, value, version); err != nil {
+			c.log().Warn("failed to write disk cache", "secret", name, "error", err)
+		}
+	}
+}
+
+// invalidateCached drops anything cached about a secret after it is written or deleted.
+func (c *Client) invalidateCached(pid, name string) {
+	if c.cache != nil {
+		c.cache.invalidate(cacheKey(pid, name))
+	}
+	if c.misses != nil {
+		c.misses.invalidate(cacheKey(pid, name))
+	}
+	if c.disk != nil {
+		c.disk.invalidate(cacheKey(pid, name))
+	}
+}
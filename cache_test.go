@@ -0,0 +1,186 @@
+package gsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientCache(t *testing.T) {
+	accesses := 0
+	value := "v1"
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, ":access"):
+			accesses++
+			writePayload(w, "projects/test-project/secrets/test-secret/versions/1", value)
+		case r.URL.Query().Get("secretId") != "":
+			w.WriteHeader(http.StatusConflict)
+		case strings.HasSuffix(r.URL.Path, ":addVersion"):
+			_ = json.NewEncoder(w).Encode(map[string]string{"name": "projects/test-project/secrets/test-secret/versions/2"}) //nolint:errcheck // test mock server
+		}
+	})
+
+	ctx := context.Background()
+	c := New(WithCache(time.Hour))
+
+	for range 3 {
+		got, err := c.FetchFromProject(ctx, "test-project", "test-secret")
+		if err != nil {
+			t.Fatalf("FetchFromProject() unexpected error = %v", err)
+		}
+		if got != "v1" {
+			t.Errorf("FetchFromProject() = %q, want v1", got)
+		}
+	}
+	if accesses != 1 {
+		t.Errorf("got %d API accesses, want 1 with caching", accesses)
+	}
+
+	// Writes through the client invalidate the cached value.
+	value = "v2"
+	if err := c.StoreInProject(ctx, "test-project", "test-secret", "v2"); err != nil {
+		t.Fatalf("StoreInProject() unexpected error = %v", err)
+	}
+	got, err := c.FetchFromProject(ctx, "test-project", "test-secret")
+	if err != nil {
+		t.Fatalf("FetchFromProject() unexpected error = %v", err)
+	}
+	if got != "v2" || accesses != 2 {
+		t.Errorf("after store: got %q with %d accesses, want v2 with 2", got, accesses)
+	}
+}
+
+func TestValueCacheExpiry(t *testing.T) {
+	vc := &valueCache{ttl: time.Millisecond, entries: map[string]cacheEntry{}}
+	vc.put("p/s", "v", "1")
+	if _, ok := vc.get("p/s"); !ok {
+		t.Fatal("get() missed fresh entry")
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := vc.get("p/s"); ok {
+		t.Error("get() returned expired entry")
+	}
+}
+
+func TestNegativeCache(t *testing.T) {
+	accesses := 0
+	exists := false
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, ":access"):
+			accesses++
+			if !exists {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			writePayload(w, "projects/test-project/secrets/flag/versions/1", "on")
+		case r.URL.Query().Get("secretId") != "":
+			exists = true
+			_, _ = w.Write([]byte(`{"name":"projects/test-project/secrets/flag"}`)) //nolint:errcheck // test mock server
+		case strings.HasSuffix(r.URL.Path, ":addVersion"):
+			_, _ = w.Write([]byte(`{"name":"projects/test-project/secrets/flag/versions/1"}`)) //nolint:errcheck // test mock server
+		}
+	})
+
+	ctx := context.Background()
+	c := New(WithNegativeCache(time.Hour))
+	for range 3 {
+		if _, err := c.FetchFromProject(ctx, "test-project", "flag"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("FetchFromProject() error = %v, want ErrNotFound", err)
+		}
+	}
+	if _, errs := c.FetchAllFromProject(ctx, "test-project", "flag"); !errors.Is(errs["flag"], ErrNotFound) {
+		t.Errorf("FetchAllFromProject() errors = %v, want ErrNotFound for flag", errs)
+	}
+	if accesses != 1 {
+		t.Errorf("got %d API accesses, want 1 with negative caching", accesses)
+	}
+
+	// Creating the secret through the client clears the cached miss.
+	if err := c.StoreInProject(ctx, "test-project", "flag", "on"); err != nil {
+		t.Fatalf("StoreInProject() unexpected error = %v", err)
+	}
+	if got, err := c.FetchFromProject(ctx, "test-project", "flag"); err != nil || got != "on" {
+		t.Errorf("FetchFromProject() after store = %q, %v, want on", got, err)
+	}
+}
+
+func TestStaleIfError(t *testing.T) {
+	status := http.StatusOK
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		writePayload(w, "projects/test-project/secrets/s/versions/3", "v3")
+	})
+
+	ctx := context.Background()
+	var ops []string
+	c := New(
+		WithStaleIfError(time.Hour),
+		WithAPIRetry(RetryPolicy{Attempts: 1}),
+		WithAuditHook(func(_ context.Context, ev AuditEvent) { ops = append(ops, ev.Operation+":"+ev.Version) }),
+	)
+	if _, err := c.FetchFromProject(ctx, "test-project", "s"); err != nil {
+		t.Fatalf("FetchFromProject() unexpected error = %v", err)
+	}
+
+	status = http.StatusServiceUnavailable
+	if got, err := c.FetchFromProject(ctx, "test-project", "s"); err != nil || got != "v3" {
+		t.Errorf("FetchFromProject() during outage = %q, %v, want stale v3", got, err)
+	}
+	if values, errs := c.FetchAllFromProject(ctx, "test-project", "s"); len(errs) != 0 || values["s"] != "v3" {
+		t.Errorf("FetchAllFromProject() during outage = %v, %v, want stale v3", values, errs)
+	}
+	if _, err := c.FetchFromProject(ctx, "test-project", "other"); err == nil {
+		t.Error("FetchFromProject() of a never-fetched secret succeeded, want error")
+	}
+	if strings.Join(ops, ",") != "fetch:3,stale:3,stale:3" {
+		t.Errorf("audit operations = %v, want a fetch then stale reads", ops)
+	}
+
+	status = http.StatusForbidden
+	if _, err := c.FetchFromProject(ctx, "test-project", "s"); err == nil {
+		t.Error("FetchFromProject() after permission loss succeeded, want error")
+	}
+}
+
+func TestCacheStats(t *testing.T) {
+	status := http.StatusOK
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/secrets/missing/") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		writePayload(w, "projects/test-project/secrets/s/versions/1", "v")
+	})
+
+	ctx := context.Background()
+	c := New(WithCache(time.Hour), WithNegativeCache(time.Hour), WithStaleIfError(time.Hour), WithAPIRetry(RetryPolicy{Attempts: 1}))
+	for range 3 {
+		c.FetchFromProject(ctx, "test-project", "s")       //nolint:errcheck // counted below
+		c.FetchFromProject(ctx, "test-project", "missing") //nolint:errcheck // counted below
+	}
+	c.cache.entries[cacheKey("test-project", "s")] = cacheEntry{expires: time.Now().Add(-time.Minute), value: "v"}
+	status = http.StatusServiceUnavailable
+	c.FetchFromProject(ctx, "test-project", "s") //nolint:errcheck // counted below
+	c.FetchFromProject(ctx, "test-project", "s") //nolint:errcheck // counted below
+
+	// A cached miss in a batch makes no request, so unlike s it is no refresh failure.
+	c.FetchAllFromProject(ctx, "test-project", "s", "missing")
+
+	want := CacheStats{Hits: 2, NegativeHits: 3, Misses: 5, RefreshFailures: 4, StaleServes: 3}
+	if got := c.CacheStats(); got != want {
+		t.Errorf("CacheStats() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,321 @@
+package gsm
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/tls"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client accesses Secret Manager with a particular configuration.
+// Create clients with New; the package-level functions use a default client.
+// A Client is safe for concurrent use.
+type Client struct {
+	audit            AuditFunc
+	logger           *slog.Logger
+	redactLogs       bool
+	health           health
+	stats            cacheStats
+	cache            *valueCache
+	misses           *missCache
+	disk             *diskCache
+	staleFor         time.Duration
+	creds            *credentials
+	credsPath        string
+	emulator         bool
+	endpoint         string
+	identityAudience string
+	identityAuth     string
+	identityHeader   bool
+	manifestKey      []byte
+	payloadCipher    payloadCipher
+	scopes           []string
+	metadataURL      string
+	metadataAccount  string
+	metadataRetry    RetryPolicy
+	apiRetry         RetryPolicy
+	retryHook        RetryFunc
+	tracer           Tracer
+	apiClient        *http.Client
+	httpClient       *http.Client
+	middleware       []Middleware
+	apiVIP           string
+	proxy            func(*http.Request) (*url.URL, error)
+	proxySet         bool
+	tlsConfig        *tls.Config
+	tlsPins          []string
+	tlsMinVersion    uint16
+	tokenCache       TokenCache
+	watchState       WatchState
+
+	mu       sync.Mutex
+	identity *cachedIdentity
+	idTokens map[string]*cachedIdentity
+	saEmail  string
+	// instKey is the rollout instance key, once instKeySet.
+	instKey    string
+	instKeySet bool
+
+	// auditEmail is the service account recorded in audit events, set by
+	// auditOnce.
+	auditOnce  sync.Once
+	auditEmail string
+
+	// tokenMu guards the metadata server access token, and is held while it
+	// is refreshed so concurrent callers share one request.
+	tokenMu      sync.Mutex
+	token        string
+	tokenExpires time.Time
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+var defaultClient = New()
+
+// New returns a Client configured by opts.
+// If $GSM_EMULATOR_HOST is set, the client talks to that emulator; see EmulatorHostEnv.
+//
+// Otherwise credentials are resolved in this order: the file given to
+// WithCredentialsFile, the key file named by $GOOGLE_APPLICATION_CREDENTIALS,
+// the gcloud Application Default Credentials file, and finally the metadata
+// server. Off GCP with none of these, calls fail at once with ErrNoCredentials
+// rather than retrying the metadata server.
+func New(opts ...Option) *Client {
+	c := &Client{}
+	if host := os.Getenv(EmulatorHostEnv); host != "" {
+		c.emulator = true
+		c.endpoint = emulatorEndpoint(host)
+	} else {
+		c.credsPath = cmp.Or(os.Getenv(CredentialsEnv), wellKnownCredentials())
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.staleFor > 0 && c.cache == nil {
+		c.cache = &valueCache{entries: map[string]cacheEntry{}}
+	}
+	if c.proxySet {
+		proxy := c.proxy
+		c.httpClient = withTransport(c.baseHTTP(), func(t *http.Transport) { t.Proxy = proxy })
+	}
+	if c.apiVIP != "" {
+		vip := c.apiVIP
+		c.httpClient = withTransport(c.baseHTTP(), func(t *http.Transport) {
+			dial := t.DialContext
+			if dial == nil {
+				dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+			}
+			t.DialContext = vipDial(vip, dial)
+		})
+	}
+	if c.tlsConfig != nil {
+		cfg := c.tlsConfig
+		c.httpClient = withTransport(c.baseHTTP(), func(t *http.Transport) { t.TLSClientConfig = cfg.Clone() })
+	}
+	if c.tlsMinVersion != 0 || len(c.tlsPins) > 0 {
+		c.apiClient = c.hardenedClient()
+	}
+	if len(c.middleware) > 0 {
+		if c.apiClient != nil {
+			c.apiClient = c.wrap(c.apiClient)
+		}
+		c.httpClient = c.wrap(c.baseHTTP())
+	}
+	if c.disk != nil {
+		c.disk.log = c.log()
+	}
+	return c
+}
+
+// WithEndpoint sends Secret Manager API requests to url instead of
+// https://secretmanager.googleapis.com/v1, e.g. a Private Service Connect
+// endpoint, a proxy, or a fake in tests. url includes the API version.
+func WithEndpoint(url string) Option {
+	return func(c *Client) {
+		c.endpoint = strings.TrimSuffix(url, "/")
+	}
+}
+
+// Virtual IP domains for WithGoogleAPIsVIP.
+const (
+	// PrivateGoogleAPIs is the Private Google Access VIP, for VMs without
+	// external IP addresses.
+	PrivateGoogleAPIs = "private.googleapis.com"
+	// RestrictedGoogleAPIs is the VIP for projects inside a VPC Service
+	// Controls perimeter, which can't reach the default endpoints.
+	RestrictedGoogleAPIs = "restricted.googleapis.com"
+)
+
+// WithGoogleAPIsVIP connects to *.googleapis.com hosts, the Secret Manager and
+// OAuth token endpoints among them, through vip, usually PrivateGoogleAPIs or
+// RestrictedGoogleAPIs, as if DNS for googleapis.com pointed there. URLs, and
+// so TLS server names, are unchanged. It suits networks without that DNS
+// configuration, and configures a copy of the HTTP client's transport, like
+// WithTLSConfig.
+func WithGoogleAPIsVIP(vip string) Option {
+	return func(c *Client) {
+		c.apiVIP = vip
+	}
+}
+
+// vipDial returns a dial function that connects to vip, on the same port, in
+// place of any googleapis.com host, and to other addresses with dial.
+func vipDial(vip string, dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if host, port, err := net.SplitHostPort(addr); err == nil && strings.HasSuffix(host, ".googleapis.com") {
+			addr = net.JoinHostPort(vip, port)
+		}
+		return dial(ctx, network, addr)
+	}
+}
+
+// WithMetadataEndpoint reads the project ID, access tokens, and instance identity from
+// url instead of http://metadata.google.internal/computeMetadata/v1.
+func WithMetadataEndpoint(url string) Option {
+	return func(c *Client) {
+		c.metadataURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithHTTPClient sends metadata server, token endpoint, and Secret Manager API
+// requests through hc instead of the package's shared client, e.g. to use a
+// custom dialer or instrument requests. hc's Timeout bounds each attempt.
+// WithProxy, WithTLSConfig, WithMinTLSVersion, and WithPinnedKeys configure a
+// copy of hc's transport, which must then be an *http.Transport (or nil for the
+// default) to be kept.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// Middleware wraps the transport of a client's HTTP requests, e.g. to add
+// headers, sign requests, or log them.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// WithMiddleware wraps the transport of every metadata server, token endpoint,
+// and Secret Manager API request the client makes. The first middleware is the
+// outermost: it sees each request first and each response last. It applies
+// on top of WithHTTPClient, WithMinTLSVersion, and WithPinnedKeys. Each
+// attempt of a retried request passes through the chain.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *Client) {
+		c.middleware = append(c.middleware, mw...)
+	}
+}
+
+// wrap returns a copy of hc whose transport passes through the client's middleware.
+func (c *Client) wrap(hc *http.Client) *http.Client {
+	wrapped := *hc
+	rt := wrapped.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	for _, mw := range slices.Backward(c.middleware) {
+		rt = mw(rt)
+	}
+	wrapped.Transport = rt
+	return &wrapped
+}
+
+// baseHTTP returns the HTTP client for requests, before any TLS hardening.
+func (c *Client) baseHTTP() *http.Client {
+	if c.httpClient != nil {
+		return c.httpClient
+	}
+	return httpClient
+}
+
+// withTransport returns a copy of hc whose transport is a copy of hc's, with a
+// copied, non-nil TLSClientConfig, modified by configure. A transport other than
+// *http.Transport can't be configured, so the default transport replaces it.
+func withTransport(hc *http.Client, configure func(*http.Transport)) *http.Client {
+	out := *hc
+	base, ok := out.Transport.(*http.Transport)
+	if !ok {
+		base = http.DefaultTransport.(*http.Transport) //nolint:errcheck,forcetypeassert // always an *http.Transport
+	}
+	t := base.Clone()
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{} //nolint:gosec // MinVersion is set by WithMinTLSVersion when requested
+	}
+	configure(t)
+
+	out.Transport = t
+	return &out
+}
+
+// WithServiceAccount authenticates through the metadata server as email, one of
+// several service accounts attached to the instance, instead of its default
+// account. It applies to access and identity tokens, not to credentials files.
+func WithServiceAccount(email string) Option {
+	return func(c *Client) {
+		c.metadataAccount = email
+	}
+}
+
+// serviceAccountPath is the metadata server directory of the client's service account.
+func (c *Client) serviceAccountPath() string {
+	return "/instance/service-accounts/" + url.PathEscape(cmp.Or(c.metadataAccount, "default"))
+}
+
+// apiEndpoint returns the base URL of the Secret Manager API.
+func (c *Client) apiEndpoint() string {
+	if c.endpoint != "" {
+		return c.endpoint
+	}
+	return apiURL
+}
+
+// metadataEndpoint returns the base URL of the metadata server.
+func (c *Client) metadataEndpoint() string {
+	if c.metadataURL != "" {
+		return c.metadataURL
+	}
+	return metadataURL
+}
+
+// apiRequest builds an authenticated Secret Manager API request.
+// A non-nil body is sent as JSON.
+func (c *Client) apiRequest(ctx context.Context, method, url, tok string, body []byte) (*http.Request, error) {
+	if err := c.allowRequest(); err != nil {
+		return nil, err
+	}
+	var r io.Reader = http.NoBody
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+tok)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if creds, _ := c.credentials(); creds != nil && creds.file.QuotaProjectID != "" { //nolint:errcheck // reported by accessToken
+		// User credentials bill API usage to a quota project rather than the OAuth client's.
+		req.Header.Set("X-Goog-User-Project", creds.file.QuotaProjectID)
+	}
+
+	if c.identityHeader {
+		_, raw, err := c.instanceIdentity(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(IdentityHeader, raw)
+	}
+	return req, nil
+}
@@ -0,0 +1,168 @@
+package gsm
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingTransport records the paths of requests before sending them.
+type recordingTransport struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	rt.paths = append(rt.paths, req.URL.Path)
+	rt.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		writePayload(w, "projects/test-project/secrets/s/versions/1", "v")
+	})
+
+	rt := &recordingTransport{}
+	c := New(WithHTTPClient(&http.Client{Transport: rt, Timeout: 5 * time.Second}))
+	if _, err := c.Fetch(context.Background(), "s"); err != nil {
+		t.Fatalf("Fetch() unexpected error = %v", err)
+	}
+
+	want := []string{
+		"/project/project-id",
+		"/instance/service-accounts/default/token",
+		"/projects/test-project/secrets/s/versions/latest:access",
+	}
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if len(rt.paths) != len(want) {
+		t.Fatalf("requests through the custom client = %v, want %v", rt.paths, want)
+	}
+	for i, p := range want {
+		if rt.paths[i] != p {
+			t.Errorf("request %d = %s, want %s", i, rt.paths[i], p)
+		}
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		writePayload(w, "projects/test-project/secrets/s/versions/1", "v")
+	})
+
+	var def bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&def, nil)))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	var custom bytes.Buffer
+	c := New(WithLogger(slog.New(slog.NewTextHandler(&custom, nil))))
+	if _, err := c.FetchFromProject(context.Background(), "test-project", "s"); err != nil {
+		t.Fatalf("FetchFromProject() error = %v", err)
+	}
+	if !strings.Contains(custom.String(), "secret accessed successfully") || def.Len() != 0 {
+		t.Errorf("custom log = %q, default log = %q, want records only in the custom log", custom.String(), def.String())
+	}
+
+	c = New(WithLogger(nil))
+	if _, err := c.FetchFromProject(context.Background(), "test-project", "s"); err != nil {
+		t.Fatalf("FetchFromProject() error = %v", err)
+	}
+	if def.Len() != 0 {
+		t.Errorf("default log = %q, want nothing with logging disabled", def.String())
+	}
+}
+
+func TestWithRedactedLogs(t *testing.T) {
+	var buf bytes.Buffer
+	c := New(WithLogger(slog.New(slog.NewTextHandler(&buf, nil))), WithRedactedLogs())
+	err := errors.New(`Get "https://secretmanager.googleapis.com/v1/projects/acme-prod/secrets/db-password/versions/latest:access": EOF`)
+	c.log().With("project", "acme-prod").Warn("failed", "secret", "db-password", "error", err, "attempt", 2)
+
+	out := buf.String()
+	if strings.Contains(out, "acme-prod") || strings.Contains(out, "db-password") {
+		t.Errorf("log = %q, want secret names and project IDs redacted", out)
+	}
+	for _, want := range []string{"project=" + redact("acme-prod"), "secret=" + redact("db-password"), "projects/" + redact("acme-prod") + "/secrets/" + redact("db-password") + "/versions/latest:access", "attempt=2"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log = %q, want it to contain %q", out, want)
+		}
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestWithMiddleware(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Get("X-Trace"))
+		mu.Unlock()
+		writePayload(w, "projects/test-project/secrets/s/versions/1", "v")
+	})
+
+	tag := func(v string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				req = req.Clone(req.Context())
+				req.Header.Set("X-Trace", req.Header.Get("X-Trace")+v)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	rt := &recordingTransport{}
+	c := New(WithHTTPClient(&http.Client{Transport: rt}), WithMiddleware(tag("a"), tag("b")), WithMinTLSVersion(tls.VersionTLS12))
+	if _, err := c.Fetch(context.Background(), "s"); err != nil {
+		t.Fatalf("Fetch() unexpected error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seen) != 1 || seen[0] != "ab" {
+		t.Errorf("API request headers = %q, want the middleware applied in order", seen)
+	}
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if len(rt.paths) != 2 {
+		t.Errorf("requests through the custom client = %v, want the metadata requests", rt.paths)
+	}
+}
+
+func TestWithGoogleAPIsVIP(t *testing.T) {
+	setupFakes(t, nil)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Host, "secretmanager.googleapis.com:") {
+			t.Errorf("Host = %q, want the googleapis.com name", r.Host)
+		}
+		writePayload(w, "projects/test-project/secrets/s/versions/1", "value")
+	}))
+	t.Cleanup(srv.Close)
+	_, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The loopback address stands in for the VIP.
+	c := New(WithGoogleAPIsVIP("127.0.0.1"), WithEndpoint("http://secretmanager.googleapis.com:"+port+"/v1"))
+	v, err := c.FetchFromProject(context.Background(), "test-project", "s")
+	if err != nil || v != "value" {
+		t.Errorf("FetchFromProject() = %q, %v, want value", v, err)
+	}
+}
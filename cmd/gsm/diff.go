@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/codeGROOVE-dev/gsm"
+)
+
+// diffCmd compares the secrets of two projects, or of a local file and a
+// project, and, with --apply, copies missing and changed secrets to the
+// destination.
+func diffCmd(ctx context.Context, args []string, stdout io.Writer, c *gsm.Client) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	filter := fs.String("filter", "", "Secret Manager list filter selecting the secrets to compare")
+	apply := fs.Bool("apply", false, "copy missing and changed secrets to the destination")
+	del := fs.Bool("delete", false, "with --apply, delete secrets only in the destination")
+	file := fs.String("from-file", "", "compare a local .env, JSON, or YAML file, as read by import, instead of a source project")
+	format := fs.String("format", "", "with --from-file, the file format: dotenv, json, or yaml (default: from the file name)")
+	projects, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	want := 2
+	if *file != "" {
+		want = 1
+	}
+	if len(projects) != want || (*del && !*apply) {
+		return errors.New("usage: gsm diff [--filter F] [--apply [--delete]] SOURCE DESTINATION\n" +
+			"       gsm diff --from-file FILE [--format F] [--filter F] [--apply [--delete]] DESTINATION")
+	}
+
+	if *file != "" {
+		return diffFile(ctx, stdout, c, *file, gsm.ImportFormat(*format), projects[0], *filter, *apply, *del)
+	}
+
+	d, err := c.DiffProjects(ctx, projects[0], projects[1], *filter)
+	if err != nil {
+		return err
+	}
+	writeDiff(stdout, d)
+	if !*apply {
+		return nil
+	}
+
+	r, err := c.ApplyDiff(ctx, d, *del)
+	if err != nil {
+		return err
+	}
+	writeSyncReport(stdout, r)
+	return r.Err()
+}
+
+// diffFile is diffCmd with a local file as the source.
+func diffFile(ctx context.Context, stdout io.Writer, c *gsm.Client, path string, f gsm.ImportFormat, dst, filter string, apply, del bool) error {
+	if f == "" {
+		var err error
+		if f, err = gsm.ImportFormatOf(path); err != nil {
+			return err
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	values, err := gsm.ParseImport(data, f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	d, err := c.DiffValues(ctx, values, dst, filter)
+	if err != nil {
+		return err
+	}
+	d.Source = path
+	writeDiff(stdout, d)
+	if !apply {
+		return nil
+	}
+
+	r, err := c.ApplyValues(ctx, d, values, del)
+	if err != nil {
+		return err
+	}
+	writeSyncReport(stdout, r)
+	return r.Err()
+}
+
+// diffMarks prefixes each line of a diff, after the convention of diff(1).
+var diffMarks = map[gsm.DiffStatus]string{gsm.DiffMissing: "+", gsm.DiffChanged: "~", gsm.DiffExtra: "-"}
+
+// writeDiff prints one line per differing secret and a summary.
+func writeDiff(w io.Writer, d *gsm.ProjectDiff) {
+	counts := map[gsm.DiffStatus]int{}
+	for _, s := range d.Secrets {
+		counts[s.Status]++
+		fmt.Fprintf(w, "%s %s (%s)\n", diffMarks[s.Status], s.Name, s.Status)
+	}
+	fmt.Fprintf(w, "%s -> %s: %d missing, %d changed, %d extra, %d unchanged\n", d.Source, d.Destination,
+		counts[gsm.DiffMissing], counts[gsm.DiffChanged], counts[gsm.DiffExtra], d.Unchanged)
+}
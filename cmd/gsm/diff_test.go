@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/codeGROOVE-dev/gsm"
+)
+
+func TestWriteDiff(t *testing.T) {
+	d := &gsm.ProjectDiff{
+		Source:      "src",
+		Destination: "dst",
+		Secrets: []gsm.SecretDiff{
+			{Name: "a", Status: gsm.DiffChanged},
+			{Name: "b", Status: gsm.DiffExtra},
+			{Name: "c", Status: gsm.DiffMissing},
+		},
+		Unchanged: 4,
+	}
+	var out bytes.Buffer
+	writeDiff(&out, d)
+	want := "~ a (changed)\n- b (extra)\n+ c (missing)\nsrc -> dst: 1 missing, 1 changed, 1 extra, 4 unchanged\n"
+	if out.String() != want {
+		t.Errorf("writeDiff() = %q, want %q", out.String(), want)
+	}
+}
+
+func TestDiffCmdUsage(t *testing.T) {
+	ctx := context.Background()
+	for _, args := range [][]string{
+		{"src"},
+		{"src", "dst", "--delete"},
+		{"--from-file", "prod.env", "src", "dst"},
+		{"--from-file", "secrets.txt", "dst"},
+		{"--from-file", filepath.Join(t.TempDir(), "missing.env"), "dst"},
+	} {
+		if err := diffCmd(ctx, args, &bytes.Buffer{}, gsm.New()); err == nil {
+			t.Errorf("diffCmd(%q) succeeded, want error", args)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/codeGROOVE-dev/gsm"
+)
+
+// resolveFunc resolves a spec to values keyed by environment variable name.
+type resolveFunc func(ctx context.Context, s *gsm.Spec) (map[string]string, error)
+
+// envCmd resolves a spec file and prints shell assignments for every variable,
+// suitable for eval.
+func envCmd(ctx context.Context, args []string, stdout io.Writer, resolve resolveFunc) error {
+	fs := flag.NewFlagSet("env", flag.ContinueOnError)
+	specFile := fs.String("spec", "secrets.yaml", "spec file declaring environment variables and their secrets")
+	export := fs.Bool("export", false, "prefix each assignment with export")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errors.New("usage: gsm env [--spec FILE] [--export]")
+	}
+
+	values, err := resolveSpecFile(ctx, *specFile, resolve)
+	if err != nil {
+		return err
+	}
+
+	keys := slices.Sorted(maps.Keys(values))
+
+	prefix := ""
+	if *export {
+		prefix = "export "
+	}
+	for _, k := range keys {
+		if _, err := fmt.Fprintf(stdout, "%s%s=%s\n", prefix, k, shellQuote(values[k])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// execCmd resolves a spec file and --map mappings and runs a command with the
+// resulting variables added to its environment. Mappings override spec entries
+// of the same name, and the spec file is optional when mappings are given. The
+// command's exit status is returned as an *exec.ExitError.
+func execCmd(ctx context.Context, args []string, resolve resolveFunc) error {
+	fs := flag.NewFlagSet("exec", flag.ContinueOnError)
+	specFile := fs.String("spec", "secrets.yaml", "spec file declaring environment variables and their secrets")
+	var mappings []gsm.EnvSpec
+	fs.Func("map", "add a variable from a secret, as NAME=[PROJECT/]SECRET[#VERSION]; repeatable", func(s string) error {
+		e, err := gsm.ParseEnvMapping(s)
+		if err != nil {
+			return err
+		}
+		mappings = append(mappings, e)
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("usage: gsm exec [--spec FILE] [--map NAME=SECRET]... [--] COMMAND [ARGS...]")
+	}
+
+	s := &gsm.Spec{}
+	if len(mappings) == 0 || flagSet(fs, "spec") {
+		var err error
+		if s, err = gsm.LoadSpecFile(*specFile); err != nil {
+			return err
+		}
+	}
+	s.Env = mergeEnv(s.Env, mappings)
+	values, err := resolve(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, fs.Arg(0), fs.Args()[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = os.Environ()
+	for k, v := range values {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	// Let the command shut down gracefully when gsm is interrupted.
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	return cmd.Run()
+}
+
+// mergeEnv returns env with extra added, replacing entries of the same name,
+// sorted by name as in a Spec.
+func mergeEnv(env, extra []gsm.EnvSpec) []gsm.EnvSpec {
+	byName := map[string]gsm.EnvSpec{}
+	for _, e := range slices.Concat(env, extra) {
+		byName[e.Name] = e
+	}
+	merged := slices.Collect(maps.Values(byName))
+	slices.SortFunc(merged, func(a, b gsm.EnvSpec) int { return strings.Compare(a.Name, b.Name) })
+	return merged
+}
+
+// flagSet reports whether the named flag was given on the command line.
+func flagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) { set = set || f.Name == name })
+	return set
+}
+
+func resolveSpecFile(ctx context.Context, path string, resolve resolveFunc) (map[string]string, error) {
+	s, err := gsm.LoadSpecFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return resolve(ctx, s)
+}
+
+// shellQuote single-quotes s for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
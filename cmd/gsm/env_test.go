@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/codeGROOVE-dev/gsm"
+)
+
+func TestEnvCmd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.yaml")
+	if err := os.WriteFile(path, []byte("env:\n  B: b\n  A: a\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	resolve := func(_ context.Context, s *gsm.Spec) (map[string]string, error) {
+		values := map[string]string{}
+		for _, e := range s.Env {
+			values[e.Name] = "it's " + e.Secret
+		}
+		return values, nil
+	}
+
+	var out bytes.Buffer
+	if err := envCmd(context.Background(), []string{"--spec", path, "--export"}, &out, resolve); err != nil {
+		t.Fatalf("envCmd() unexpected error = %v", err)
+	}
+	want := "export A='it'\\''s a'\nexport B='it'\\''s b'\n"
+	if out.String() != want {
+		t.Errorf("envCmd() output = %q, want %q", out.String(), want)
+	}
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	err := execCmd(context.Background(), []string{"--spec", path, "--", "sh", "-c", `test "$A" = "it's a" || exit 3`}, resolve)
+	if err != nil {
+		t.Errorf("execCmd() unexpected error = %v", err)
+	}
+	err = execCmd(context.Background(), []string{"--spec", path, "sh", "-c", "exit 4"}, resolve)
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 4 {
+		t.Errorf("execCmd() error = %v, want exit status 4", err)
+	}
+}
+
+func TestExecCmdMap(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	path := filepath.Join(t.TempDir(), "secrets.yaml")
+	if err := os.WriteFile(path, []byte("env:\n  A: a\n  B: b\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var got []gsm.EnvSpec
+	resolve := func(_ context.Context, s *gsm.Spec) (map[string]string, error) {
+		got = s.Env
+		values := map[string]string{}
+		for _, e := range s.Env {
+			values[e.Name] = e.Secret
+		}
+		return values, nil
+	}
+
+	// Without --spec, mappings alone are enough; there is no secrets.yaml here.
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) }) //nolint:errcheck,gosec // best effort restore
+	err = execCmd(context.Background(), []string{"--map", "DB_PASS=db-password#2", "--", "sh", "-c", `test "$DB_PASS" = db-password || exit 3`}, resolve)
+	if err != nil {
+		t.Errorf("execCmd() unexpected error = %v", err)
+	}
+	if len(got) != 1 || got[0].Version != "2" {
+		t.Errorf("execCmd() resolved %+v, want DB_PASS at version 2", got)
+	}
+
+	err = execCmd(context.Background(), []string{"--spec", path, "--map", "B=other", "sh", "-c", `test "$A$B" = aother || exit 3`}, resolve)
+	if err != nil {
+		t.Errorf("execCmd() with --spec and --map unexpected error = %v", err)
+	}
+
+	if err := execCmd(context.Background(), []string{"--map", "DB_PASS", "true"}, resolve); err == nil {
+		t.Error("execCmd() with a malformed mapping succeeded, want error")
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/codeGROOVE-dev/gsm"
+)
+
+// exportFunc exports a project's secrets; an empty project means the current project.
+type exportFunc func(ctx context.Context, project string, w io.Writer, opts ...gsm.ExportOption) error
+
+// exportCmd writes a project's secrets to stdout or a file for backup and migration.
+func exportCmd(ctx context.Context, args []string, stdout io.Writer, export exportFunc) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	project := fs.String("project", "", "project to export (default: current project)")
+	format := fs.String("format", "json", "output format: json, yaml, or dotenv (dotenv requires --payloads)")
+	payloads := fs.Bool("payloads", false, "include the latest value of each secret")
+	filter := fs.String("filter", "", "Secret Manager list filter, e.g. labels.env=prod")
+	out := fs.String("out", "", "file to write (mode 0600) instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errors.New("usage: gsm export [--project P] [--format json|yaml|dotenv] [--payloads] [--filter F] [--out FILE]")
+	}
+
+	opts := []gsm.ExportOption{gsm.WithExportFormat(gsm.ExportFormat(*format)), gsm.WithExportFilter(*filter)}
+	if *payloads {
+		opts = append(opts, gsm.WithPayloads())
+	}
+
+	if *out == "" {
+		return export(ctx, *project, stdout, opts...)
+	}
+	f, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := export(ctx, *project, f, opts...); err != nil {
+		f.Close() //nolint:errcheck,gosec // already failing
+		return err
+	}
+	return f.Close()
+}
+
+// clientExport adapts a client to exportFunc.
+func clientExport(c *gsm.Client) exportFunc {
+	return func(ctx context.Context, project string, w io.Writer, opts ...gsm.ExportOption) error {
+		if project == "" {
+			return c.Export(ctx, w, opts...)
+		}
+		return c.ExportFromProject(ctx, project, w, opts...)
+	}
+}
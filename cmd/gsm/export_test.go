@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codeGROOVE-dev/gsm"
+)
+
+func TestExportCmd(t *testing.T) {
+	var gotProject string
+	export := func(_ context.Context, project string, w io.Writer, _ ...gsm.ExportOption) error {
+		gotProject = project
+		_, err := io.WriteString(w, "{}\n")
+		return err
+	}
+
+	var out bytes.Buffer
+	if err := exportCmd(context.Background(), []string{"--project", "my-project", "--format", "yaml"}, &out, export); err != nil {
+		t.Fatalf("exportCmd() unexpected error = %v", err)
+	}
+	if gotProject != "my-project" || out.String() != "{}\n" {
+		t.Errorf("exportCmd() exported %q to %q", gotProject, out.String())
+	}
+
+	path := filepath.Join(t.TempDir(), "backup.json")
+	if err := exportCmd(context.Background(), []string{"--payloads", "--out", path}, &out, export); err != nil {
+		t.Fatalf("exportCmd() unexpected error = %v", err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0o600 || fi.Size() != 3 {
+		t.Errorf("backup file mode %v size %d, want 0600 and 3 bytes", fi.Mode().Perm(), fi.Size())
+	}
+
+	if err := exportCmd(context.Background(), []string{"extra"}, &out, export); err == nil {
+		t.Error("exportCmd() with a positional argument succeeded, want usage error")
+	}
+}
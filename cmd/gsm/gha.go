@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var envNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// gha fetches a secret for a GitHub Actions step: it masks the value in logs and
+// writes it to $GITHUB_OUTPUT and/or $GITHUB_ENV using multiline-safe delimiters.
+func gha(ctx context.Context, args []string, stdout io.Writer, fetch fetchFunc) error {
+	fs := flag.NewFlagSet("gha", flag.ContinueOnError)
+	project := fs.String("project", "", "project containing the secret (default: current project)")
+	mask := fs.Bool("mask", true, "register the value with ::add-mask:: before writing it")
+	output := fs.String("output", "", "step output name (default: the secret name unless --env is set)")
+	env := fs.String("env", "", "environment variable to export to later steps")
+	names, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(names) != 1 {
+		return errors.New("usage: gsm gha [--project P] [--mask] [--output NAME] [--env VAR] SECRET")
+	}
+	name := names[0]
+	if *env != "" && !envNameRegex.MatchString(*env) {
+		return fmt.Errorf("invalid environment variable name %q", *env)
+	}
+	if *output == "" && *env == "" {
+		*output = name
+	}
+
+	value, err := fetch(ctx, *project, name)
+	if err != nil {
+		return err
+	}
+
+	if *mask {
+		writeMasks(stdout, value)
+	}
+	if *output != "" {
+		if err := appendGitHubFile("GITHUB_OUTPUT", *output, value); err != nil {
+			return err
+		}
+	}
+	if *env != "" {
+		if err := appendGitHubFile("GITHUB_ENV", *env, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeMasks registers value with the runner's log masking. Multiline values are
+// also masked line by line, since the runner matches log output per line.
+func writeMasks(w io.Writer, value string) {
+	fmt.Fprintf(w, "::add-mask::%s\n", escapeCommand(value))
+	if !strings.ContainsAny(value, "\r\n") {
+		return
+	}
+	for _, line := range strings.FieldsFunc(value, func(r rune) bool { return r == '\n' || r == '\r' }) {
+		if strings.TrimSpace(line) != "" {
+			fmt.Fprintf(w, "::add-mask::%s\n", escapeCommand(line))
+		}
+	}
+}
+
+// escapeCommand escapes a workflow command value.
+func escapeCommand(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// appendGitHubFile appends key=value to the file named by the environment variable fileVar,
+// using a random heredoc delimiter that cannot occur in the value.
+func appendGitHubFile(fileVar, key, value string) error {
+	path := os.Getenv(fileVar)
+	if path == "" {
+		return fmt.Errorf("$%s is not set; is this running in GitHub Actions?", fileVar)
+	}
+
+	entry, err := githubFileEntry(key, value)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(entry); err != nil {
+		f.Close() //nolint:errcheck,gosec // already failing
+		return err
+	}
+	return f.Close()
+}
+
+// githubFileEntry formats key and value in the GITHUB_OUTPUT/GITHUB_ENV multiline syntax.
+func githubFileEntry(key, value string) (string, error) {
+	if strings.ContainsAny(key, "\r\n=") || strings.Contains(key, "<<") {
+		return "", fmt.Errorf("invalid name %q", key)
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	delim := "ghadelimiter_" + hex.EncodeToString(b)
+	if strings.Contains(value, delim) {
+		return "", errors.New("secret value contains the generated delimiter")
+	}
+	return fmt.Sprintf("%s<<%s\n%s\n%s\n", key, delim, value, delim), nil
+}
+
+// parseInterspersed parses flags that may appear before or after positional arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGHA(t *testing.T) {
+	dir := t.TempDir()
+	outFile := filepath.Join(dir, "output")
+	envFile := filepath.Join(dir, "env")
+	t.Setenv("GITHUB_OUTPUT", outFile)
+	t.Setenv("GITHUB_ENV", envFile)
+
+	fetch := func(_ context.Context, project, name string) (string, error) {
+		if project != "other-project" || name != "tls-key" {
+			t.Errorf("fetch(%q, %q), want other-project/tls-key", project, name)
+		}
+		return "line1\nline2 100%", nil
+	}
+
+	var stdout bytes.Buffer
+	err := gha(context.Background(), []string{"tls-key", "--project", "other-project", "--output", "key", "--env", "TLS_KEY"}, &stdout, fetch)
+	if err != nil {
+		t.Fatalf("gha() unexpected error = %v", err)
+	}
+
+	wantMasks := "::add-mask::line1%0Aline2 100%25\n::add-mask::line1\n::add-mask::line2 100%25\n"
+	if stdout.String() != wantMasks {
+		t.Errorf("stdout = %q, want %q", stdout.String(), wantMasks)
+	}
+
+	entry := regexp.MustCompile(`^(\w+)<<(ghadelimiter_[0-9a-f]{32})\nline1\nline2 100%\n(ghadelimiter_[0-9a-f]{32})\n$`)
+	for file, key := range map[string]string{outFile: "key", envFile: "TLS_KEY"} {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := entry.FindStringSubmatch(string(b))
+		if m == nil || m[1] != key || m[2] != m[3] {
+			t.Errorf("%s = %q, want heredoc entry for %s", filepath.Base(file), b, key)
+		}
+	}
+}
+
+func TestGHADefaults(t *testing.T) {
+	outFile := filepath.Join(t.TempDir(), "output")
+	t.Setenv("GITHUB_OUTPUT", outFile)
+
+	var stdout bytes.Buffer
+	fetch := func(context.Context, string, string) (string, error) { return "v", nil }
+	if err := gha(context.Background(), []string{"--mask=false", "api-key"}, &stdout, fetch); err != nil {
+		t.Fatalf("gha() unexpected error = %v", err)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("stdout = %q, want no masks with --mask=false", stdout.String())
+	}
+	b, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(b), "api-key<<") {
+		t.Errorf("output = %q, want entry named after the secret", b)
+	}
+}
+
+func TestGHAErrors(t *testing.T) {
+	fetch := func(context.Context, string, string) (string, error) { return "v", nil }
+	t.Setenv("GITHUB_OUTPUT", "")
+
+	tests := map[string][]string{
+		"missing name":      {},
+		"two names":         {"a", "b"},
+		"bad env name":      {"--env", "1BAD", "a"},
+		"not in actions":    {"a"},
+		"newline in output": {"--output", "a\nb", "a"},
+	}
+	for name, args := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := gha(context.Background(), args, &bytes.Buffer{}, fetch); err == nil {
+				t.Errorf("gha(%q) expected error", args)
+			}
+		})
+	}
+}
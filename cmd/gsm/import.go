@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/codeGROOVE-dev/gsm"
+)
+
+// importFunc imports a secrets file; an empty project means the current project.
+type importFunc func(ctx context.Context, project string, data []byte, f gsm.ImportFormat, opts ...gsm.StoreOption) (map[string]error, error)
+
+// importCmd bulk-loads a dotenv, JSON, or YAML file into Secret Manager,
+// printing the outcome for each secret.
+func importCmd(ctx context.Context, args []string, stdout io.Writer, imp importFunc) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	project := fs.String("project", "", "project to import into (default: current project)")
+	format := fs.String("format", "", "file format: dotenv, json, or yaml (default: from the file name)")
+	budget := fs.Int("write-budget", 0, "write requests per minute to stay under (default 500; negative disables pacing)")
+	checkpoint := fs.String("checkpoint", "", "file recording progress, so an interrupted import can be resumed by re-running it")
+	files, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) != 1 {
+		return errors.New("usage: gsm import [--project P] [--format dotenv|json|yaml] [--write-budget N] [--checkpoint PATH] FILE")
+	}
+
+	f := gsm.ImportFormat(*format)
+	if f == "" {
+		if f, err = gsm.ImportFormatOf(files[0]); err != nil {
+			return err
+		}
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		return err
+	}
+
+	opts := []gsm.StoreOption{gsm.WithWriteBudget(*budget)}
+	if *checkpoint != "" {
+		opts = append(opts, gsm.WithCheckpoint(*checkpoint))
+	}
+	results, err := imp(ctx, *project, data, f, opts...)
+	if err != nil {
+		return err
+	}
+
+	names := slices.Sorted(maps.Keys(results))
+
+	failed := 0
+	for _, name := range names {
+		if err := results[name]; err != nil {
+			failed++
+			fmt.Fprintf(stdout, "%s: %v\n", name, err)
+			continue
+		}
+		fmt.Fprintf(stdout, "%s: ok\n", name)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d secrets failed to import", failed, len(names))
+	}
+	return nil
+}
+
+// clientImport adapts a client to importFunc.
+func clientImport(c *gsm.Client) importFunc {
+	return func(ctx context.Context, project string, data []byte, f gsm.ImportFormat, opts ...gsm.StoreOption) (map[string]error, error) {
+		if project == "" {
+			return c.Import(ctx, data, f, opts...)
+		}
+		return c.ImportInProject(ctx, project, data, f, opts...)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codeGROOVE-dev/gsm"
+)
+
+func TestImportCmd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env.production")
+	if err := os.WriteFile(path, []byte("A=1\nB=2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var gotProject string
+	var gotFormat gsm.ImportFormat
+	var gotOpts int
+	imp := func(_ context.Context, project string, _ []byte, f gsm.ImportFormat, opts ...gsm.StoreOption) (map[string]error, error) {
+		gotProject, gotFormat, gotOpts = project, f, len(opts)
+		return map[string]error{"B": errors.New("denied"), "A": nil}, nil
+	}
+
+	var out bytes.Buffer
+	err := importCmd(context.Background(), []string{path, "--project", "my-project", "--checkpoint", "progress"}, &out, imp)
+	if err == nil || err.Error() != "1 of 2 secrets failed to import" {
+		t.Errorf("importCmd() error = %v, want 1 of 2 failed", err)
+	}
+	if gotProject != "my-project" || gotFormat != gsm.ImportDotenv || gotOpts != 2 {
+		t.Errorf("importCmd() imported into %q as %q with %d options", gotProject, gotFormat, gotOpts)
+	}
+	if want := "A: ok\nB: denied\n"; out.String() != want {
+		t.Errorf("importCmd() output = %q, want %q", out.String(), want)
+	}
+
+	if err := importCmd(context.Background(), []string{"secrets.txt"}, &out, imp); err == nil {
+		t.Error("importCmd() with an unknown extension succeeded, want error")
+	}
+}
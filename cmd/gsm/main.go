@@ -0,0 +1,99 @@
+// Command gsm is a command-line interface to Google Cloud Secret Manager built
+// on the gsm library, sharing its authentication, retry, and validation behavior.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/codeGROOVE-dev/gsm"
+)
+
+const usage = `usage: gsm <command> [flags]
+
+commands:
+  get       print the latest value of a secret
+  edit      edit a secret in $EDITOR, adding a version if it changed
+  set       add a version to a secret (creating it if needed) from stdin or a file
+  list      list the secrets in a project
+  delete    delete a secret and all of its versions
+  versions  list the versions of a secret and their states
+  diff      compare the secrets of two projects, optionally copying differences
+  env       print shell assignments for the variables declared in a spec file
+  exec      run a command with variables from a spec file or --map NAME=SECRET
+  export    write a project's secrets (and optionally values) as JSON, YAML, or dotenv
+  gha       fetch a secret into GitHub Actions outputs or env, masked in logs
+  import    create or update secrets from a .env, JSON, or YAML file
+  render    render a template containing secrets to a file, optionally watching for changes
+  serve     serve secrets over an authenticated localhost HTTP API or Unix socket
+  sync      mirror secrets from one project to others, once or continuously
+  tf-read   read secrets for a Terraform external data source (JSON on stdin/stdout)
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := run(ctx, os.Args[1:])
+	stop()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.Exited() {
+		os.Exit(exitErr.ExitCode())
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gsm:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return errors.New("missing command")
+	}
+
+	switch args[0] {
+	case "get":
+		return getCmd(ctx, args[1:], os.Stdout, clientFetch(gsm.New()))
+	case "edit":
+		c := gsm.New()
+		return editCmd(ctx, args[1:], os.Stdout, clientFetch(c), clientStore(c))
+	case "set":
+		return setCmd(ctx, args[1:], os.Stdin, clientStore(gsm.New()))
+	case "list":
+		return listCmd(ctx, args[1:], os.Stdout, clientList(gsm.New()))
+	case "delete":
+		return deleteCmd(ctx, args[1:], clientDelete(gsm.New()))
+	case "versions":
+		return versionsCmd(ctx, args[1:], os.Stdout, clientVersions(gsm.New()))
+	case "diff":
+		return diffCmd(ctx, args[1:], os.Stdout, gsm.New())
+	case "env":
+		return envCmd(ctx, args[1:], os.Stdout, gsm.New().ResolveSpec)
+	case "exec":
+		return execCmd(ctx, args[1:], gsm.New().ResolveSpec)
+	case "export":
+		return exportCmd(ctx, args[1:], os.Stdout, clientExport(gsm.New()))
+	case "gha":
+		return gha(ctx, args[1:], os.Stdout, clientFetch(gsm.New()))
+	case "import":
+		return importCmd(ctx, args[1:], os.Stdout, clientImport(gsm.New()))
+	case "render":
+		return render(ctx, args[1:])
+	case "serve":
+		return serve(ctx, args[1:])
+	case "sync":
+		return syncCmd(ctx, args[1:], os.Stdout, gsm.New())
+	case "tf-read":
+		return tfRead(ctx, os.Stdin, os.Stdout, clientFetch(gsm.New()))
+	case "help", "-h", "--help":
+		fmt.Fprint(os.Stdout, usage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
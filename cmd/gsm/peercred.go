@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// peerCred identifies the process on the other end of a Unix socket.
+type peerCred struct {
+	pid int32
+	uid uint32
+	gid uint32
+}
+
+type peerCredKey struct{}
+
+// peerPolicy lists the secrets each peer UID or GID may read, as
+// [PROJECT/]SECRET entries. An entry without a project applies only to the
+// current project, and a "*" secret allows any secret in its project.
+type peerPolicy struct {
+	uids map[uint32][]string
+	gids map[uint32][]string
+}
+
+// allows reports whether a peer with the given credentials may read the secret
+// name in project, where an empty project means the current one.
+func (p *peerPolicy) allows(cred peerCred, project, name string) bool {
+	permits := func(entries []string) bool {
+		return slices.ContainsFunc(entries, func(e string) bool {
+			entryProject, secret, ok := strings.Cut(e, "/")
+			if !ok {
+				entryProject, secret = "", e
+			}
+			return entryProject == project && (secret == "*" || secret == name)
+		})
+	}
+	return permits(p.uids[cred.uid]) || permits(p.gids[cred.gid])
+}
+
+// allowFlag parses repeated ID=[PROJECT/]SECRET[,...] flags into an allow-list map.
+type allowFlag map[uint32][]string
+
+func (f allowFlag) String() string {
+	var parts []string
+	for id, secrets := range f {
+		parts = append(parts, fmt.Sprintf("%d=%s", id, strings.Join(secrets, ",")))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (f allowFlag) Set(v string) error {
+	idStr, list, ok := strings.Cut(v, "=")
+	if !ok || list == "" {
+		return errors.New("want ID=[PROJECT/]SECRET[,...]")
+	}
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid ID %q: %w", idStr, err)
+	}
+	f[uint32(id)] = append(f[uint32(id)], strings.Split(list, ",")...)
+	return nil
+}
+
+// peerConnContext records the peer credentials of Unix socket connections in the request context.
+func peerConnContext(ctx context.Context, c net.Conn) context.Context {
+	uc, ok := c.(*net.UnixConn)
+	if !ok {
+		return ctx
+	}
+	cred, err := readPeerCred(uc)
+	if err != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, peerCredKey{}, cred)
+}
+
+// peerFromRequest returns the peer credentials recorded for r's connection.
+func peerFromRequest(r *http.Request) (peerCred, bool) {
+	cred, ok := r.Context().Value(peerCredKey{}).(peerCred)
+	return cred, ok
+}
+
+// listenUnix creates a Unix socket at path with the given permissions, replacing a stale socket.
+// The socket is created in a private directory and only moved to path once its
+// permissions are set, so it is never reachable with the umask's permissions.
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".gsm-socket-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck // best effort cleanup
+	tmp := filepath.Join(dir, "sock")
+
+	ln, err := net.Listen("unix", tmp)
+	if err != nil {
+		return nil, err
+	}
+	// The socket file moves to path; serve removes it from there.
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, mode); err != nil {
+		ln.Close() //nolint:errcheck,gosec // best effort cleanup
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		ln.Close() //nolint:errcheck,gosec // best effort cleanup
+		return nil, err
+	}
+	return ln, nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"net"
+)
+
+// readPeerCred is only supported on Linux, where SO_PEERCRED is available.
+func readPeerCred(*net.UnixConn) (peerCred, error) {
+	return peerCred{}, errors.New("peer credentials are only supported on Linux")
+}
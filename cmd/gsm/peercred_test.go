@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestAllowFlag(t *testing.T) {
+	f := allowFlag{}
+	for _, v := range []string{"1000=db-password,api-key", "1000=extra", "0=*"} {
+		if err := f.Set(v); err != nil {
+			t.Fatalf("Set(%q) unexpected error = %v", v, err)
+		}
+	}
+	if got := f[1000]; len(got) != 3 {
+		t.Errorf("uid 1000 secrets = %v, want 3 entries", got)
+	}
+	for _, bad := range []string{"1000", "1000=", "abc=x", "-1=x"} {
+		if err := f.Set(bad); err == nil {
+			t.Errorf("Set(%q) expected error", bad)
+		}
+	}
+}
+
+func TestPeerPolicy(t *testing.T) {
+	p := &peerPolicy{
+		uids: map[uint32][]string{1000: {"db-password", "other-project/api-key"}},
+		gids: map[uint32][]string{50: {"*", "shared-project/*"}},
+	}
+	tests := []struct {
+		cred    peerCred
+		project string
+		name    string
+		want    bool
+	}{
+		{peerCred{uid: 1000, gid: 1}, "", "db-password", true},
+		{peerCred{uid: 1000, gid: 1}, "", "api-key", false},
+		{peerCred{uid: 1000, gid: 1}, "other-project", "api-key", true},
+		{peerCred{uid: 1000, gid: 1}, "other-project", "db-password", false},
+		{peerCred{uid: 2000, gid: 50}, "", "anything", true},
+		{peerCred{uid: 2000, gid: 50}, "shared-project", "anything", true},
+		{peerCred{uid: 2000, gid: 50}, "other-project", "anything", false},
+		{peerCred{uid: 2000, gid: 1}, "", "db-password", false},
+	}
+	for _, tt := range tests {
+		if got := p.allows(tt.cred, tt.project, tt.name); got != tt.want {
+			t.Errorf("allows(%+v, %q, %q) = %v, want %v", tt.cred, tt.project, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUnixSocketPeerAuth(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("SO_PEERCRED requires Linux")
+	}
+
+	sock := filepath.Join(t.TempDir(), "gsm.sock")
+	ln, err := listenUnix(sock, 0o600)
+	if err != nil {
+		t.Fatalf("listenUnix() error = %v", err)
+	}
+	fi, err := os.Stat(sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0o600 {
+		t.Errorf("socket mode = %v, want 0600", fi.Mode().Perm())
+	}
+	if entries, err := os.ReadDir(filepath.Dir(sock)); err != nil || len(entries) != 1 {
+		t.Errorf("socket directory holds %v, %v; want only the socket", entries, err)
+	}
+
+	uid := uint32(os.Getuid()) //nolint:gosec // test process UID fits in uint32
+	srv := &http.Server{
+		Handler: &secretHandler{
+			peers: &peerPolicy{uids: map[uint32][]string{uid: {"allowed"}}},
+			fetch: func(_ context.Context, _, name string) (string, error) { return "value-of-" + name, nil },
+		},
+		ConnContext:       peerConnContext,
+		ReadHeaderTimeout: time.Second,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- listenAndServe(ctx, srv, ln) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("listenAndServe() error = %v", err)
+		}
+	}()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", sock)
+		},
+	}}
+
+	// Allowing a secret allows it only in the current project.
+	for name, want := range map[string]int{"allowed": http.StatusOK, "denied": http.StatusForbidden, "allowed?project=other-project": http.StatusForbidden} {
+		resp, err := client.Get("http://gsm/secret/" + name)
+		if err != nil {
+			t.Fatalf("GET %s error = %v", name, err)
+		}
+		body, _ := io.ReadAll(resp.Body) //nolint:errcheck // compared below
+		resp.Body.Close()                //nolint:errcheck,gosec // test cleanup
+		if resp.StatusCode != want {
+			t.Errorf("GET %s status = %d, want %d", name, resp.StatusCode, want)
+		}
+		if want == http.StatusOK && string(body) != "value-of-allowed" {
+			t.Errorf("GET %s body = %q", name, body)
+		}
+	}
+}
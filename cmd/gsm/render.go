@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/codeGROOVE-dev/gsm"
+)
+
+// render renders a template containing secret functions to a file, optionally
+// re-rendering on an interval and signalling a process whenever the output changes.
+func render(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	tmplPath := fs.String("template", "", "template file to render (required)")
+	out := fs.String("out", "", "output file, replaced atomically (required)")
+	mode := fs.Uint("mode", 0o600, "permissions for the output file")
+	watch := fs.Bool("watch", false, "keep re-rendering every --interval")
+	interval := fs.Duration("interval", time.Minute, "how often to re-render with --watch")
+	sigName := fs.String("signal", "HUP", "signal to send on change")
+	pid := fs.Int("pid", 0, "process to signal when the output changes")
+	pidFile := fs.String("pid-file", "", "file containing the PID of the process to signal when the output changes")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *tmplPath == "" || *out == "" {
+		return errors.New("--template and --out are required")
+	}
+
+	r := &renderer{
+		fetch:    clientFetch(gsm.New()),
+		template: *tmplPath,
+		out:      *out,
+		mode:     os.FileMode(*mode), //nolint:gosec // mode is a small permission value
+	}
+	if *pid != 0 || *pidFile != "" {
+		sig, err := parseSignal(*sigName)
+		if err != nil {
+			return err
+		}
+		r.onChange = func() error { return signalProcess(*pid, *pidFile, sig) }
+	}
+
+	if _, err := r.render(ctx); err != nil {
+		return err
+	}
+	if !*watch {
+		return nil
+	}
+
+	t := time.NewTicker(*interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+		// Keep the last good output on transient failures.
+		if _, err := r.render(ctx); err != nil {
+			slog.Error("render failed", "error", err)
+		}
+	}
+}
+
+// renderer renders a template with secret functions to an output file.
+type renderer struct {
+	fetch    fetchFunc
+	onChange func() error
+	template string
+	out      string
+	mode     os.FileMode
+}
+
+// render executes the template and replaces the output file if its content changed.
+// It reports whether the file was written.
+func (r *renderer) render(ctx context.Context) (bool, error) {
+	text, err := os.ReadFile(r.template)
+	if err != nil {
+		return false, err
+	}
+
+	funcs := template.FuncMap{
+		"secret": func(name string) (string, error) {
+			return r.fetch(ctx, "", name)
+		},
+		"secretFrom": func(project, name string) (string, error) {
+			return r.fetch(ctx, project, name)
+		},
+	}
+	t, err := template.New(filepath.Base(r.template)).Funcs(funcs).Option("missingkey=error").Parse(string(text))
+	if err != nil {
+		return false, err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, nil); err != nil {
+		return false, err
+	}
+
+	if old, err := os.ReadFile(r.out); err == nil && bytes.Equal(old, buf.Bytes()) {
+		return false, nil
+	}
+	if err := writeFileAtomic(r.out, buf.Bytes(), r.mode); err != nil {
+		return false, err
+	}
+	slog.Info("rendered template", "out", r.out)
+
+	if r.onChange != nil {
+		if err := r.onChange(); err != nil {
+			return true, fmt.Errorf("signalling process: %w", err)
+		}
+	}
+	return true, nil
+}
+
+// writeFileAtomic writes data to a temporary file beside path and renames it into place,
+// so readers never observe a partially written file.
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp) //nolint:errcheck // no-op once renamed
+
+	if err := f.Chmod(mode); err != nil {
+		f.Close() //nolint:errcheck,gosec // already failing
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close() //nolint:errcheck,gosec // already failing
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close() //nolint:errcheck,gosec // already failing
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// signalProcess sends sig to pid, or to the PID read from pidFile.
+func signalProcess(pid int, pidFile string, sig os.Signal) error {
+	if pidFile != "" {
+		b, err := os.ReadFile(pidFile)
+		if err != nil {
+			return err
+		}
+		pid, err = strconv.Atoi(strings.TrimSpace(string(b)))
+		if err != nil {
+			return fmt.Errorf("invalid PID file %s: %w", pidFile, err)
+		}
+	}
+
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	slog.Info("signalling process", "pid", pid, "signal", sig)
+	return p.Signal(sig)
+}
+
+// parseSignal converts a signal name such as "HUP" or "SIGUSR1" to a signal.
+func parseSignal(name string) (os.Signal, error) {
+	sig, ok := signals[strings.TrimPrefix(strings.ToUpper(name), "SIG")]
+	if !ok {
+		return nil, fmt.Errorf("unsupported signal %q", name)
+	}
+	return sig, nil
+}
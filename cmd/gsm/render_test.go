@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestRenderer(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := filepath.Join(dir, "app.conf.tmpl")
+	out := filepath.Join(dir, "app.conf")
+	if err := os.WriteFile(tmpl, []byte(`password={{ secret "db-password" }}
+key={{ secretFrom "other-project" "api-key" }}
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string]string{"/db-password": "hunter2", "other-project/api-key": "k1"}
+	changes := 0
+	r := &renderer{
+		fetch: func(_ context.Context, project, name string) (string, error) {
+			v, ok := values[project+"/"+name]
+			if !ok {
+				return "", errors.New("not found")
+			}
+			return v, nil
+		},
+		onChange: func() error { changes++; return nil },
+		template: tmpl,
+		out:      out,
+		mode:     0o640,
+	}
+
+	ctx := context.Background()
+	wrote, err := r.render(ctx)
+	if err != nil || !wrote {
+		t.Fatalf("render() = %v, %v; want written", wrote, err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "password=hunter2\nkey=k1\n" {
+		t.Errorf("output = %q", got)
+	}
+	if fi, err := os.Stat(out); err != nil || fi.Mode().Perm() != 0o640 {
+		t.Errorf("output mode = %v, %v; want 0640", fi.Mode().Perm(), err)
+	}
+
+	// Unchanged values leave the file and process alone.
+	if wrote, err := r.render(ctx); err != nil || wrote {
+		t.Errorf("re-render() = %v, %v; want unchanged", wrote, err)
+	}
+
+	values["/db-password"] = "rotated"
+	if wrote, err := r.render(ctx); err != nil || !wrote {
+		t.Errorf("render() after rotation = %v, %v; want written", wrote, err)
+	}
+	if changes != 2 {
+		t.Errorf("onChange called %d times, want 2", changes)
+	}
+
+	// A failing fetch keeps the last good output.
+	delete(values, "/db-password")
+	if _, err := r.render(ctx); err == nil {
+		t.Error("render() expected error for missing secret")
+	}
+	if got, _ := os.ReadFile(out); string(got) != "password=rotated\nkey=k1\n" { //nolint:errcheck // compared below
+		t.Errorf("output after failure = %q, want last good render", got)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 { //nolint:errcheck // compared below
+		t.Errorf("dir has %d entries, want no leftover temp files", len(entries))
+	}
+}
+
+func TestParseSignal(t *testing.T) {
+	for _, name := range []string{"HUP", "sighup", "SIGTERM"} {
+		if _, err := parseSignal(name); err != nil {
+			t.Errorf("parseSignal(%q) unexpected error = %v", name, err)
+		}
+	}
+	if sig, _ := parseSignal("hup"); sig != syscall.SIGHUP { //nolint:errcheck // checked above
+		t.Errorf("parseSignal(hup) = %v, want SIGHUP", sig)
+	}
+	if _, err := parseSignal("BOGUS"); err == nil {
+		t.Error("parseSignal(BOGUS) expected error")
+	}
+}
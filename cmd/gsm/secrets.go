@@ -0,0 +1,234 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/codeGROOVE-dev/gsm"
+)
+
+// storeFunc stores a secret value; an empty project means the current project.
+type storeFunc func(ctx context.Context, project, name, value string) error
+
+// listFunc lists secrets matching filter; an empty project means the current project.
+type listFunc func(ctx context.Context, project, filter string) ([]*gsm.Secret, error)
+
+// deleteFunc deletes a secret; an empty project means the current project.
+type deleteFunc func(ctx context.Context, project, name, etag string) error
+
+// versionsFunc lists a secret's versions; an empty project means the current project.
+type versionsFunc func(ctx context.Context, project, name string) ([]*gsm.Version, error)
+
+// getCmd writes the latest value of a secret to stdout, exactly as stored.
+func getCmd(ctx context.Context, args []string, stdout io.Writer, fetch fetchFunc) error {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	project := fs.String("project", "", "project containing the secret (default: current project)")
+	names, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(names) != 1 {
+		return errors.New("usage: gsm get [--project P] SECRET")
+	}
+
+	value, err := fetch(ctx, *project, names[0])
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(stdout, value)
+	return err
+}
+
+// setCmd stores a new version of a secret read from stdin or a file, so the
+// value stays out of shell history and process listings.
+func setCmd(ctx context.Context, args []string, stdin io.Reader, store storeFunc) error {
+	fs := flag.NewFlagSet("set", flag.ContinueOnError)
+	project := fs.String("project", "", "project containing the secret (default: current project)")
+	file := fs.String("file", "", "file to read the value from (default: stdin)")
+	names, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(names) != 1 {
+		return errors.New("usage: gsm set [--project P] [--file FILE] SECRET < VALUE")
+	}
+
+	var value []byte
+	if *file != "" {
+		value, err = os.ReadFile(*file)
+	} else {
+		value, err = io.ReadAll(io.LimitReader(stdin, gsm.MaxPayloadSize+1))
+	}
+	if err != nil {
+		return err
+	}
+	return store(ctx, *project, names[0], string(value))
+}
+
+// listCmd prints the names of a project's secrets, one per line.
+func listCmd(ctx context.Context, args []string, stdout io.Writer, list listFunc) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	project := fs.String("project", "", "project to list (default: current project)")
+	filter := fs.String("filter", "", "Secret Manager list filter, e.g. labels.env=prod")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errors.New("usage: gsm list [--project P] [--filter F]")
+	}
+
+	secrets, err := list(ctx, *project, *filter)
+	if err != nil {
+		return err
+	}
+	for _, s := range secrets {
+		if _, err := fmt.Fprintln(stdout, s.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteCmd deletes a secret and all of its versions.
+func deleteCmd(ctx context.Context, args []string, del deleteFunc) error {
+	fs := flag.NewFlagSet("delete", flag.ContinueOnError)
+	project := fs.String("project", "", "project containing the secret (default: current project)")
+	etag := fs.String("etag", "", "delete only if the secret's etag still matches")
+	names, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(names) != 1 {
+		return errors.New("usage: gsm delete [--project P] [--etag E] SECRET")
+	}
+	return del(ctx, *project, names[0], *etag)
+}
+
+// versionsCmd prints a table of a secret's versions and their states.
+func versionsCmd(ctx context.Context, args []string, stdout io.Writer, versions versionsFunc) error {
+	fs := flag.NewFlagSet("versions", flag.ContinueOnError)
+	project := fs.String("project", "", "project containing the secret (default: current project)")
+	names, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(names) != 1 {
+		return errors.New("usage: gsm versions [--project P] SECRET")
+	}
+
+	vs, err := versions(ctx, *project, names[0])
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tSTATE\tCREATED")
+	for _, v := range vs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", v.ID, v.State, v.CreateTime.UTC().Format(time.RFC3339))
+	}
+	return tw.Flush()
+}
+
+// clientStore adapts a client to storeFunc.
+func clientStore(c *gsm.Client) storeFunc {
+	return func(ctx context.Context, project, name, value string) error {
+		if project == "" {
+			return c.Store(ctx, name, value)
+		}
+		return c.StoreInProject(ctx, project, name, value)
+	}
+}
+
+// clientList adapts a client to listFunc.
+func clientList(c *gsm.Client) listFunc {
+	return func(ctx context.Context, project, filter string) ([]*gsm.Secret, error) {
+		if project == "" {
+			return c.List(ctx, filter)
+		}
+		return c.ListInProject(ctx, project, filter)
+	}
+}
+
+// clientDelete adapts a client to deleteFunc.
+func clientDelete(c *gsm.Client) deleteFunc {
+	return func(ctx context.Context, project, name, etag string) error {
+		if project == "" {
+			return c.Delete(ctx, name, etag)
+		}
+		return c.DeleteFromProject(ctx, project, name, etag)
+	}
+}
+
+// clientVersions adapts a client to versionsFunc.
+func clientVersions(c *gsm.Client) versionsFunc {
+	return func(ctx context.Context, project, name string) ([]*gsm.Version, error) {
+		if project == "" {
+			return c.ListVersions(ctx, name)
+		}
+		return c.ListVersionsInProject(ctx, project, name)
+	}
+}
+
+// editCmd opens the latest value of a secret in $VISUAL or $EDITOR (default vi)
+// from a temporary file readable only by the user, and adds a version only if
+// the content changed. A secret that does not exist starts empty and is created.
+func editCmd(ctx context.Context, args []string, stdout io.Writer, fetch fetchFunc, store storeFunc) error {
+	fs := flag.NewFlagSet("edit", flag.ContinueOnError)
+	project := fs.String("project", "", "project containing the secret (default: current project)")
+	names, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(names) != 1 {
+		return errors.New("usage: gsm edit [--project P] SECRET")
+	}
+	name := names[0]
+	editor := strings.Fields(cmp.Or(os.Getenv("VISUAL"), os.Getenv("EDITOR"), "vi"))
+
+	old, err := fetch(ctx, *project, name)
+	if err != nil && !errors.Is(err, gsm.ErrNotFound) {
+		return err
+	}
+
+	// CreateTemp creates the file with mode 0600.
+	f, err := os.CreateTemp("", "gsm-edit-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) //nolint:errcheck // best effort cleanup
+	if _, err := io.WriteString(f, old); err != nil {
+		f.Close() //nolint:errcheck,gosec // already failing
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, editor[0], append(editor[1:], f.Name())...) //nolint:gosec // the user's own editor
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor: %w", err)
+	}
+
+	edited, err := os.ReadFile(f.Name())
+	if err != nil {
+		return err
+	}
+	if string(edited) == old {
+		_, err := fmt.Fprintf(stdout, "%s: unchanged\n", name)
+		return err
+	}
+	if err := store(ctx, *project, name, string(edited)); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(stdout, "%s: new version added\n", name)
+	return err
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codeGROOVE-dev/gsm"
+)
+
+func TestGetSetCmd(t *testing.T) {
+	ctx := context.Background()
+	stored := map[string]string{}
+	store := func(_ context.Context, project, name, value string) error {
+		stored[project+"/"+name] = value
+		return nil
+	}
+	fetch := func(_ context.Context, project, name string) (string, error) {
+		return stored[project+"/"+name], nil
+	}
+
+	if err := setCmd(ctx, []string{"db-password", "--project", "p"}, strings.NewReader("s3cret\n"), store); err != nil {
+		t.Fatalf("setCmd() unexpected error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte("a: 1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := setCmd(ctx, []string{"--file", path, "config"}, strings.NewReader(""), store); err != nil {
+		t.Fatalf("setCmd() unexpected error = %v", err)
+	}
+	if stored["p/db-password"] != "s3cret\n" || stored["/config"] != "a: 1\n" {
+		t.Errorf("setCmd() stored %q", stored)
+	}
+
+	var out bytes.Buffer
+	if err := getCmd(ctx, []string{"--project", "p", "db-password"}, &out, fetch); err != nil {
+		t.Fatalf("getCmd() unexpected error = %v", err)
+	}
+	if out.String() != "s3cret\n" {
+		t.Errorf("getCmd() wrote %q, want the value exactly as stored", out.String())
+	}
+
+	if err := getCmd(ctx, nil, &out, fetch); err == nil {
+		t.Error("getCmd() without a secret succeeded, want usage error")
+	}
+	if err := setCmd(ctx, []string{"a", "b"}, strings.NewReader(""), store); err == nil {
+		t.Error("setCmd() with two secrets succeeded, want usage error")
+	}
+}
+
+func TestListDeleteVersionsCmd(t *testing.T) {
+	ctx := context.Background()
+	var out bytes.Buffer
+	list := func(_ context.Context, project, filter string) ([]*gsm.Secret, error) {
+		if project != "p" || filter != "labels.env=prod" {
+			t.Errorf("listed %q with filter %q", project, filter)
+		}
+		return []*gsm.Secret{{Name: "a"}, {Name: "b"}}, nil
+	}
+	if err := listCmd(ctx, []string{"--project", "p", "--filter", "labels.env=prod"}, &out, list); err != nil {
+		t.Fatalf("listCmd() unexpected error = %v", err)
+	}
+	if out.String() != "a\nb\n" {
+		t.Errorf("listCmd() wrote %q", out.String())
+	}
+
+	var deleted string
+	del := func(_ context.Context, project, name, etag string) error {
+		deleted = project + "/" + name + "@" + etag
+		return nil
+	}
+	if err := deleteCmd(ctx, []string{"old-key", "--etag", `"abc"`}, del); err != nil {
+		t.Fatalf("deleteCmd() unexpected error = %v", err)
+	}
+	if deleted != `/old-key@"abc"` {
+		t.Errorf("deleteCmd() deleted %q", deleted)
+	}
+
+	out.Reset()
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	versions := func(context.Context, string, string) ([]*gsm.Version, error) {
+		return []*gsm.Version{{ID: "2", State: "ENABLED", CreateTime: created}, {ID: "1", State: "DESTROYED", CreateTime: created}}, nil
+	}
+	if err := versionsCmd(ctx, []string{"api-key"}, &out, versions); err != nil {
+		t.Fatalf("versionsCmd() unexpected error = %v", err)
+	}
+	want := "VERSION  STATE      CREATED\n" +
+		"2        ENABLED    2024-01-02T03:04:05Z\n" +
+		"1        DESTROYED  2024-01-02T03:04:05Z\n"
+	if out.String() != want {
+		t.Errorf("versionsCmd() wrote\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestEditCmd(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	ctx := context.Background()
+	script := filepath.Join(t.TempDir(), "editor")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nprintf ' edited' >> \"$1\"\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", "")
+
+	tests := []struct {
+		name    string
+		editor  string
+		current string
+		fetch   error
+		want    string
+		wantOut string
+	}{
+		{name: "changed", editor: script, current: "value", want: "value edited", wantOut: "s: new version added\n"},
+		{name: "unchanged", editor: "true", current: "value", wantOut: "s: unchanged\n"},
+		{name: "missing", editor: script, fetch: gsm.ErrNotFound, want: " edited", wantOut: "s: new version added\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EDITOR", tt.editor)
+			fetch := func(context.Context, string, string) (string, error) { return tt.current, tt.fetch }
+			var stored string
+			store := func(_ context.Context, _, _, value string) error {
+				stored = value
+				return nil
+			}
+			var out bytes.Buffer
+			if err := editCmd(ctx, []string{"s"}, &out, fetch, store); err != nil {
+				t.Fatalf("editCmd() unexpected error = %v", err)
+			}
+			if stored != tt.want || out.String() != tt.wantOut {
+				t.Errorf("editCmd() stored %q and wrote %q, want %q and %q", stored, out.String(), tt.want, tt.wantOut)
+			}
+		})
+	}
+
+	t.Setenv("EDITOR", "false")
+	fetch := func(context.Context, string, string) (string, error) { return "", nil }
+	store := func(context.Context, string, string, string) error {
+		t.Error("editCmd() stored a value after the editor failed")
+		return nil
+	}
+	if err := editCmd(ctx, []string{"s"}, &bytes.Buffer{}, fetch, store); err == nil {
+		t.Error("editCmd() with a failing editor succeeded, want error")
+	}
+}
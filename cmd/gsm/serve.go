@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/codeGROOVE-dev/gsm"
+)
+
+// fetchFunc fetches a secret; an empty project means the current project.
+type fetchFunc func(ctx context.Context, project, name string) (string, error)
+
+// serve runs a read-through proxy exposing GET /secret/NAME on a loopback
+// address (bearer token auth) and/or a Unix socket (peer credential auth).
+func serve(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	listen := fs.String("listen", "127.0.0.1:8099", "loopback address to listen on; empty disables TCP")
+	tokenFile := fs.String("token-file", "", "file containing the bearer token TCP clients must present (default: $GSM_SERVE_TOKEN)")
+	ttl := fs.Duration("cache-ttl", 5*time.Minute, "how long fetched secrets are cached")
+	socket := fs.String("socket", "", "Unix socket path to listen on, authenticating clients by peer UID/GID (Linux only)")
+	socketMode := fs.Uint("socket-mode", 0o660, "permissions for the Unix socket")
+	allowUIDs := allowFlag{}
+	allowGIDs := allowFlag{}
+	fs.Var(allowUIDs, "allow-uid", "UID=[PROJECT/]SECRET[,...] secrets a peer UID may read over the socket; entries without a project cover only the current project, and \"*\" allows all (repeatable)")
+	fs.Var(allowGIDs, "allow-gid", "GID=[PROJECT/]SECRET[,...] secrets a peer GID may read over the socket; entries without a project cover only the current project, and \"*\" allows all (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *listen == "" && *socket == "" {
+		return errors.New("nothing to serve: set --listen and/or --socket")
+	}
+
+	c := gsm.New(gsm.WithCache(*ttl))
+	fetch := clientFetch(c)
+
+	var servers []*http.Server
+	var listeners []net.Listener
+
+	if *listen != "" {
+		token, err := serveToken(*tokenFile)
+		if err != nil {
+			return err
+		}
+		if err := requireLoopback(*listen); err != nil {
+			return err
+		}
+		servers = append(servers, &http.Server{
+			Addr:              *listen,
+			Handler:           &secretHandler{token: token, fetch: fetch},
+			ReadHeaderTimeout: 5 * time.Second,
+		})
+		listeners = append(listeners, nil)
+	}
+
+	if *socket != "" {
+		if len(allowUIDs) == 0 && len(allowGIDs) == 0 {
+			return errors.New("--socket requires at least one --allow-uid or --allow-gid")
+		}
+		ln, err := listenUnix(*socket, os.FileMode(*socketMode)) //nolint:gosec // mode is a small permission value
+		if err != nil {
+			return err
+		}
+		defer os.Remove(*socket) //nolint:errcheck // best effort cleanup
+		servers = append(servers, &http.Server{
+			Addr:              *socket,
+			Handler:           &secretHandler{peers: &peerPolicy{uids: allowUIDs, gids: allowGIDs}, fetch: fetch},
+			ConnContext:       peerConnContext,
+			ReadHeaderTimeout: 5 * time.Second,
+		})
+		listeners = append(listeners, ln)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errc := make(chan error, len(servers))
+	for i, srv := range servers {
+		go func() { errc <- listenAndServe(ctx, srv, listeners[i]) }()
+	}
+
+	var firstErr error
+	for range servers {
+		if err := <-errc; err != nil && firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+	return firstErr
+}
+
+// listenAndServe runs srv until ctx is cancelled, then shuts it down gracefully.
+// If ln is nil, srv listens on srv.Addr.
+func listenAndServe(ctx context.Context, srv *http.Server, ln net.Listener) error {
+	errc := make(chan error, 1)
+	go func() {
+		slog.Info("serving secrets", "addr", srv.Addr)
+		if ln != nil {
+			errc <- srv.Serve(ln)
+			return
+		}
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func clientFetch(c *gsm.Client) fetchFunc {
+	return func(ctx context.Context, project, name string) (string, error) {
+		if project == "" {
+			return c.Fetch(ctx, name)
+		}
+		return c.FetchFromProject(ctx, project, name)
+	}
+}
+
+// serveToken reads the bearer token from path, or $GSM_SERVE_TOKEN if path is empty.
+func serveToken(path string) (string, error) {
+	token := os.Getenv("GSM_SERVE_TOKEN")
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("reading token file: %w", err)
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	if token == "" {
+		return "", errors.New("a bearer token is required: set --token-file or GSM_SERVE_TOKEN")
+	}
+	return token, nil
+}
+
+// requireLoopback rejects listen addresses reachable from other hosts.
+func requireLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid listen address: %w", err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("listen address %q is not a loopback address", addr)
+}
+
+// secretHandler serves GET /secret/NAME[?project=PROJECT]. Clients are
+// authenticated by peer credentials when peers is set, and by bearer token otherwise.
+type secretHandler struct {
+	fetch fetchFunc
+	peers *peerPolicy
+	token string
+}
+
+func (h *secretHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.peers == nil {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(h.token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	name, ok := strings.CutPrefix(r.URL.Path, "/secret/")
+	if !ok || name == "" || strings.Contains(name, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	project := r.URL.Query().Get("project")
+	if h.peers != nil {
+		cred, ok := peerFromRequest(r)
+		if !ok || !h.peers.allows(cred, project, name) {
+			slog.Warn("peer denied secret access", "uid", cred.uid, "gid", cred.gid, "pid", cred.pid, "project", project, "secret", name)
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+	}
+
+	value, err := h.fetch(r.Context(), project, name)
+	if err != nil {
+		slog.Warn("secret fetch failed", "error", err)
+		http.Error(w, "secret unavailable", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write([]byte(value)) //nolint:errcheck // client disconnects are not actionable
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSecretHandler(t *testing.T) {
+	h := &secretHandler{
+		token: "s3cret",
+		fetch: func(_ context.Context, project, name string) (string, error) {
+			if name == "missing" {
+				return "", errors.New("status 404")
+			}
+			return project + ":" + name, nil
+		},
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		auth     string
+		wantCode int
+		wantBody string
+	}{
+		{name: "ok", method: http.MethodGet, path: "/secret/db-password", auth: "Bearer s3cret", wantCode: http.StatusOK, wantBody: ":db-password"},
+		{name: "explicit project", method: http.MethodGet, path: "/secret/db-password?project=other-project", auth: "Bearer s3cret", wantCode: http.StatusOK, wantBody: "other-project:db-password"},
+		{name: "missing token", method: http.MethodGet, path: "/secret/db-password", wantCode: http.StatusUnauthorized},
+		{name: "wrong token", method: http.MethodGet, path: "/secret/db-password", auth: "Bearer nope", wantCode: http.StatusUnauthorized},
+		{name: "wrong method", method: http.MethodPost, path: "/secret/db-password", auth: "Bearer s3cret", wantCode: http.StatusMethodNotAllowed},
+		{name: "unknown path", method: http.MethodGet, path: "/other", auth: "Bearer s3cret", wantCode: http.StatusNotFound},
+		{name: "fetch error", method: http.MethodGet, path: "/secret/missing", auth: "Bearer s3cret", wantCode: http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, http.NoBody)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if tt.wantBody != "" {
+				body, _ := io.ReadAll(rec.Body) //nolint:errcheck // recorder reads never fail
+				if string(body) != tt.wantBody {
+					t.Errorf("body = %q, want %q", body, tt.wantBody)
+				}
+			}
+		})
+	}
+}
+
+func TestRequireLoopback(t *testing.T) {
+	tests := []struct {
+		addr    string
+		wantErr bool
+	}{
+		{"127.0.0.1:8099", false},
+		{"[::1]:8099", false},
+		{"localhost:8099", false},
+		{"0.0.0.0:8099", true},
+		{":8099", true},
+		{"10.0.0.5:8099", true},
+		{"nonsense", true},
+	}
+	for _, tt := range tests {
+		if err := requireLoopback(tt.addr); (err != nil) != tt.wantErr {
+			t.Errorf("requireLoopback(%q) error = %v, wantErr %v", tt.addr, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/codeGROOVE-dev/gsm"
+)
+
+// syncCmd mirrors secrets from one project to others, once or continuously,
+// printing a status report after each pass.
+func syncCmd(ctx context.Context, args []string, stdout io.Writer, c *gsm.Client) error {
+	cfg, interval, err := parseSyncArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if interval == 0 {
+		r, err := c.SyncOnce(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		writeSyncReport(stdout, r)
+		return r.Err()
+	}
+
+	err = c.Sync(ctx, cfg, interval, func(r *gsm.SyncReport) { writeSyncReport(stdout, r) })
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// parseSyncArgs parses sync flags and the optional secret names into a config.
+func parseSyncArgs(args []string) (gsm.SyncConfig, time.Duration, error) {
+	fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+	from := fs.String("from", "", "source project")
+	to := fs.String("to", "", "comma-separated destination projects")
+	filter := fs.String("filter", "", "Secret Manager list filter selecting secrets when none are named")
+	del := fs.Bool("delete", false, "delete destination secrets no longer in the source")
+	dryRun := fs.Bool("dry-run", false, "report what would change without writing")
+	interval := fs.Duration("interval", 0, "sync continuously at this interval (default: sync once)")
+	names, err := parseInterspersed(fs, args)
+	if err != nil {
+		return gsm.SyncConfig{}, 0, err
+	}
+	if *from == "" || *to == "" {
+		return gsm.SyncConfig{}, 0, errors.New("usage: gsm sync --from PROJECT --to PROJECT[,PROJECT...] [--filter F] [--delete] [--dry-run] [--interval D] [SECRET...]")
+	}
+	if len(names) > 0 && *filter != "" {
+		return gsm.SyncConfig{}, 0, errors.New("--filter cannot be combined with secret names")
+	}
+	if *interval < 0 {
+		return gsm.SyncConfig{}, 0, fmt.Errorf("invalid interval %v", *interval)
+	}
+
+	return gsm.SyncConfig{
+		Source:       *from,
+		Destinations: strings.Split(*to, ","),
+		Secrets:      names,
+		Filter:       *filter,
+		Delete:       *del,
+		DryRun:       *dryRun,
+	}, *interval, nil
+}
+
+// writeSyncReport prints one line per changed or failed secret and a summary.
+func writeSyncReport(w io.Writer, r *gsm.SyncReport) {
+	counts := map[gsm.SyncAction]int{}
+	for _, res := range r.Results {
+		counts[res.Action]++
+		switch {
+		case res.Err != nil:
+			fmt.Fprintf(w, "%s/%s: %s: %v\n", res.Destination, res.Secret, res.Action, res.Err)
+		case res.Action != gsm.SyncUnchanged:
+			fmt.Fprintf(w, "%s/%s: %s\n", res.Destination, res.Secret, res.Action)
+		}
+	}
+
+	prefix := ""
+	if r.DryRun {
+		prefix = "dry run: "
+	}
+	fmt.Fprintf(w, "%s%s: %d updated, %d deleted, %d unchanged, %d failed\n", prefix, r.Time.Format(time.RFC3339),
+		counts[gsm.SyncUpdated], counts[gsm.SyncDeleted], counts[gsm.SyncUnchanged], counts[gsm.SyncFailed])
+}
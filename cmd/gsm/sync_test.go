@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codeGROOVE-dev/gsm"
+)
+
+func TestParseSyncArgs(t *testing.T) {
+	cfg, interval, err := parseSyncArgs([]string{"--from", "src-project", "db-password", "--to", "dst-one,dst-two", "--delete", "--interval", "1m", "api-key"})
+	if err != nil {
+		t.Fatalf("parseSyncArgs() unexpected error = %v", err)
+	}
+	want := gsm.SyncConfig{
+		Source:       "src-project",
+		Destinations: []string{"dst-one", "dst-two"},
+		Secrets:      []string{"db-password", "api-key"},
+		Delete:       true,
+	}
+	if !reflect.DeepEqual(cfg, want) || interval != time.Minute {
+		t.Errorf("parseSyncArgs() = %+v, %v, want %+v, 1m", cfg, interval, want)
+	}
+
+	for _, args := range [][]string{
+		{"--to", "dst-one"},
+		{"--from", "src-project", "--to", "dst-one", "--filter", "labels.a=b", "name"},
+		{"--from", "src-project", "--to", "dst-one", "--interval", "-1s"},
+	} {
+		if _, _, err := parseSyncArgs(args); err == nil {
+			t.Errorf("parseSyncArgs(%q) succeeded, want error", args)
+		}
+	}
+}
+
+func TestWriteSyncReport(t *testing.T) {
+	r := &gsm.SyncReport{
+		Time:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		DryRun: true,
+		Results: []gsm.SyncResult{
+			{Destination: "dst", Secret: "a", Action: gsm.SyncUnchanged},
+			{Destination: "dst", Secret: "b", Action: gsm.SyncUpdated},
+			{Destination: "dst", Secret: "c", Action: gsm.SyncFailed, Err: errors.New("denied")},
+		},
+	}
+	var out bytes.Buffer
+	writeSyncReport(&out, r)
+	want := "dst/b: updated\ndst/c: failed: denied\ndry run: 2024-01-02T03:04:05Z: 1 updated, 0 deleted, 1 unchanged, 1 failed\n"
+	if out.String() != want {
+		t.Errorf("writeSyncReport() = %q, want %q", out.String(), want)
+	}
+	if !strings.Contains(r.Err().Error(), "dst/c: denied") {
+		t.Errorf("SyncReport.Err() = %v", r.Err())
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// tfRead implements the Terraform external data source protocol: it reads a
+// JSON object of string values from in, where each value is a secret reference
+// ("NAME" or "PROJECT/NAME"), and writes an object with the same keys mapped
+// to the secret values to out.
+func tfRead(ctx context.Context, in io.Reader, out io.Writer, fetch fetchFunc) error {
+	var query map[string]string
+	if err := json.NewDecoder(in).Decode(&query); err != nil {
+		return fmt.Errorf("reading query: %w", err)
+	}
+
+	keys := slices.Sorted(maps.Keys(query))
+
+	result := make(map[string]string, len(query))
+	for _, k := range keys {
+		project, name := splitRef(query[k])
+		v, err := fetch(ctx, project, name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+		result[k] = v
+	}
+
+	return json.NewEncoder(out).Encode(result)
+}
+
+// splitRef splits a "PROJECT/NAME" secret reference; a bare "NAME" refers to the current project.
+func splitRef(ref string) (project, name string) {
+	if p, n, ok := strings.Cut(ref, "/"); ok {
+		return p, n
+	}
+	return "", ref
+}
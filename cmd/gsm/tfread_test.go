@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTFRead(t *testing.T) {
+	fetch := func(_ context.Context, project, name string) (string, error) {
+		if name == "missing" {
+			return "", errors.New("not found")
+		}
+		return project + ":" + name, nil
+	}
+
+	tests := []struct {
+		name        string
+		in          string
+		want        string
+		errContains string
+	}{
+		{
+			name: "current and explicit project",
+			in:   `{"db_password":"db-password","api_key":"other-project/api-key"}`,
+			want: `{"api_key":"other-project:api-key","db_password":":db-password"}` + "\n",
+		},
+		{name: "empty query", in: `{}`, want: "{}\n"},
+		{name: "fetch error names key", in: `{"x":"missing"}`, errContains: "x: not found"},
+		{name: "non-string values rejected", in: `{"x":1}`, errContains: "reading query"},
+		{name: "malformed input", in: `not json`, errContains: "reading query"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := tfRead(context.Background(), strings.NewReader(tt.in), &out, fetch)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("tfRead() error = %v, want error containing %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("tfRead() unexpected error = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("tfRead() output = %s, want %s", out.String(), tt.want)
+			}
+		})
+	}
+}
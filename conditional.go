@@ -0,0 +1,205 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrAlreadyExists is returned (wrapped) by StoreIfAbsent when the secret already
+// exists, and matches, with errors.Is, API errors for resources that already exist.
+var ErrAlreadyExists = errors.New("secret already exists")
+
+// StoreIfAbsent creates a secret with value as its first version in the current project
+// using the default client, returning ErrAlreadyExists if the secret already exists.
+// The project ID is auto-detected from the GCP metadata server.
+func StoreIfAbsent(ctx context.Context, name, value string, opts ...StoreOption) error {
+	return defaultClient.StoreIfAbsent(ctx, name, value, opts...)
+}
+
+// StoreIfAbsentInProject creates a secret with value as its first version in a specific
+// project using the default client, returning ErrAlreadyExists if the secret already exists.
+func StoreIfAbsentInProject(ctx context.Context, pid, name, value string, opts ...StoreOption) error {
+	return defaultClient.StoreIfAbsentInProject(ctx, pid, name, value, opts...)
+}
+
+// StoreIfAbsent creates a secret with value as its first version in the current project,
+// returning ErrAlreadyExists if the secret already exists. Use it for seed-once
+// bootstrap secrets that must never be overwritten.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) StoreIfAbsent(ctx context.Context, name, value string, opts ...StoreOption) error {
+	if !secretNameRegex.MatchString(name) {
+		return errors.New("invalid secret name format")
+	}
+
+	p, err := c.projectID(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.StoreIfAbsentInProject(ctx, p, name, value, opts...)
+}
+
+// StoreIfAbsentInProject creates a secret with value as its first version in a specific
+// project, returning ErrAlreadyExists if the secret already exists, even without
+// any versions: it may have just been created by a concurrent caller. If this call
+// created the secret but failed to add the version, pass its error to ResumeStore
+// to add only the version.
+func (c *Client) StoreIfAbsentInProject(ctx context.Context, pid, name, value string, opts ...StoreOption) error {
+	if !projectIDRegex.MatchString(pid) {
+		return fmt.Errorf("invalid project ID format: %q", pid)
+	}
+	if !secretNameRegex.MatchString(name) {
+		return errors.New("invalid secret name format")
+	}
+	_, err := c.store(ctx, pid, name, value, newStoreOptions(opts), storeIfAbsent)
+	return err
+}
+
+// StoreIfChanged adds value as a new version of a secret in the current project using
+// the default client, unless the latest version already holds value.
+// It reports whether a new version was written.
+// The project ID is auto-detected from the GCP metadata server.
+func StoreIfChanged(ctx context.Context, name, value string, opts ...StoreOption) (bool, error) {
+	return defaultClient.StoreIfChanged(ctx, name, value, opts...)
+}
+
+// StoreIfChangedInProject adds value as a new version of a secret in a specific project
+// using the default client, unless the latest version already holds value.
+// It reports whether a new version was written.
+func StoreIfChangedInProject(ctx context.Context, pid, name, value string, opts ...StoreOption) (bool, error) {
+	return defaultClient.StoreIfChangedInProject(ctx, pid, name, value, opts...)
+}
+
+// StoreIfChanged adds value as a new version of a secret in the current project, unless
+// the latest version already holds value, so reconcilers can call it every cycle
+// without piling up identical versions. Missing secrets are created as with Store.
+// It reports whether a new version was written.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) StoreIfChanged(ctx context.Context, name, value string, opts ...StoreOption) (bool, error) {
+	if !secretNameRegex.MatchString(name) {
+		return false, errors.New("invalid secret name format")
+	}
+
+	p, err := c.projectID(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return c.StoreIfChangedInProject(ctx, p, name, value, opts...)
+}
+
+// StoreIfChangedInProject adds value as a new version of a secret in a specific project,
+// unless the latest version already holds value. It reports whether a new version was written.
+func (c *Client) StoreIfChangedInProject(ctx context.Context, pid, name, value string, opts ...StoreOption) (bool, error) {
+	if !projectIDRegex.MatchString(pid) {
+		return false, fmt.Errorf("invalid project ID format: %q", pid)
+	}
+	if !secretNameRegex.MatchString(name) {
+		return false, errors.New("invalid secret name format")
+	}
+	return c.store(ctx, pid, name, value, newStoreOptions(opts), storeIfChanged)
+}
+
+// FetchOrStore returns the latest value of a secret in the current project using the
+// default client, generating and storing a new value with gen if the secret is missing.
+// The project ID is auto-detected from the GCP metadata server.
+func FetchOrStore(ctx context.Context, name string, gen func() (string, error), opts ...StoreOption) (string, error) {
+	return defaultClient.FetchOrStore(ctx, name, gen, opts...)
+}
+
+// FetchOrStoreInProject returns the latest value of a secret in a specific project using the
+// default client, generating and storing a new value with gen if the secret is missing.
+func FetchOrStoreInProject(ctx context.Context, pid, name string, gen func() (string, error), opts ...StoreOption) (string, error) {
+	return defaultClient.FetchOrStoreInProject(ctx, pid, name, gen, opts...)
+}
+
+// FetchOrStore returns the latest value of a secret in the current project, generating
+// and storing a new value with gen if the secret is missing. It suits first-boot
+// generation of signing keys and admin passwords.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) FetchOrStore(ctx context.Context, name string, gen func() (string, error), opts ...StoreOption) (string, error) {
+	if !secretNameRegex.MatchString(name) {
+		return "", errors.New("invalid secret name format")
+	}
+
+	p, err := c.projectID(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return c.FetchOrStoreInProject(ctx, p, name, gen, opts...)
+}
+
+// FetchOrStoreInProject returns the latest value of a secret in a specific project,
+// generating and storing a new value with gen if the secret is missing.
+// Creation uses StoreIfAbsent semantics, so when several instances race only one
+// generated value is stored and every caller returns that value.
+func (c *Client) FetchOrStoreInProject(ctx context.Context, pid, name string, gen func() (string, error), opts ...StoreOption) (string, error) {
+	value, err := c.FetchFromProject(ctx, pid, name)
+	if !hasStatus(err, http.StatusNotFound) {
+		return value, err
+	}
+
+	value, err = gen()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate secret %s: %w", name, err)
+	}
+	err = c.StoreIfAbsentInProject(ctx, pid, name, value, opts...)
+	if err == nil {
+		return value, nil
+	}
+	if !errors.Is(err, ErrAlreadyExists) {
+		return "", err
+	}
+
+	// Another instance created the secret first; its version may not be added yet.
+	c.log().Info("secret created concurrently, fetching the stored value")
+	tok, err := c.accessToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	rl := c.newRetryLog(c.apiRetry, "fetch concurrently stored secret")
+	for attempt := range c.apiRetry.attempts() {
+		if attempt > 0 {
+			if err := rl.wait(ctx, attempt, err); err != nil {
+				return "", err
+			}
+		}
+		value, _, err = c.accessVersion(ctx, tok, pid, name, "latest")
+		if !hasStatus(err, http.StatusNotFound) {
+			return value, err
+		}
+	}
+	return "", err
+}
+
+// ResumeStore completes a Store that failed with a *StoreError, using the default client.
+func ResumeStore(ctx context.Context, err error, value string, opts ...StoreOption) error {
+	return defaultClient.ResumeStore(ctx, err, value, opts...)
+}
+
+// ResumeStore completes a Store that failed with a *StoreError. If the failure was in
+// the add version step the secret is known to exist, so only the version is added;
+// otherwise the whole Store is repeated. Pass the same value and options as the
+// original call.
+func (c *Client) ResumeStore(ctx context.Context, err error, value string, opts ...StoreOption) error {
+	var se *StoreError
+	if !errors.As(err, &se) {
+		return fmt.Errorf("cannot resume store: %w", err)
+	}
+	if !projectIDRegex.MatchString(se.Project) {
+		return fmt.Errorf("invalid project ID format: %q", se.Project)
+	}
+	if !secretNameRegex.MatchString(se.Secret) {
+		return errors.New("invalid secret name format")
+	}
+
+	mode := storeAlways
+	if se.Step == StepAddVersion {
+		mode = storeResume
+	}
+	_, err = c.store(ctx, se.Project, se.Secret, value, newStoreOptions(opts), mode)
+	return err
+}
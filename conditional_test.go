@@ -0,0 +1,253 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStoreIfAbsent(t *testing.T) {
+	tests := []struct {
+		name         string
+		createStatus int
+		wantErr      error
+		wantVersion  bool
+	}{
+		{name: "absent", createStatus: http.StatusOK, wantVersion: true},
+		// An existing secret is never written, even if it has no versions yet.
+		{name: "exists", createStatus: http.StatusConflict, wantErr: ErrAlreadyExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addedVersion := false
+			setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasSuffix(r.URL.Path, ":addVersion") {
+					addedVersion = true
+					_, _ = w.Write([]byte(`{"name":"projects/test-project/secrets/seed/versions/1"}`)) //nolint:errcheck // test mock server
+					return
+				}
+				w.WriteHeader(tt.createStatus)
+				_, _ = w.Write([]byte(`{}`)) //nolint:errcheck // test mock server
+			})
+
+			err := StoreIfAbsent(context.Background(), "seed", "value")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("StoreIfAbsent() error = %v, want %v", err, tt.wantErr)
+			}
+			if addedVersion != tt.wantVersion {
+				t.Errorf("added version = %v, want %v", addedVersion, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestStoreIfAbsentConcurrent(t *testing.T) {
+	var created atomic.Bool
+	var adds atomic.Int32
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ":addVersion") {
+			adds.Add(1)
+			_, _ = w.Write([]byte(`{"name":"projects/test-project/secrets/seed/versions/1"}`)) //nolint:errcheck // test mock server
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/versions") {
+			_, _ = w.Write([]byte(`{}`)) //nolint:errcheck // test mock server
+			return
+		}
+		if !created.CompareAndSwap(false, true) {
+			w.WriteHeader(http.StatusConflict)
+		}
+		_, _ = w.Write([]byte(`{}`)) //nolint:errcheck // test mock server
+	})
+
+	// Every caller but the one that created the secret fails, although the
+	// secret has no versions while they race.
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = StoreIfAbsent(context.Background(), "seed", "value")
+		}()
+	}
+	wg.Wait()
+	stored := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			stored++
+		case !errors.Is(err, ErrAlreadyExists):
+			t.Errorf("StoreIfAbsent() error = %v, want nil or ErrAlreadyExists", err)
+		}
+	}
+	if stored != 1 || adds.Load() != 1 {
+		t.Errorf("%d callers stored and %d versions were added, want 1 and 1", stored, adds.Load())
+	}
+}
+
+func TestStoreIfChanged(t *testing.T) {
+	tests := []struct {
+		name        string
+		current     string
+		accessCode  int
+		wantWritten bool
+		wantErr     bool
+	}{
+		{name: "unchanged", current: "value", accessCode: http.StatusOK},
+		{name: "changed", current: "old", accessCode: http.StatusOK, wantWritten: true},
+		{name: "missing", accessCode: http.StatusNotFound, wantWritten: true},
+		{name: "denied", accessCode: http.StatusForbidden, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addedVersion := false
+			setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, ":access"):
+					if tt.accessCode != http.StatusOK {
+						w.WriteHeader(tt.accessCode)
+						return
+					}
+					writePayload(w, "projects/test-project/secrets/s/versions/1", tt.current)
+				case strings.HasSuffix(r.URL.Path, ":addVersion"):
+					addedVersion = true
+					_, _ = w.Write([]byte(`{"name":"projects/test-project/secrets/s/versions/2"}`)) //nolint:errcheck // test mock server
+				default:
+					w.WriteHeader(http.StatusConflict)
+				}
+			})
+
+			written, err := StoreIfChanged(context.Background(), "s", "value")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StoreIfChanged() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if written != tt.wantWritten || addedVersion != tt.wantWritten {
+				t.Errorf("StoreIfChanged() = %v (added version: %v), want %v", written, addedVersion, tt.wantWritten)
+			}
+		})
+	}
+}
+
+func TestFetchOrStore(t *testing.T) {
+	tests := []struct {
+		name          string
+		existing      string
+		createCode    int
+		wantValue     string
+		wantGenerated bool
+	}{
+		{name: "exists", existing: "old", wantValue: "old"},
+		{name: "missing", createCode: http.StatusOK, wantValue: "generated", wantGenerated: true},
+		{name: "lost race", createCode: http.StatusConflict, wantValue: "winner", wantGenerated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := tt.existing
+			setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, ":access"):
+					if stored == "" {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					writePayload(w, "projects/test-project/secrets/s/versions/1", stored)
+				case strings.HasSuffix(r.URL.Path, "/versions"):
+					_, _ = w.Write([]byte(`{"versions":[{"name":"projects/test-project/secrets/s/versions/1"}]}`)) //nolint:errcheck // test mock server
+				case strings.HasSuffix(r.URL.Path, ":addVersion"):
+					stored = "generated"
+					_, _ = w.Write([]byte(`{"name":"projects/test-project/secrets/s/versions/1"}`)) //nolint:errcheck // test mock server
+				default:
+					if tt.createCode == http.StatusConflict {
+						stored = "winner"
+					}
+					w.WriteHeader(tt.createCode)
+					_, _ = w.Write([]byte(`{}`)) //nolint:errcheck // test mock server
+				}
+			})
+
+			generated := false
+			got, err := FetchOrStore(context.Background(), "s", func() (string, error) {
+				generated = true
+				return "generated", nil
+			})
+			if err != nil {
+				t.Fatalf("FetchOrStore() unexpected error = %v", err)
+			}
+			if got != tt.wantValue || generated != tt.wantGenerated {
+				t.Errorf("FetchOrStore() = %q (generated: %v), want %q (generated: %v)", got, generated, tt.wantValue, tt.wantGenerated)
+			}
+		})
+	}
+}
+
+func TestFetchOrStoreRetryHook(t *testing.T) {
+	// The winner's version appears only on the third access.
+	var accesses atomic.Int32
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ":access") {
+			if accesses.Add(1) < 3 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			writePayload(w, "projects/test-project/secrets/s/versions/1", "winner")
+			return
+		}
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{}`)) //nolint:errcheck // test mock server
+	})
+
+	var events []RetryEvent
+	c := New(WithAPIRetry(RetryPolicy{Attempts: 3, Delay: time.Millisecond}),
+		WithRetryHook(func(_ context.Context, ev RetryEvent) { events = append(events, ev) }))
+	got, err := c.FetchOrStoreInProject(context.Background(), "test-project", "s", func() (string, error) { return "generated", nil })
+	if err != nil || got != "winner" {
+		t.Fatalf("FetchOrStoreInProject() = %q, %v, want winner", got, err)
+	}
+	if len(events) != 1 || events[0].Op != "fetch concurrently stored secret" || events[0].Attempt != 2 {
+		t.Errorf("retry events = %+v, want one retry of the stored value fetch", events)
+	}
+}
+
+func TestResumeStore(t *testing.T) {
+	var creates, adds int
+	addCode := http.StatusServiceUnavailable
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ":addVersion") {
+			adds++
+			w.WriteHeader(addCode)
+			_, _ = w.Write([]byte(`{"name":"projects/test-project/secrets/s/versions/1"}`)) //nolint:errcheck // test mock server
+			return
+		}
+		creates++
+		_, _ = w.Write([]byte(`{}`)) //nolint:errcheck // test mock server
+	})
+
+	ctx := context.Background()
+	err := Store(ctx, "s", "value")
+	var se *StoreError
+	if !errors.As(err, &se) || se.Step != StepAddVersion || !se.Created {
+		t.Fatalf("Store() error = %v, want add version failure after create", err)
+	}
+
+	addCode = http.StatusOK
+	creates, adds = 0, 0
+	if err := ResumeStore(ctx, err, "value"); err != nil {
+		t.Fatalf("ResumeStore() unexpected error = %v", err)
+	}
+	if creates != 0 || adds != 1 {
+		t.Errorf("ResumeStore() made %d creates and %d adds, want 0 and 1", creates, adds)
+	}
+
+	if err := ResumeStore(ctx, errors.New("other"), "value"); err == nil {
+		t.Error("ResumeStore() with a non-store error expected error")
+	}
+}
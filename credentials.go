@@ -0,0 +1,326 @@
+package gsm
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CredentialsEnv names the environment variable holding the path of a
+// service account key file. When it is set, clients authenticate with that key
+// instead of the metadata server, so gsm also works off GCP, e.g. on CI runners
+// and on-prem hosts. See WithCredentialsFile.
+//
+// If it is not set, the Application Default Credentials file written by
+// "gcloud auth application-default login" is used when present, so code run by
+// developers locally authenticates as them.
+const CredentialsEnv = "GOOGLE_APPLICATION_CREDENTIALS"
+
+// ErrNoCredentials is returned (wrapped) when no credentials file is configured
+// and the metadata server is unreachable because the process isn't on GCP.
+var ErrNoCredentials = errors.New("no Google Cloud credentials found")
+
+// noCredentials explains how to supply credentials after the metadata server
+// turned out to be unreachable.
+func noCredentials(err error) error {
+	return fmt.Errorf(`%w: not running on GCP (%w); set %s to a service account key file, `+
+		`run "gcloud auth application-default login", or use WithCredentialsFile`, ErrNoCredentials, err, CredentialsEnv)
+}
+
+const (
+	defaultTokenURI = "https://oauth2.googleapis.com/token"
+	cloudPlatform   = "https://www.googleapis.com/auth/cloud-platform"
+)
+
+// WithCredentialsFile authenticates with the service account key or gcloud
+// user credentials file at path rather than the metadata server or
+// $GOOGLE_APPLICATION_CREDENTIALS. The file is read on first use. The current
+// project is a service account key's project_id, otherwise
+// $GOOGLE_CLOUD_PROJECT, otherwise user credentials' quota_project_id.
+func WithCredentialsFile(path string) Option {
+	return func(c *Client) {
+		c.credsPath = path
+	}
+}
+
+// WithScopes requests access tokens limited to the given OAuth scopes instead of
+// https://www.googleapis.com/auth/cloud-platform. Service account keys sign the
+// scopes into their assertion and the metadata server is asked for them, though
+// GCE only grants scopes enabled on the instance. User credentials keep the
+// scopes they were granted at login.
+func WithScopes(scopes ...string) Option {
+	return func(c *Client) {
+		c.scopes = scopes
+	}
+}
+
+// scope returns the space-separated OAuth scopes to request.
+func (c *Client) scope() string {
+	if len(c.scopes) == 0 {
+		return cloudPlatform
+	}
+	return strings.Join(c.scopes, " ")
+}
+
+// wellKnownCredentials returns the path of the Application Default Credentials
+// file written by "gcloud auth application-default login", or "" if there is none.
+func wellKnownCredentials() string {
+	dir := os.Getenv("CLOUDSDK_CONFIG")
+	if dir == "" {
+		if runtime.GOOS == "windows" {
+			dir = filepath.Join(os.Getenv("APPDATA"), "gcloud")
+		} else if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, ".config", "gcloud")
+		} else {
+			return ""
+		}
+	}
+	path := filepath.Join(dir, "application_default_credentials.json")
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// credentialsFile is the JSON credentials format written by gcloud and the
+// Cloud console: a service account key, or a user's OAuth refresh token.
+type credentialsFile struct {
+	Type           string `json:"type"`
+	ProjectID      string `json:"project_id"`
+	PrivateKeyID   string `json:"private_key_id"`
+	PrivateKey     string `json:"private_key"`
+	ClientEmail    string `json:"client_email"`
+	TokenURI       string `json:"token_uri"`
+	ClientID       string `json:"client_id"`
+	ClientSecret   string `json:"client_secret"`
+	RefreshToken   string `json:"refresh_token"`
+	QuotaProjectID string `json:"quota_project_id"`
+}
+
+// credentials mints access tokens from a credentials file, caching each token
+// until shortly before it expires.
+type credentials struct {
+	expires   time.Time
+	key       *rsa.PrivateKey
+	file      credentialsFile
+	token     string
+	projectID string
+	mu        sync.Mutex
+}
+
+// loadCredentials parses a service account key or user credentials file.
+func loadCredentials(data []byte) (*credentials, error) {
+	var f credentialsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing credentials: %w", err)
+	}
+	if f.TokenURI == "" {
+		f.TokenURI = defaultTokenURI
+	}
+	switch f.Type {
+	case "service_account":
+	case "authorized_user":
+		if f.RefreshToken == "" || f.ClientID == "" || f.ClientSecret == "" {
+			return nil, errors.New("user credentials need client_id, client_secret, and refresh_token")
+		}
+		return &credentials{file: f}, nil
+	default:
+		return nil, fmt.Errorf("unsupported credentials type %q", f.Type)
+	}
+	if f.ClientEmail == "" {
+		return nil, errors.New("service account key has no client_email")
+	}
+
+	block, _ := pem.Decode([]byte(f.PrivateKey))
+	if block == nil {
+		return nil, errors.New("service account key has no PEM private key")
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if k, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return nil, fmt.Errorf("parsing service account private key: %w", err)
+		}
+	}
+	key, ok := k.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("service account private key is not an RSA key")
+	}
+
+	return &credentials{file: f, key: key, projectID: f.ProjectID}, nil
+}
+
+// credentials returns the client's file credentials, or nil if the client
+// uses the metadata server.
+func (c *Client) credentials() (*credentials, error) {
+	if c.credsPath == "" {
+		return nil, nil //nolint:nilnil // nil means no key file is configured
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.creds != nil {
+		return c.creds, nil
+	}
+
+	data, err := os.ReadFile(c.credsPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading credentials: %w", err)
+	}
+	creds, err := loadCredentials(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.credsPath, err)
+	}
+	c.log().Info("using credentials file", "type", creds.file.Type, "email", creds.file.ClientEmail)
+	c.creds = creds
+	return creds, nil
+}
+
+// credentialsProjectID returns the current project for file credentials.
+func credentialsProjectID(creds *credentials) (string, error) {
+	if creds.projectID != "" {
+		return creds.projectID, nil
+	}
+	if p := os.Getenv("GOOGLE_CLOUD_PROJECT"); p != "" {
+		return p, nil
+	}
+	if creds.file.QuotaProjectID != "" {
+		return creds.file.QuotaProjectID, nil
+	}
+	return "", errors.New("failed to get project ID: credentials have no project and GOOGLE_CLOUD_PROJECT is not set")
+}
+
+// credentialsToken returns a cached access token, or exchanges a freshly
+// signed assertion or the user's refresh token for a new one.
+func (c *Client) credentialsToken(ctx context.Context, creds *credentials) (string, error) {
+	creds.mu.Lock()
+	defer creds.mu.Unlock()
+	if creds.token != "" && time.Until(creds.expires) > tokenRefreshMargin {
+		return creds.token, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {creds.file.ClientID},
+		"client_secret": {creds.file.ClientSecret},
+		"refresh_token": {creds.file.RefreshToken},
+	}
+	if creds.key != nil {
+		assertion, err := creds.assertion(time.Now(), c.scope())
+		if err != nil {
+			return "", err
+		}
+		form = url.Values{
+			"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+			"assertion":  {assertion},
+		}
+	}
+	tok, expires, err := c.exchangeToken(ctx, creds.file.TokenURI, form)
+	if err != nil && creds.key == nil {
+		return "", fmt.Errorf(`%w (run "gcloud auth application-default login" to renew user credentials)`, err)
+	}
+	if err != nil {
+		return "", err
+	}
+	creds.token, creds.expires = tok, expires
+	return tok, nil
+}
+
+// assertion returns a JWT signed with the service account key requesting a
+// token for scope; see https://developers.google.com/identity/protocols/oauth2/service-account.
+func (creds *credentials) assertion(now time.Time, scope string) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": creds.file.PrivateKeyID})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iss":   creds.file.ClientEmail,
+		"scope": scope,
+		"aud":   creds.file.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(nil, creds.key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// exchangeToken posts an OAuth 2.0 token request and returns the access token
+// and its expiry, retrying transient failures per the API retry policy.
+func (c *Client) exchangeToken(ctx context.Context, tokenURI string, form url.Values) (string, time.Time, error) {
+	var lastErr error
+	rl := c.newRetryLog(c.apiRetry, "exchange token")
+	for attempt := range c.apiRetry.attempts() {
+		if attempt > 0 {
+			c.log().Info("retrying token exchange", "attempt", attempt+1)
+			if err := rl.wait(ctx, attempt, lastErr); err != nil {
+				return "", time.Time{}, rl.fail(err)
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURI, strings.NewReader(form.Encode()))
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		resp, err := rl.do(c.baseHTTP(), req)
+		if err != nil {
+			lastErr = err
+			c.log().Warn("token exchange failed", "attempt", attempt+1, "error", err)
+			continue
+		}
+		body, err := readBody(ctx, resp.Body)
+		resp.Body.Close() //nolint:errcheck,gosec // best effort close
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if clientError(resp.StatusCode) {
+			// The body is an OAuth error such as invalid_grant; it holds no secrets.
+			return "", time.Time{}, rl.fail(fmt.Errorf("token exchange denied: status %d: %s", resp.StatusCode, body))
+		}
+		if resp.StatusCode != http.StatusOK {
+			lastErr = fmt.Errorf("token endpoint status %d", resp.StatusCode)
+			c.log().Warn("token exchange failed", "attempt", attempt+1, "status", resp.StatusCode)
+			continue
+		}
+
+		var result struct {
+			AccessToken string `json:"access_token"`
+			ExpiresIn   int    `json:"expires_in"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			lastErr = err
+			continue
+		}
+		if result.AccessToken == "" {
+			lastErr = errors.New("empty access token")
+			continue
+		}
+		return result.AccessToken, time.Now().Add(time.Duration(result.ExpiresIn) * time.Second), nil
+	}
+	return "", time.Time{}, rl.fail(fmt.Errorf("token exchange failed: %w", lastErr))
+}
@@ -0,0 +1,255 @@
+package gsm
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// writeServiceAccountKey writes a key file for a new RSA key and returns its path.
+func writeServiceAccountKey(t *testing.T, tokenURI string) (string, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "key-project",
+		"private_key_id": "kid-1",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email":   "ci@key-project.iam.gserviceaccount.com",
+		"token_uri":      tokenURI,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "key.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path, key
+}
+
+func TestServiceAccountCredentials(t *testing.T) {
+	var exchanges atomic.Int32
+	var key *rsa.PrivateKey
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		exchanges.Add(1)
+		if r.FormValue("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		parts := strings.Split(r.FormValue("assertion"), ".")
+		sig, _ := base64.RawURLEncoding.DecodeString(parts[2])    //nolint:errcheck // checked by VerifyPKCS1v15
+		claims, _ := base64.RawURLEncoding.DecodeString(parts[1]) //nolint:errcheck // checked below
+		sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		var c struct{ Iss, Scope, Aud string }
+		_ = json.Unmarshal(claims, &c) //nolint:errcheck // test mock server
+		if rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, sum[:], sig) != nil ||
+			c.Iss != "ci@key-project.iam.gserviceaccount.com" || c.Scope != cloudPlatform || !strings.HasSuffix(c.Aud, "/token") {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`)) //nolint:errcheck // test mock server
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"sa-token","expires_in":3600}`)) //nolint:errcheck // test mock server
+	}))
+	t.Cleanup(tokenServer.Close)
+
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sa-token" || !strings.Contains(r.URL.Path, "/projects/key-project/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		writePayload(w, "projects/key-project/secrets/s/versions/1", "value")
+	})
+
+	var path string
+	path, key = writeServiceAccountKey(t, tokenServer.URL+"/token")
+	t.Setenv(CredentialsEnv, path)
+	ctx := context.Background()
+
+	c := New()
+	for range 2 {
+		if v, err := c.Fetch(ctx, "s"); err != nil || v != "value" {
+			t.Fatalf("Fetch() = %q, %v, want value", v, err)
+		}
+	}
+	if n := exchanges.Load(); n != 1 {
+		t.Errorf("token exchanges = %d, want 1 (cached)", n)
+	}
+
+	// A key the token endpoint rejects fails without retrying.
+	other, _ := writeServiceAccountKey(t, tokenServer.URL+"/token")
+	exchanges.Store(0)
+	_, err := New(WithCredentialsFile(other)).Fetch(ctx, "s")
+	if err == nil || !strings.Contains(err.Error(), "failed to get access token") || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("Fetch() with a rejected key = %v", err)
+	}
+	if n := exchanges.Load(); n != 1 {
+		t.Errorf("token exchanges = %d, want 1", n)
+	}
+}
+
+func TestLoadCredentialsErrors(t *testing.T) {
+	for _, tt := range []struct{ data, want string }{
+		{`{`, "parsing credentials"},
+		{`{"type":"external_account"}`, `unsupported credentials type "external_account"`},
+		{`{"type":"service_account"}`, "no client_email"},
+		{`{"type":"service_account","client_email":"a@b","private_key":"junk"}`, "no PEM private key"},
+		{`{"type":"authorized_user","client_id":"a"}`, "need client_id, client_secret, and refresh_token"},
+	} {
+		if _, err := loadCredentials([]byte(tt.data)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("loadCredentials(%s) = %v, want error containing %q", tt.data, err, tt.want)
+		}
+	}
+
+	_, err := New(WithCredentialsFile(filepath.Join(t.TempDir(), "missing.json"))).Fetch(context.Background(), "s")
+	if err == nil || !strings.Contains(err.Error(), "failed to get project ID") {
+		t.Errorf("Fetch() with a missing key file = %v", err)
+	}
+}
+
+func TestUserCredentials(t *testing.T) {
+	var refreshes atomic.Int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		refreshes.Add(1)
+		if r.FormValue("grant_type") != "refresh_token" || r.FormValue("refresh_token") != "1//refresh" ||
+			r.FormValue("client_id") != "client" || r.FormValue("client_secret") != "shh" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`)) //nolint:errcheck // test mock server
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"user-token","expires_in":3599}`)) //nolint:errcheck // test mock server
+	}))
+	t.Cleanup(tokenServer.Close)
+
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer user-token" || r.Header.Get("X-Goog-User-Project") != "quota-project" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		writePayload(w, "projects/dev-project/secrets/s/versions/1", "value")
+	})
+
+	// gcloud writes the well-known file under $CLOUDSDK_CONFIG, or ~/.config/gcloud.
+	dir := t.TempDir()
+	t.Setenv("CLOUDSDK_CONFIG", dir)
+	t.Setenv(CredentialsEnv, "")
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "dev-project")
+	if New().credsPath != "" {
+		t.Fatal("New() found credentials before the well-known file exists")
+	}
+	writeUser := func(refreshToken string) {
+		data := `{"type":"authorized_user","client_id":"client","client_secret":"shh","refresh_token":"` + refreshToken +
+			`","quota_project_id":"quota-project","token_uri":"` + tokenServer.URL + `"}`
+		if err := os.WriteFile(filepath.Join(dir, "application_default_credentials.json"), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeUser("1//refresh")
+
+	ctx := context.Background()
+	c := New()
+	for range 2 {
+		if v, err := c.Fetch(ctx, "s"); err != nil || v != "value" {
+			t.Fatalf("Fetch() = %q, %v, want value", v, err)
+		}
+	}
+	if n := refreshes.Load(); n != 1 {
+		t.Errorf("token refreshes = %d, want 1 (cached)", n)
+	}
+
+	// Without $GOOGLE_CLOUD_PROJECT, the quota project is the current project.
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+	if p, err := c.projectID(ctx); err != nil || p != "quota-project" {
+		t.Errorf("projectID() = %q, %v, want quota-project", p, err)
+	}
+
+	writeUser("1//revoked")
+	_, err := New().FetchFromProject(ctx, "dev-project", "s")
+	if err == nil || !strings.Contains(err.Error(), "gcloud auth application-default login") {
+		t.Errorf("Fetch() with a revoked refresh token = %v, want a login hint", err)
+	}
+}
+
+func TestNoCredentials(t *testing.T) {
+	t.Setenv(CredentialsEnv, "")
+	t.Setenv("CLOUDSDK_CONFIG", t.TempDir())
+	setupFakes(t, nil)
+	metadataURL = "http://127.0.0.1:1"
+
+	c := New()
+	_, err := c.Fetch(context.Background(), "s")
+	if !errors.Is(err, ErrNoCredentials) || !strings.Contains(err.Error(), CredentialsEnv) {
+		t.Fatalf("Fetch() error = %v, want ErrNoCredentials naming %s", err, CredentialsEnv)
+	}
+	var r *RetryReport
+	if !errors.As(err, &r) || len(r.Attempts) != 1 {
+		t.Errorf("Fetch() error = %v, want a single metadata server attempt", err)
+	}
+
+	if _, err := c.FetchFromProject(context.Background(), "test-project", "s"); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("FetchFromProject() error = %v, want ErrNoCredentials", err)
+	}
+}
+
+func TestWithScopes(t *testing.T) {
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		writePayload(w, "projects/test-project/secrets/s/versions/1", "v")
+	})
+	var scopes string
+	metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scopes = r.URL.Query().Get("scopes")
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "test-token", "expires_in": 3599}) //nolint:errcheck // test mock server
+	}))
+	t.Cleanup(metadataServer.Close)
+	metadataURL = metadataServer.URL
+
+	read := "https://www.googleapis.com/auth/secretmanager.readonly"
+	c := New(WithScopes(read, "openid"))
+	if _, err := c.FetchFromProject(context.Background(), "test-project", "s"); err != nil {
+		t.Fatalf("FetchFromProject() unexpected error = %v", err)
+	}
+	if scopes != read+",openid" {
+		t.Errorf("metadata token scopes = %q, want %q", scopes, read+",openid")
+	}
+
+	path, _ := writeServiceAccountKey(t, "http://127.0.0.1:1/token")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds, err := loadCredentials(data)
+	if err != nil {
+		t.Fatalf("loading key: %v", err)
+	}
+	assertion, err := creds.assertion(time.Now(), c.scope())
+	if err != nil {
+		t.Fatalf("assertion() unexpected error = %v", err)
+	}
+	claims, _ := base64.RawURLEncoding.DecodeString(strings.Split(assertion, ".")[1]) //nolint:errcheck // checked below
+	var got struct{ Scope string }
+	if err := json.Unmarshal(claims, &got); err != nil || got.Scope != read+" openid" {
+		t.Errorf("assertion scope = %q, want %q", got.Scope, read+" openid")
+	}
+}
@@ -0,0 +1,171 @@
+package gsm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// DecodeError is returned by FetchJSON and FetchYAML when a secret was fetched
+// but its value could not be decoded. Err describes the problem without quoting the value.
+type DecodeError struct {
+	Err    error
+	Secret string
+	Format string
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("secret %s: decoding %s: %v", e.Secret, e.Format, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// WithStrictDecoding makes FetchJSON and FetchYAML fail if the value has
+// fields that the destination type lacks, catching typos and schema drift in
+// stored config. Other fetches ignore it.
+func WithStrictDecoding() FetchOption {
+	return func(o *fetchOptions) {
+		o.strict = true
+	}
+}
+
+// FetchJSON fetches the latest value of a secret in the current project using
+// the default client and unmarshals it as JSON into a T. Decoding failures are
+// reported as a *DecodeError; fetch failures are returned unchanged.
+// The project ID is auto-detected from the GCP metadata server.
+func FetchJSON[T any](ctx context.Context, name string, opts ...FetchOption) (T, error) {
+	var v T
+	err := defaultClient.FetchJSON(ctx, name, &v, opts...)
+	return v, err
+}
+
+// FetchJSONFromProject fetches the latest value of a secret in a specific
+// project using the default client and unmarshals it as JSON into a T.
+func FetchJSONFromProject[T any](ctx context.Context, pid, name string, opts ...FetchOption) (T, error) {
+	var v T
+	err := defaultClient.FetchJSONFromProject(ctx, pid, name, &v, opts...)
+	return v, err
+}
+
+// FetchJSON fetches the latest value of a secret in the current project and
+// unmarshals it as JSON into v, which must be a non-nil pointer. Decoding
+// failures are reported as a *DecodeError; fetch failures are returned unchanged.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) FetchJSON(ctx context.Context, name string, v any, opts ...FetchOption) error {
+	value, err := c.Fetch(ctx, name, opts...)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(name, value, v, newFetchOptions(opts).strict)
+}
+
+// FetchJSONFromProject fetches the latest value of a secret in a specific
+// project and unmarshals it as JSON into v, which must be a non-nil pointer.
+func (c *Client) FetchJSONFromProject(ctx context.Context, pid, name string, v any, opts ...FetchOption) error {
+	value, err := c.FetchFromProject(ctx, pid, name, opts...)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(name, value, v, newFetchOptions(opts).strict)
+}
+
+// FetchYAML fetches the latest value of a secret in the current project using
+// the default client and decodes it as YAML into a T, as FetchJSON does JSON.
+// The project ID is auto-detected from the GCP metadata server.
+func FetchYAML[T any](ctx context.Context, name string, opts ...FetchOption) (T, error) {
+	var v T
+	err := defaultClient.FetchYAML(ctx, name, &v, opts...)
+	return v, err
+}
+
+// FetchYAMLFromProject fetches the latest value of a secret in a specific
+// project using the default client and decodes it as YAML into a T.
+func FetchYAMLFromProject[T any](ctx context.Context, pid, name string, opts ...FetchOption) (T, error) {
+	var v T
+	err := defaultClient.FetchYAMLFromProject(ctx, pid, name, &v, opts...)
+	return v, err
+}
+
+// FetchYAML fetches the latest value of a secret in the current project and
+// decodes it as YAML into v, which must be a non-nil pointer. The document is
+// decoded as its JSON equivalent would be, so v's fields use json tags. The
+// supported YAML is the subset described for spec files: no anchors, aliases,
+// tags, or multiple documents. Decoding failures are reported as a *DecodeError.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) FetchYAML(ctx context.Context, name string, v any, opts ...FetchOption) error {
+	value, err := c.Fetch(ctx, name, opts...)
+	if err != nil {
+		return err
+	}
+	return decodeYAML(name, value, v, newFetchOptions(opts).strict)
+}
+
+// FetchYAMLFromProject fetches the latest value of a secret in a specific
+// project and decodes it as YAML into v, which must be a non-nil pointer.
+func (c *Client) FetchYAMLFromProject(ctx context.Context, pid, name string, v any, opts ...FetchOption) error {
+	value, err := c.FetchFromProject(ctx, pid, name, opts...)
+	if err != nil {
+		return err
+	}
+	return decodeYAML(name, value, v, newFetchOptions(opts).strict)
+}
+
+// yamlLineRegex extracts the line number from a YAML syntax error.
+var yamlLineRegex = regexp.MustCompile(`^yaml: line ([0-9]+):`)
+
+// decodeJSON unmarshals a secret's value into v.
+func decodeJSON(name, value string, v any, strict bool) error {
+	if err := unmarshalJSON([]byte(value), v, strict); err != nil {
+		// Syntax errors quote the offending character; report only its offset.
+		var se *json.SyntaxError
+		if errors.As(err, &se) {
+			err = fmt.Errorf("invalid JSON at offset %d", se.Offset)
+		}
+		return &DecodeError{Secret: name, Format: "JSON", Err: err}
+	}
+	return nil
+}
+
+// decodeYAML decodes a secret's YAML value into v by way of JSON.
+func decodeYAML(name, value string, v any, strict bool) error {
+	doc, err := parseYAML([]byte(value))
+	if err != nil {
+		// Some syntax errors quote the document; report only the line.
+		msg := "invalid YAML"
+		if m := yamlLineRegex.FindStringSubmatch(err.Error()); m != nil {
+			msg += " at line " + m[1]
+		}
+		return &DecodeError{Secret: name, Format: "YAML", Err: errors.New(msg)}
+	}
+	data, err := json.Marshal(doc)
+	if err == nil {
+		err = unmarshalJSON(data, v, strict)
+	}
+	if err != nil {
+		return &DecodeError{Secret: name, Format: "YAML", Err: err}
+	}
+	return nil
+}
+
+// unmarshalJSON is json.Unmarshal, optionally rejecting unknown fields.
+func unmarshalJSON(data []byte, v any, strict bool) error {
+	if !strict {
+		return json.Unmarshal(data, v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	// Decoder stops after one value; reject trailing data as Unmarshal does.
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("unexpected data after top-level value")
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFetchJSON(t *testing.T) {
+	values := map[string]string{
+		"bundle": `{"host":"db","port":5432}`,
+		"broken": `{"host":"s3cr3t`,
+		"typed":  `{"port":"not-a-number"}`,
+	}
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		name := strings.Split(r.URL.Path, "/")[4]
+		v, ok := values[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writePayload(w, "projects/test-project/secrets/"+name+"/versions/1", v)
+	})
+	ctx := context.Background()
+
+	type bundle struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	}
+	got, err := FetchJSON[bundle](ctx, "bundle")
+	if err != nil || got != (bundle{Host: "db", Port: 5432}) {
+		t.Errorf("FetchJSON() = %+v, %v", got, err)
+	}
+
+	for _, name := range []string{"broken", "typed"} {
+		_, err := FetchJSONFromProject[bundle](ctx, "test-project", name)
+		var de *DecodeError
+		if !errors.As(err, &de) || de.Secret != name || de.Format != "JSON" {
+			t.Errorf("FetchJSON(%s) error = %v, want *DecodeError", name, err)
+		}
+		if err != nil && strings.Contains(err.Error(), "s3cr3t") {
+			t.Errorf("FetchJSON(%s) error %q quotes the value", name, err)
+		}
+	}
+
+	_, err = FetchJSON[bundle](ctx, "missing")
+	var de *DecodeError
+	if !errors.Is(err, ErrNotFound) || errors.As(err, &de) {
+		t.Errorf("FetchJSON(missing) error = %v, want ErrNotFound and no *DecodeError", err)
+	}
+}
+
+func TestFetchYAML(t *testing.T) {
+	values := map[string]string{
+		"config":  "host: db\nport: 5432\ntags: [a, b]\n",
+		"extra":   "host: db\ntimeout: 5s\n",
+		"broken":  "host: db\n  s3cr3t: [\n",
+		"typed":   "port: s3cr3t\n",
+		"trailer": `{"host":"db"} {"host":"s3cr3t"}`,
+	}
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		name := strings.Split(r.URL.Path, "/")[4]
+		writePayload(w, "projects/test-project/secrets/"+name+"/versions/1", values[name])
+	})
+	ctx := context.Background()
+
+	type config struct {
+		Host string   `json:"host"`
+		Port int      `json:"port"`
+		Tags []string `json:"tags"`
+	}
+	got, err := FetchYAML[config](ctx, "config")
+	if err != nil || got.Host != "db" || got.Port != 5432 || strings.Join(got.Tags, ",") != "a,b" {
+		t.Errorf("FetchYAML(config) = %+v, %v", got, err)
+	}
+
+	// Unknown fields are ignored unless decoding is strict.
+	if got, err := FetchYAML[config](ctx, "extra"); err != nil || got.Host != "db" {
+		t.Errorf("FetchYAML(extra) = %+v, %v", got, err)
+	}
+	_, err = FetchYAMLFromProject[config](ctx, "test-project", "extra", WithStrictDecoding())
+	var de *DecodeError
+	if !errors.As(err, &de) || de.Format != "YAML" || !strings.Contains(err.Error(), `"timeout"`) {
+		t.Errorf("FetchYAML(extra, strict) error = %v, want unknown field", err)
+	}
+
+	for _, name := range []string{"broken", "typed"} {
+		_, err := FetchYAML[config](ctx, name)
+		if !errors.As(err, &de) || de.Secret != name || de.Format != "YAML" {
+			t.Errorf("FetchYAML(%s) error = %v, want *DecodeError", name, err)
+		}
+		if err != nil && strings.Contains(err.Error(), "s3cr3t") {
+			t.Errorf("FetchYAML(%s) error %q quotes the value", name, err)
+		}
+	}
+
+	// Strict decoding still rejects trailing values.
+	_, err = FetchJSON[config](ctx, "trailer", WithStrictDecoding())
+	if !errors.As(err, &de) || strings.Contains(err.Error(), "s3cr3t") {
+		t.Errorf("FetchJSON(trailer, strict) error = %v, want *DecodeError", err)
+	}
+}
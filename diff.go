@@ -0,0 +1,245 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DiffStatus describes how a secret differs between two projects.
+type DiffStatus string
+
+// Diff statuses.
+const (
+	DiffMissing DiffStatus = "missing" // in the source only, or without a value in the destination
+	DiffChanged DiffStatus = "changed" // latest values differ
+	DiffExtra   DiffStatus = "extra"   // in the destination only
+)
+
+// SecretDiff is one secret that differs between two projects. Checksums are the
+// hex SHA-256 digests of each project's latest value, or empty if it has none.
+type SecretDiff struct {
+	Name                string
+	Status              DiffStatus
+	SourceChecksum      string
+	DestinationChecksum string
+}
+
+// ProjectDiff is the difference between the secrets of two projects.
+type ProjectDiff struct {
+	Source      string
+	Destination string
+	// Secrets lists the secrets that differ, sorted by name.
+	Secrets []SecretDiff
+	// Unchanged is the number of secrets whose latest values match.
+	Unchanged int
+}
+
+// DiffProjects compares the secrets of two projects using the default client.
+// See Client.DiffProjects.
+func DiffProjects(ctx context.Context, src, dst, filter string) (*ProjectDiff, error) {
+	return defaultClient.DiffProjects(ctx, src, dst, filter)
+}
+
+// ApplyDiff copies missing and changed secrets using the default client.
+// See Client.ApplyDiff.
+func ApplyDiff(ctx context.Context, d *ProjectDiff, deleteExtra bool) (*SyncReport, error) {
+	return defaultClient.ApplyDiff(ctx, d, deleteExtra)
+}
+
+// DiffValues compares local values with a project's secrets using the default
+// client. See Client.DiffValues.
+func DiffValues(ctx context.Context, values map[string]string, dst, filter string) (*ProjectDiff, error) {
+	return defaultClient.DiffValues(ctx, values, dst, filter)
+}
+
+// ApplyValues applies a diff from DiffValues using the default client.
+// See Client.ApplyValues.
+func ApplyValues(ctx context.Context, d *ProjectDiff, values map[string]string, deleteExtra bool) (*SyncReport, error) {
+	return defaultClient.ApplyValues(ctx, d, values, deleteExtra)
+}
+
+// DiffProjects compares the secrets of two projects by name and by SHA-256 digest
+// of their latest values, reporting secrets missing from the destination, secrets
+// whose values differ, and destination secrets the source doesn't have. filter
+// is a Secret Manager list filter applied to both projects; empty compares every
+// secret. Source secrets without an accessible latest version are ignored.
+// Values are read to compute checksums but never returned.
+func (c *Client) DiffProjects(ctx context.Context, src, dst, filter string) (*ProjectDiff, error) {
+	for _, pid := range []string{src, dst} {
+		if !projectIDRegex.MatchString(pid) {
+			return nil, fmt.Errorf("invalid project ID format: %q", pid)
+		}
+	}
+	if src == dst {
+		return nil, fmt.Errorf("destination %q is the source project", dst)
+	}
+
+	tok, err := c.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	srcSums, err := c.latestChecksums(ctx, tok, src, filter)
+	if err != nil {
+		return nil, err
+	}
+	dstSums, err := c.latestChecksums(ctx, tok, dst, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffChecksums(src, dst, srcSums, dstSums), nil
+}
+
+// DiffValues compares values, keyed by secret name, with the secrets of project
+// dst, like DiffProjects with a local source such as a file parsed by
+// ParseImport. filter applies to dst only. The diff's Source is empty.
+func (c *Client) DiffValues(ctx context.Context, values map[string]string, dst, filter string) (*ProjectDiff, error) {
+	if !projectIDRegex.MatchString(dst) {
+		return nil, fmt.Errorf("invalid project ID format: %q", dst)
+	}
+	srcSums := make(map[string]string, len(values))
+	for name, value := range values {
+		if !secretNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("invalid secret name format: %q", name)
+		}
+		srcSums[name] = sha256Hex(value)
+	}
+
+	tok, err := c.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dstSums, err := c.latestChecksums(ctx, tok, dst, filter)
+	if err != nil {
+		return nil, err
+	}
+	return diffChecksums("", dst, srcSums, dstSums), nil
+}
+
+// ApplyValues brings the destination of d, from DiffValues, in line with values:
+// missing and changed secrets get a new version if their value still differs,
+// and extra secrets are deleted if deleteExtra is set.
+func (c *Client) ApplyValues(ctx context.Context, d *ProjectDiff, values map[string]string, deleteExtra bool) (*SyncReport, error) {
+	if !projectIDRegex.MatchString(d.Destination) {
+		return nil, fmt.Errorf("invalid project ID format: %q", d.Destination)
+	}
+	tok, err := c.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &SyncReport{Time: time.Now()}
+	for _, s := range d.Secrets {
+		switch {
+		case s.Status != DiffExtra:
+			value, ok := values[s.Name]
+			if !ok {
+				r.Results = append(r.Results, SyncResult{Destination: d.Destination, Secret: s.Name, Action: SyncFailed, Err: errors.New("no value to apply")})
+				continue
+			}
+			r.Results = append(r.Results, c.syncSecret(ctx, tok, d.Destination, s.Name, value, false))
+		case deleteExtra:
+			r.Results = append(r.Results, c.syncDelete(ctx, tok, d.Destination, s.Name, false))
+		}
+	}
+	return r, nil
+}
+
+// diffChecksums compares the latest-value digests of two sets of secrets. An
+// empty digest means the secret has no accessible latest version.
+func diffChecksums(src, dst string, srcSums, dstSums map[string]string) *ProjectDiff {
+	d := &ProjectDiff{Source: src, Destination: dst}
+	for name, sum := range srcSums {
+		if sum == "" {
+			continue
+		}
+		switch other := dstSums[name]; other {
+		case "":
+			d.Secrets = append(d.Secrets, SecretDiff{Name: name, Status: DiffMissing, SourceChecksum: sum})
+		case sum:
+			d.Unchanged++
+		default:
+			d.Secrets = append(d.Secrets, SecretDiff{Name: name, Status: DiffChanged, SourceChecksum: sum, DestinationChecksum: other})
+		}
+	}
+	for name, sum := range dstSums {
+		if _, ok := srcSums[name]; ok {
+			continue
+		}
+		d.Secrets = append(d.Secrets, SecretDiff{Name: name, Status: DiffExtra, DestinationChecksum: sum})
+	}
+	slices.SortFunc(d.Secrets, func(a, b SecretDiff) int { return strings.Compare(a.Name, b.Name) })
+	return d
+}
+
+// ApplyDiff brings the destination of d in line with its source: missing and
+// changed secrets are copied with SyncOnce, which re-reads the source, and extra
+// secrets are deleted if deleteExtra is set.
+func (c *Client) ApplyDiff(ctx context.Context, d *ProjectDiff, deleteExtra bool) (*SyncReport, error) {
+	cfg := SyncConfig{Source: d.Source, Destinations: []string{d.Destination}, Delete: deleteExtra}
+	for _, s := range d.Secrets {
+		if s.Status != DiffExtra || deleteExtra {
+			cfg.Secrets = append(cfg.Secrets, s.Name)
+		}
+	}
+	if len(cfg.Secrets) == 0 {
+		if err := cfg.validate(); err != nil {
+			return nil, err
+		}
+		return &SyncReport{Time: time.Now()}, nil
+	}
+	return c.SyncOnce(ctx, cfg)
+}
+
+// latestChecksums lists the secrets in a project and returns the hex SHA-256 of
+// each one's latest value, or "" for secrets without an accessible latest version.
+func (c *Client) latestChecksums(ctx context.Context, tok, pid, filter string) (map[string]string, error) {
+	secrets, err := c.listSecrets(ctx, tok, pid, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs []error
+		sums = make(map[string]string, len(secrets))
+		sem  = make(chan struct{}, maxConcurrentFetches)
+	)
+	for _, s := range secrets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			var sum string
+			value, _, err := c.accessVersion(ctx, tok, pid, s.Name, "latest")
+			switch {
+			case noVersion(err):
+			case err != nil:
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s/%s: %w", pid, s.Name, err))
+				mu.Unlock()
+				return
+			default:
+				sum = sha256Hex(value)
+			}
+			mu.Lock()
+			sums[s.Name] = sum
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return sums, nil
+}
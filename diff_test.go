@@ -0,0 +1,109 @@
+package gsm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestDiffProjects(t *testing.T) {
+	fake := &fakeProjects{values: map[string]string{
+		"source-project/same":    "1",
+		"source-project/changed": "new",
+		"source-project/missing": "m",
+		"dest-project/same":      "1",
+		"dest-project/changed":   "old",
+		"dest-project/extra":     "x",
+	}}
+	setupFakes(t, fake.ServeHTTP)
+	ctx := context.Background()
+
+	d, err := DiffProjects(ctx, "source-project", "dest-project", "")
+	if err != nil {
+		t.Fatalf("DiffProjects() unexpected error = %v", err)
+	}
+	sum := sha256Hex
+	want := []SecretDiff{
+		{Name: "changed", Status: DiffChanged, SourceChecksum: sum("new"), DestinationChecksum: sum("old")},
+		{Name: "extra", Status: DiffExtra, DestinationChecksum: sum("x")},
+		{Name: "missing", Status: DiffMissing, SourceChecksum: sum("m")},
+	}
+	if fmt.Sprint(d.Secrets) != fmt.Sprint(want) || d.Unchanged != 1 {
+		t.Errorf("DiffProjects() = %v, %d unchanged, want %v, 1 unchanged", d.Secrets, d.Unchanged, want)
+	}
+
+	r, err := ApplyDiff(ctx, d, false)
+	if err != nil || r.Err() != nil {
+		t.Fatalf("ApplyDiff() unexpected error = %v, %v", err, r.Err())
+	}
+	if fake.values["dest-project/changed"] != "new" || fake.values["dest-project/missing"] != "m" || fake.values["dest-project/extra"] != "x" {
+		t.Errorf("destination after ApplyDiff = %v", fake.values)
+	}
+
+	d, err = DiffProjects(ctx, "source-project", "dest-project", "")
+	if err != nil || len(d.Secrets) != 1 || d.Secrets[0].Status != DiffExtra {
+		t.Fatalf("DiffProjects() after apply = %v, %v, want only extra", d, err)
+	}
+	if _, err := ApplyDiff(ctx, d, true); err != nil {
+		t.Fatalf("ApplyDiff(deleteExtra) unexpected error = %v", err)
+	}
+	if _, ok := fake.values["dest-project/extra"]; ok {
+		t.Error("ApplyDiff(deleteExtra) kept the extra secret")
+	}
+
+	if _, err := DiffProjects(ctx, "source-project", "source-project", ""); err == nil {
+		t.Error("DiffProjects() with the same project succeeded, want error")
+	}
+}
+
+func TestDiffValues(t *testing.T) {
+	fake := &fakeProjects{values: map[string]string{
+		"dest-project/same":    "1",
+		"dest-project/changed": "old",
+		"dest-project/extra":   "x",
+	}}
+	setupFakes(t, fake.ServeHTTP)
+	ctx := context.Background()
+	values := map[string]string{"same": "1", "changed": "new", "missing": "m"}
+
+	d, err := DiffValues(ctx, values, "dest-project", "")
+	if err != nil {
+		t.Fatalf("DiffValues() unexpected error = %v", err)
+	}
+	var got []string
+	for _, s := range d.Secrets {
+		got = append(got, s.Name+":"+string(s.Status))
+	}
+	if fmt.Sprint(got) != "[changed:changed extra:extra missing:missing]" || d.Unchanged != 1 || d.Source != "" {
+		t.Errorf("DiffValues() = %v, %d unchanged, source %q", got, d.Unchanged, d.Source)
+	}
+
+	r, err := ApplyValues(ctx, d, values, true)
+	if err != nil || r.Err() != nil {
+		t.Fatalf("ApplyValues() unexpected error = %v, %v", err, r.Err())
+	}
+	if fake.values["dest-project/changed"] != "new" || fake.values["dest-project/missing"] != "m" {
+		t.Errorf("destination after ApplyValues = %v", fake.values)
+	}
+	if _, ok := fake.values["dest-project/extra"]; ok {
+		t.Error("ApplyValues(deleteExtra) kept the extra secret")
+	}
+
+	if _, err := DiffValues(ctx, map[string]string{"a.b": "v"}, "dest-project", ""); err == nil {
+		t.Error("DiffValues() with an invalid secret name succeeded, want error")
+	}
+}
+
+func TestDiffValuesChecksumCollision(t *testing.T) {
+	// These two values share a CRC32C, so only a stronger digest tells them apart.
+	fake := &fakeProjects{values: map[string]string{"dest-project/key": "value-1371838"}}
+	setupFakes(t, fake.ServeHTTP)
+
+	d, err := DiffValues(context.Background(), map[string]string{"key": "value-2000402"}, "dest-project", "")
+	if err != nil {
+		t.Fatalf("DiffValues() unexpected error = %v", err)
+	}
+	if len(d.Secrets) != 1 || d.Secrets[0].Status != DiffChanged {
+		t.Errorf("DiffValues() = %v, %d unchanged, want key changed", d.Secrets, d.Unchanged)
+	}
+}
@@ -0,0 +1,122 @@
+package gsm
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// diskCache keeps fetched secret values in dir, one AES-256-GCM encrypted file
+// per secret, so they survive process restarts.
+type diskCache struct {
+	aead cipher.AEAD
+	log  *slog.Logger
+	dir  string
+	ttl  time.Duration
+}
+
+type diskEntry struct {
+	Expires time.Time `json:"expires"`
+	Value   string    `json:"value"`
+	Version string    `json:"version"`
+}
+
+// WithDiskCache additionally caches fetched secret values for ttl in dir, so
+// instances that scale to zero or restart warm-start without fetching them
+// again, and, with WithStaleIfError, ride out outages that begin before their
+// first fetch. Each secret is a separate file, readable only by its owner
+// (mode 0600, in a 0700 directory) and named by a hash, not the secret name.
+//
+// Values are encrypted with AES-256-GCM under a key derived from key by
+// SHA-256; key is not written to disk. Use high-entropy material kept apart
+// from dir, such as a data key unwrapped with Cloud KMS at startup or a
+// mounted secret. Entries that fail to decrypt, e.g. after the key changes,
+// are ignored.
+func WithDiskCache(dir string, key []byte, ttl time.Duration) Option {
+	return func(c *Client) {
+		sum := sha256.Sum256(key)
+		aead, _ := newGCM(sum[:]) //nolint:errcheck // a SHA-256 sum is a valid AES-256 key
+		c.disk = &diskCache{aead: aead, dir: dir, ttl: ttl}
+	}
+}
+
+// path returns the file holding key's entry.
+func (dc *diskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(dc.dir, hex.EncodeToString(sum[:]))
+}
+
+// read returns key's entry, expired or not. Missing, unreadable, and
+// undecryptable files are treated as absent.
+func (dc *diskCache) read(key string) (diskEntry, bool) {
+	data, err := os.ReadFile(dc.path(key))
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			dc.log.Warn("failed to read disk cache", "error", err)
+		}
+		return diskEntry{}, false
+	}
+	n := dc.aead.NonceSize()
+	if len(data) < n {
+		return diskEntry{}, false
+	}
+	// The cache key is authenticated, so files can't be swapped between secrets.
+	plain, err := dc.aead.Open(nil, data[:n], data[n:], []byte(key))
+	if err != nil {
+		dc.log.Warn("ignoring disk cache entry that failed to decrypt", "error", err)
+		return diskEntry{}, false
+	}
+	var e diskEntry
+	if err := json.Unmarshal(plain, &e); err != nil {
+		return diskEntry{}, false
+	}
+	return e, true
+}
+
+// get returns key's entry if it has not expired.
+func (dc *diskCache) get(key string) (diskEntry, bool) {
+	e, ok := dc.read(key)
+	if !ok || time.Now().After(e.Expires) {
+		return diskEntry{}, false
+	}
+	return e, true
+}
+
+// stale returns key's entry, expired or not, if it expired less than maxAge ago.
+func (dc *diskCache) stale(key string, maxAge time.Duration) (diskEntry, bool) {
+	e, ok := dc.read(key)
+	if !ok || time.Since(e.Expires) > maxAge {
+		return diskEntry{}, false
+	}
+	return e, true
+}
+
+func (dc *diskCache) put(key, value, version string) error {
+	plain, err := json.Marshal(diskEntry{Expires: time.Now().Add(dc.ttl), Value: value, Version: version})
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, dc.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dc.dir, 0o700); err != nil {
+		return fmt.Errorf("creating disk cache: %w", err)
+	}
+	return writeFileAtomic(dc.path(key), dc.aead.Seal(nonce, nonce, plain, []byte(key)))
+}
+
+func (dc *diskCache) invalidate(key string) {
+	if err := os.Remove(dc.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		dc.log.Warn("failed to remove disk cache entry", "error", err)
+	}
+}
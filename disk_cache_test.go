@@ -0,0 +1,72 @@
+package gsm
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiskCache(t *testing.T) {
+	accesses := 0
+	status := http.StatusOK
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		accesses++
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		writePayload(w, "projects/test-project/secrets/s/versions/2", "hunter2")
+	})
+
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "cache")
+	key := []byte("0123456789abcdef0123456789abcdef")
+	if _, err := New(WithDiskCache(dir, key, time.Hour)).FetchFromProject(ctx, "test-project", "s"); err != nil {
+		t.Fatalf("FetchFromProject() unexpected error = %v", err)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("disk cache files = %v, %v, want one", files, err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hunter2") || len(files[0].Name()) != 64 {
+		t.Errorf("disk cache file %s holds plaintext or isn't named by a hash", files[0].Name())
+	}
+	if info, err := files[0].Info(); err != nil || (runtime.GOOS != "windows" && info.Mode().Perm() != 0o600) {
+		t.Errorf("disk cache file mode = %v, %v, want 0600", info.Mode(), err)
+	}
+
+	// A new client, as after a restart, warm-starts from disk.
+	status = http.StatusServiceUnavailable
+	got, err := New(WithDiskCache(dir, key, time.Hour)).FetchFromProject(ctx, "test-project", "s")
+	if err != nil || got != "hunter2" || accesses != 1 {
+		t.Errorf("FetchFromProject() after restart = %q, %v with %d accesses, want cached hunter2", got, err, accesses)
+	}
+
+	// Entries written under another key are ignored.
+	other := New(WithDiskCache(dir, []byte("another key"), time.Hour), WithAPIRetry(RetryPolicy{Attempts: 1}))
+	if _, err := other.FetchFromProject(ctx, "test-project", "s"); err == nil {
+		t.Error("FetchFromProject() with the wrong key succeeded, want error")
+	}
+
+	// Expired entries still serve as stale values during an outage.
+	stale := New(WithDiskCache(dir, key, 0), WithStaleIfError(time.Hour), WithAPIRetry(RetryPolicy{Attempts: 1}))
+	status = http.StatusOK
+	if _, err := stale.FetchFromProject(ctx, "test-project", "s"); err != nil {
+		t.Fatalf("FetchFromProject() unexpected error = %v", err)
+	}
+	status = http.StatusServiceUnavailable
+	restarted := New(WithDiskCache(dir, key, 0), WithStaleIfError(time.Hour), WithAPIRetry(RetryPolicy{Attempts: 1}))
+	if got, err := restarted.FetchFromProject(ctx, "test-project", "s"); err != nil || got != "hunter2" {
+		t.Errorf("FetchFromProject() during outage after restart = %q, %v, want stale hunter2", got, err)
+	}
+}
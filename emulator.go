@@ -0,0 +1,34 @@
+package gsm
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// EmulatorHostEnv names the environment variable that points clients at a local
+// Secret Manager emulator or fake, e.g. "localhost:8085". When it is set, New
+// sends API requests to http://HOST/v1 without fetching access tokens, and the
+// current project is read from $GOOGLE_CLOUD_PROJECT instead of the metadata
+// server, so tests and local development don't need GCP at all. An explicit
+// WithEndpoint takes precedence over the emulator address.
+const EmulatorHostEnv = "GSM_EMULATOR_HOST"
+
+// emulatorToken is sent as the bearer token to emulators, which don't check it.
+const emulatorToken = "emulator"
+
+// emulatorEndpoint returns the API base URL for an emulator host.
+func emulatorEndpoint(host string) string {
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+	return strings.TrimSuffix(host, "/") + "/v1"
+}
+
+// emulatorProjectID returns the current project when running against an emulator.
+func emulatorProjectID() (string, error) {
+	if p := os.Getenv("GOOGLE_CLOUD_PROJECT"); p != "" {
+		return p, nil
+	}
+	return "", errors.New("failed to get project ID: " + EmulatorHostEnv + " is set but GOOGLE_CLOUD_PROJECT is not")
+}
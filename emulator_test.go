@@ -0,0 +1,45 @@
+package gsm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEmulatorHost(t *testing.T) {
+	var gotPath, gotAuth string
+	emulator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth = r.URL.Path, r.Header.Get("Authorization")
+		writePayload(w, "projects/local-project/secrets/my-secret/versions/1", "secret-value")
+	}))
+	t.Cleanup(emulator.Close)
+
+	// Any use of the metadata server would fail.
+	oldMetadataURL := metadataURL
+	t.Cleanup(func() { metadataURL = oldMetadataURL })
+	metadataURL = "http://127.0.0.1:1"
+
+	t.Setenv(EmulatorHostEnv, strings.TrimPrefix(emulator.URL, "http://"))
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "local-project")
+
+	got, err := New().Fetch(context.Background(), "my-secret")
+	if err != nil {
+		t.Fatalf("Fetch() unexpected error = %v", err)
+	}
+	if got != "secret-value" {
+		t.Errorf("Fetch() = %q, want %q", got, "secret-value")
+	}
+	if want := "/v1/projects/local-project/secrets/my-secret/versions/latest:access"; gotPath != want {
+		t.Errorf("path = %q, want %q", gotPath, want)
+	}
+	if gotAuth != "Bearer "+emulatorToken {
+		t.Errorf("Authorization = %q, want emulator token", gotAuth)
+	}
+
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+	if _, err := New().Fetch(context.Background(), "my-secret"); err == nil || !strings.Contains(err.Error(), "GOOGLE_CLOUD_PROJECT") {
+		t.Errorf("Fetch() error = %v, want error naming GOOGLE_CLOUD_PROJECT", err)
+	}
+}
@@ -0,0 +1,219 @@
+package gsm
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// kmsURL is the Cloud KMS API endpoint used by WithKMSEnvelope.
+var kmsURL = "https://cloudkms.googleapis.com/v1"
+
+// ErrNotEncrypted is returned (wrapped) when a client that encrypts values
+// fetches a secret version that isn't in its encrypted format.
+var ErrNotEncrypted = errors.New("secret value is not encrypted")
+
+// payloadCipher encrypts secret values before they are stored and decrypts
+// them after they are fetched. name is the secret the value belongs to.
+type payloadCipher interface {
+	seal(ctx context.Context, c *Client, tok, name string, plaintext []byte) ([]byte, error)
+	open(ctx context.Context, c *Client, tok, name string, ciphertext []byte) ([]byte, error)
+}
+
+// WithKMSEnvelope envelope-encrypts values with the Cloud KMS key keyName
+// ("projects/P/locations/L/keyRings/R/cryptoKeys/K") before storing them, and
+// decrypts them after fetching. Each version gets a fresh AES-256-GCM data key,
+// which KMS wraps; Secret Manager holds only the ciphertext and wrapped key, so
+// reading a value also requires permission to decrypt with the key
+// (roles/cloudkms.cryptoKeyDecrypter), and writing one to encrypt with it.
+//
+// Versions are decrypted only with keyName or one of previousKeys, which keep
+// versions sealed before a switch to a new key readable. An envelope naming
+// any other key fails, so whoever can add versions cannot substitute a key
+// they control and forge values that decrypt cleanly.
+//
+// Ciphertext is bound to the secret's name: copied to a secret with another
+// name, it fails to decrypt. Versions that aren't envelopes fail with
+// ErrNotEncrypted rather than being returned as is.
+func WithKMSEnvelope(keyName string, previousKeys ...string) Option {
+	return func(c *Client) {
+		c.payloadCipher = kmsEnvelope{key: keyName, previous: slices.Clone(previousKeys)}
+	}
+}
+
+type kmsEnvelope struct {
+	key      string
+	previous []string
+}
+
+// kmsEnvelopeFormat identifies the envelope format stored in secret versions.
+const kmsEnvelopeFormat = "gsm-kms-v1"
+
+// envelope is an encrypted secret value as stored in a secret version.
+type envelope struct {
+	Format     string `json:"format"`
+	KMSKey     string `json:"kmsKey,omitempty"`
+	WrappedKey []byte `json:"wrappedKey,omitempty"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func (k kmsEnvelope) seal(ctx context.Context, c *Client, tok, name string, plaintext []byte) ([]byte, error) {
+	dek := make([]byte, 32)
+	defer clear(dek)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(dek)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	var wrapped struct {
+		Ciphertext []byte `json:"ciphertext"`
+	}
+	body, err := json.Marshal(map[string][]byte{"plaintext": dek})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.call(ctx, tok, "encrypt with KMS", http.MethodPost, kmsURL+"/"+k.key+":encrypt", body, &wrapped); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(envelope{
+		Format:     kmsEnvelopeFormat,
+		KMSKey:     k.key,
+		WrappedKey: wrapped.Ciphertext,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, []byte(name)),
+	})
+}
+
+func (k kmsEnvelope) open(ctx context.Context, c *Client, tok, name string, ciphertext []byte) ([]byte, error) {
+	var env envelope
+	if json.Unmarshal(ciphertext, &env) != nil || env.Format != kmsEnvelopeFormat {
+		return nil, fmt.Errorf("secret %s: %w with KMS", name, ErrNotEncrypted)
+	}
+	// The envelope's key name is as untrusted as the rest of the version.
+	if env.KMSKey != k.key && !slices.Contains(k.previous, env.KMSKey) {
+		return nil, fmt.Errorf("secret %s: envelope names KMS key %q, which is not configured", name, env.KMSKey)
+	}
+
+	var unwrapped struct {
+		Plaintext []byte `json:"plaintext"`
+	}
+	body, err := json.Marshal(map[string][]byte{"ciphertext": env.WrappedKey})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.call(ctx, tok, "decrypt with KMS", http.MethodPost, kmsURL+"/"+env.KMSKey+":decrypt", body, &unwrapped); err != nil {
+		return nil, err
+	}
+	defer clear(unwrapped.Plaintext)
+
+	aead, err := newGCM(unwrapped.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("secret %s: malformed envelope", name)
+	}
+	plain, err := aead.Open(nil, env.Nonce, env.Ciphertext, []byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("secret %s: failed to decrypt envelope: %w", name, err)
+	}
+	return plain, nil
+}
+
+// WithLocalEncryption encrypts values with AES-256-GCM under a key derived from
+// key by SHA-256 before storing them, and decrypts them after fetching, so
+// plaintext never reaches Google even if project IAM is misconfigured. Use
+// high-entropy key material and keep it outside the project, since losing it
+// loses every value stored with it. Like WithKMSEnvelope, ciphertext is bound
+// to the secret's name, and versions that aren't encrypted fail with
+// ErrNotEncrypted.
+func WithLocalEncryption(key []byte) Option {
+	return func(c *Client) {
+		sum := sha256.Sum256(key)
+		aead, _ := newGCM(sum[:]) //nolint:errcheck // a SHA-256 sum is a valid AES-256 key
+		c.payloadCipher = localCipher{aead: aead}
+	}
+}
+
+type localCipher struct {
+	aead cipher.AEAD
+}
+
+// localFormat identifies locally encrypted values stored in secret versions.
+const localFormat = "gsm-aes-v1"
+
+func (l localCipher) seal(_ context.Context, _ *Client, _, name string, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, l.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return json.Marshal(envelope{
+		Format:     localFormat,
+		Nonce:      nonce,
+		Ciphertext: l.aead.Seal(nil, nonce, plaintext, []byte(name)),
+	})
+}
+
+func (l localCipher) open(_ context.Context, _ *Client, _, name string, ciphertext []byte) ([]byte, error) {
+	var env envelope
+	if json.Unmarshal(ciphertext, &env) != nil || env.Format != localFormat {
+		return nil, fmt.Errorf("secret %s: %w locally", name, ErrNotEncrypted)
+	}
+	if len(env.Nonce) != l.aead.NonceSize() {
+		return nil, fmt.Errorf("secret %s: malformed envelope", name)
+	}
+	plain, err := l.aead.Open(nil, env.Nonce, env.Ciphertext, []byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("secret %s: wrong key or corrupted value: %w", name, err)
+	}
+	return plain, nil
+}
+
+// newGCM returns AES-GCM with key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealValue encrypts value for storing in secret name, if the client encrypts values.
+func (c *Client) sealValue(ctx context.Context, tok, name, value string) (string, error) {
+	if c.payloadCipher == nil {
+		return value, nil
+	}
+	sealed, err := c.payloadCipher.seal(ctx, c, tok, name, []byte(value))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+	return string(sealed), nil
+}
+
+// openValue decrypts a value fetched from secret name, if the client encrypts values.
+func (c *Client) openValue(ctx context.Context, tok, name string, data []byte) (string, error) {
+	if c.payloadCipher == nil {
+		return string(data), nil
+	}
+	plain, err := c.payloadCipher.open(ctx, c, tok, name, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return string(plain), nil
+}
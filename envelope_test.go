@@ -0,0 +1,177 @@
+package gsm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeVersions serves a Secret Manager API that keeps the latest stored payload.
+type fakeVersions struct {
+	mu     sync.Mutex
+	stored []byte
+}
+
+func (fv *fakeVersions) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+	switch {
+	case strings.HasSuffix(r.URL.Path, ":addVersion"):
+		var body struct {
+			Payload struct {
+				Data []byte `json:"data"`
+			} `json:"payload"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body) //nolint:errcheck // test mock server
+		fv.stored = body.Payload.Data
+		_, _ = w.Write([]byte(`{"name":"projects/test-project/secrets/s/versions/1"}`)) //nolint:errcheck // test mock server
+	case strings.HasSuffix(r.URL.Path, ":access"):
+		writePayload(w, "projects/test-project/secrets/s/versions/1", string(fv.stored))
+	default:
+		_, _ = w.Write([]byte(`{}`)) //nolint:errcheck // test mock server
+	}
+}
+
+// setupFakeKMS serves a Cloud KMS API that "wraps" keys by prefixing them with
+// the key name, recording the keys used.
+func setupFakeKMS(t *testing.T) *[]string {
+	t.Helper()
+	var mu sync.Mutex
+	var used []string
+	kms := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, op, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), ":")
+		mu.Lock()
+		used = append(used, op+" "+key)
+		mu.Unlock()
+		var body map[string][]byte
+		_ = json.NewDecoder(r.Body).Decode(&body) //nolint:errcheck // test mock server
+		switch op {
+		case "encrypt":
+			_ = json.NewEncoder(w).Encode(map[string][]byte{"ciphertext": append([]byte(key+"|"), body["plaintext"]...)}) //nolint:errcheck // test mock server
+		case "decrypt":
+			dek, ok := bytes.CutPrefix(body["ciphertext"], []byte(key+"|"))
+			if !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string][]byte{"plaintext": dek}) //nolint:errcheck // test mock server
+		}
+	}))
+	t.Cleanup(kms.Close)
+	old := kmsURL
+	t.Cleanup(func() { kmsURL = old })
+	kmsURL = kms.URL
+	return &used
+}
+
+func TestKMSEnvelope(t *testing.T) {
+	fv := &fakeVersions{}
+	setupFakes(t, fv.ServeHTTP)
+	used := setupFakeKMS(t)
+
+	const key = "projects/p/locations/global/keyRings/r/cryptoKeys/k"
+	ctx := context.Background()
+	c := New(WithKMSEnvelope(key))
+	if err := c.StoreInProject(ctx, "test-project", "s", "hunter2"); err != nil {
+		t.Fatalf("StoreInProject() unexpected error = %v", err)
+	}
+	if bytes.Contains(fv.stored, []byte("hunter2")) || !bytes.Contains(fv.stored, []byte(kmsEnvelopeFormat)) {
+		t.Fatalf("stored payload = %s, want an envelope without the plaintext", fv.stored)
+	}
+
+	// A client that switched keys still reads versions sealed under an old one
+	// it lists, but not under keys it doesn't.
+	const newKey = "projects/p/locations/global/keyRings/r/cryptoKeys/new"
+	got, err := New(WithKMSEnvelope(newKey, key)).FetchFromProject(ctx, "test-project", "s")
+	if err != nil || got != "hunter2" {
+		t.Errorf("FetchFromProject() = %q, %v, want hunter2", got, err)
+	}
+	if _, err := New(WithKMSEnvelope(newKey)).FetchFromProject(ctx, "test-project", "s"); err == nil {
+		t.Error("FetchFromProject() with the old key unlisted succeeded, want error")
+	}
+	if want := []string{"encrypt " + key, "decrypt " + key}; strings.Join(*used, ",") != strings.Join(want, ",") {
+		t.Errorf("KMS calls = %v, want %v", *used, want)
+	}
+
+	// An envelope tampered to name another key is rejected without calling KMS.
+	const attackerKey = "projects/attacker/locations/global/keyRings/r/cryptoKeys/k"
+	forged, err := New(WithKMSEnvelope(attackerKey)).sealValue(ctx, "test-token", "s", "forged")
+	if err != nil {
+		t.Fatalf("sealValue() unexpected error = %v", err)
+	}
+	fv.stored = []byte(forged)
+	*used = nil
+	if got, err := c.FetchFromProject(ctx, "test-project", "s"); err == nil || !strings.Contains(err.Error(), "not configured") {
+		t.Errorf("FetchFromProject() of a tampered envelope = %q, %v, want error", got, err)
+	}
+	if len(*used) != 0 {
+		t.Errorf("KMS calls for a tampered envelope = %v, want none", *used)
+	}
+
+	// Without the layer, the envelope is all that can be read.
+	if err := c.StoreInProject(ctx, "test-project", "s", "hunter2"); err != nil {
+		t.Fatalf("StoreInProject() unexpected error = %v", err)
+	}
+	if raw, err := New().FetchFromProject(ctx, "test-project", "s"); err != nil || strings.Contains(raw, "hunter2") {
+		t.Errorf("FetchFromProject() without KMS = %q, %v, want the envelope", raw, err)
+	}
+
+	fv.stored = []byte("plaintext")
+	if _, err := c.FetchFromProject(ctx, "test-project", "s"); !errors.Is(err, ErrNotEncrypted) {
+		t.Errorf("FetchFromProject() of a plaintext version error = %v, want ErrNotEncrypted", err)
+	}
+}
+
+func TestKMSEnvelopeBoundToName(t *testing.T) {
+	setupFakes(t, func(http.ResponseWriter, *http.Request) {})
+	setupFakeKMS(t)
+
+	ctx := context.Background()
+	c := New(WithKMSEnvelope("projects/p/locations/global/keyRings/r/cryptoKeys/k"))
+	sealed, err := c.sealValue(ctx, "test-token", "a", "hunter2")
+	if err != nil {
+		t.Fatalf("sealValue() unexpected error = %v", err)
+	}
+	if _, err := c.openValue(ctx, "test-token", "b", []byte(sealed)); err == nil {
+		t.Error("openValue() of another secret's envelope succeeded, want error")
+	}
+	if got, err := c.openValue(ctx, "test-token", "a", []byte(sealed)); err != nil || got != "hunter2" {
+		t.Errorf("openValue() = %q, %v, want hunter2", got, err)
+	}
+}
+
+func TestLocalEncryption(t *testing.T) {
+	fv := &fakeVersions{}
+	setupFakes(t, fv.ServeHTTP)
+
+	ctx := context.Background()
+	c := New(WithLocalEncryption([]byte("0123456789abcdef0123456789abcdef")))
+	if err := c.StoreInProject(ctx, "test-project", "s", "hunter2"); err != nil {
+		t.Fatalf("StoreInProject() unexpected error = %v", err)
+	}
+	if bytes.Contains(fv.stored, []byte("hunter2")) || !bytes.Contains(fv.stored, []byte(localFormat)) {
+		t.Fatalf("stored payload = %s, want ciphertext", fv.stored)
+	}
+	if got, err := c.FetchFromProject(ctx, "test-project", "s"); err != nil || got != "hunter2" {
+		t.Errorf("FetchFromProject() = %q, %v, want hunter2", got, err)
+	}
+	if _, err := New(WithLocalEncryption([]byte("another key"))).FetchFromProject(ctx, "test-project", "s"); err == nil {
+		t.Error("FetchFromProject() with the wrong key succeeded, want error")
+	}
+	if sealed, err := c.sealValue(ctx, "", "a", "v"); err != nil {
+		t.Errorf("sealValue() unexpected error = %v", err)
+	} else if _, err := c.openValue(ctx, "", "b", []byte(sealed)); err == nil {
+		t.Error("openValue() of another secret's value succeeded, want error")
+	}
+
+	fv.stored = []byte("plaintext")
+	if _, err := c.FetchFromProject(ctx, "test-project", "s"); !errors.Is(err, ErrNotEncrypted) {
+		t.Errorf("FetchFromProject() of a plaintext version error = %v, want ErrNotEncrypted", err)
+	}
+}
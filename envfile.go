@@ -0,0 +1,115 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+)
+
+// OverwritePolicy decides what LoadEnvFile does with variables that are
+// already set in the process environment.
+type OverwritePolicy int
+
+// Overwrite policies.
+const (
+	// OverwriteNever keeps existing values, so the environment can override
+	// the secret, as with most dotenv loaders.
+	OverwriteNever OverwritePolicy = iota
+	// OverwriteAlways replaces existing values with the secret's.
+	OverwriteAlways
+	// OverwriteError fails, setting nothing, if any variable is already set
+	// to a different value.
+	OverwriteError
+)
+
+// EnvFileOption configures the behavior of LoadEnvFile.
+type EnvFileOption func(*envFileOptions)
+
+type envFileOptions struct {
+	overwrite OverwritePolicy
+}
+
+// WithOverwrite sets the policy for variables that are already set. The
+// default is OverwriteNever.
+func WithOverwrite(p OverwritePolicy) EnvFileOption {
+	return func(o *envFileOptions) {
+		o.overwrite = p
+	}
+}
+
+// LoadEnvFile fetches a secret holding KEY=VALUE lines from the current
+// project using the default client and sets them in the process environment.
+// See Client.LoadEnvFile.
+func LoadEnvFile(ctx context.Context, name string, opts ...EnvFileOption) error {
+	return defaultClient.LoadEnvFile(ctx, name, opts...)
+}
+
+// LoadEnvFileFromProject fetches a secret holding KEY=VALUE lines from a
+// specific project using the default client and sets them in the process
+// environment.
+func LoadEnvFileFromProject(ctx context.Context, pid, name string, opts ...EnvFileOption) error {
+	return defaultClient.LoadEnvFileFromProject(ctx, pid, name, opts...)
+}
+
+// LoadEnvFile fetches the latest value of a secret in the current project,
+// parses it as a dotenv file, as ParseImport does FormatDotenv, and sets each
+// variable in the process environment, subject to the WithOverwrite policy.
+// Nothing is set unless the whole file parses. A value that doesn't parse is
+// reported as a *DecodeError naming only the line.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) LoadEnvFile(ctx context.Context, name string, opts ...EnvFileOption) error {
+	value, err := c.Fetch(ctx, name)
+	if err != nil {
+		return err
+	}
+	return setEnvFile(name, value, opts)
+}
+
+// LoadEnvFileFromProject is LoadEnvFile with the secret read from a specific
+// project.
+func (c *Client) LoadEnvFileFromProject(ctx context.Context, pid, name string, opts ...EnvFileOption) error {
+	value, err := c.FetchFromProject(ctx, pid, name)
+	if err != nil {
+		return err
+	}
+	return setEnvFile(name, value, opts)
+}
+
+// setEnvFile parses a dotenv secret value and applies it to the environment.
+func setEnvFile(name, value string, opts []EnvFileOption) error {
+	var o envFileOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	values, err := parseDotenv(value)
+	if err != nil {
+		return &DecodeError{Secret: name, Format: "dotenv", Err: err}
+	}
+
+	keys := slices.Sorted(maps.Keys(values))
+
+	if o.overwrite == OverwriteError {
+		var errs []error
+		for _, k := range keys {
+			if old, ok := os.LookupEnv(k); ok && old != values[k] {
+				errs = append(errs, fmt.Errorf("environment variable %s is already set", k))
+			}
+		}
+		if len(errs) > 0 {
+			return errors.Join(errs...)
+		}
+	}
+
+	for _, k := range keys {
+		if _, ok := os.LookupEnv(k); ok && o.overwrite == OverwriteNever {
+			continue
+		}
+		if err := os.Setenv(k, values[k]); err != nil {
+			return fmt.Errorf("setting %s: %w", k, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLoadEnvFile(t *testing.T) {
+	values := map[string]string{
+		"app-env": "# app settings\nGSM_TEST_HOST=db\nexport GSM_TEST_PASS=\"s3cr\\\"et\"\nGSM_TEST_KEPT=from-secret\n",
+		"broken":  "GSM_TEST_HOST=db\nGSM_TEST_PASS=\"s3cr3t\n",
+	}
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		name := strings.Split(r.URL.Path, "/")[4]
+		v, ok := values[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writePayload(w, "projects/test-project/secrets/"+name+"/versions/1", v)
+	})
+	ctx := context.Background()
+
+	reset := func() {
+		t.Setenv("GSM_TEST_KEPT", "from-env")
+		for _, k := range []string{"GSM_TEST_HOST", "GSM_TEST_PASS"} {
+			t.Setenv(k, "")
+			os.Unsetenv(k) //nolint:errcheck // restored by t.Setenv
+		}
+	}
+	check := func(policy string, want map[string]string) {
+		t.Helper()
+		for k, v := range want {
+			if got := os.Getenv(k); got != v {
+				t.Errorf("%s: %s = %q, want %q", policy, k, got, v)
+			}
+		}
+	}
+
+	reset()
+	if err := LoadEnvFile(ctx, "app-env"); err != nil {
+		t.Fatalf("LoadEnvFile() error = %v", err)
+	}
+	check("default", map[string]string{"GSM_TEST_HOST": "db", "GSM_TEST_PASS": `s3cr"et`, "GSM_TEST_KEPT": "from-env"})
+
+	reset()
+	if err := LoadEnvFileFromProject(ctx, "test-project", "app-env", WithOverwrite(OverwriteAlways)); err != nil {
+		t.Fatalf("LoadEnvFile(OverwriteAlways) error = %v", err)
+	}
+	check("OverwriteAlways", map[string]string{"GSM_TEST_HOST": "db", "GSM_TEST_KEPT": "from-secret"})
+
+	reset()
+	err := LoadEnvFile(ctx, "app-env", WithOverwrite(OverwriteError))
+	if err == nil || !strings.Contains(err.Error(), "GSM_TEST_KEPT") {
+		t.Errorf("LoadEnvFile(OverwriteError) error = %v, want conflict on GSM_TEST_KEPT", err)
+	}
+	check("OverwriteError", map[string]string{"GSM_TEST_HOST": "", "GSM_TEST_KEPT": "from-env"})
+
+	reset()
+	err = LoadEnvFile(ctx, "broken")
+	var de *DecodeError
+	if !errors.As(err, &de) || de.Format != "dotenv" || strings.Contains(err.Error(), "s3cr3t") {
+		t.Errorf("LoadEnvFile(broken) error = %v, want *DecodeError without the value", err)
+	}
+	check("broken", map[string]string{"GSM_TEST_HOST": ""})
+
+	if err := LoadEnvFile(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("LoadEnvFile(missing) error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,60 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"os"
+	"os/exec"
+	"slices"
+)
+
+// ExecWithSecrets runs cmd with secrets from the default client added to its
+// environment. See Client.ExecWithSecrets.
+func ExecWithSecrets(ctx context.Context, cmd *exec.Cmd, secrets map[string]string) error {
+	return defaultClient.ExecWithSecrets(ctx, cmd, secrets)
+}
+
+// ExecWithSecrets resolves secrets, which maps environment variable names to
+// [PROJECT/]SECRET[#VERSION] references as in ParseEnvMapping, and runs cmd
+// with the values added to its environment, as gsm exec does:
+//
+//	cmd := exec.CommandContext(ctx, "./worker")
+//	err := gsm.ExecWithSecrets(ctx, cmd, map[string]string{
+//		"DB_PASSWORD": "db-password",
+//		"API_KEY":     "other-project/api-key#3",
+//	})
+//
+// Secrets are fetched concurrently with one access token, and the command is
+// not started unless every one resolves. The values are added to cmd.Env, or
+// to the current environment if cmd.Env is nil, replacing variables of the
+// same name; the process's own environment is not changed. Like cmd.Run, it
+// returns an *exec.ExitError if the command exits unsuccessfully.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) ExecWithSecrets(ctx context.Context, cmd *exec.Cmd, secrets map[string]string) error {
+	s := &Spec{}
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(secrets)) {
+		e, err := ParseEnvMapping(name + "=" + secrets[name])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		s.Env = append(s.Env, e)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	values, err := c.ResolveSpec(ctx, s)
+	if err != nil {
+		return err
+	}
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	for _, e := range s.Env {
+		cmd.Env = append(cmd.Env, e.Name+"="+values[e.Name])
+	}
+	return cmd.Run()
+}
@@ -0,0 +1,58 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestExecWithSecrets(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(r.URL.Path, "/")
+		project, name, version := parts[2], parts[4], strings.TrimSuffix(parts[6], ":access")
+		if name == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writePayload(w, "projects/"+project+"/secrets/"+name+"/versions/1", project+":"+name+":"+version)
+	})
+	ctx := context.Background()
+	t.Setenv("GSM_TEST_INHERITED", "yes")
+	t.Setenv("DB_PASS", "from-env")
+
+	cmd := exec.Command("sh", "-c", `test "$DB_PASS|$API_KEY|$GSM_TEST_INHERITED" = "test-project:db-password:latest|other-project:api-key:3|yes" || exit 3`)
+	err := ExecWithSecrets(ctx, cmd, map[string]string{"DB_PASS": "db-password", "API_KEY": "other-project/api-key#3"})
+	if err != nil {
+		t.Errorf("ExecWithSecrets() error = %v", err)
+	}
+	if got := os.Getenv("DB_PASS"); got != "from-env" {
+		t.Errorf("ExecWithSecrets() changed DB_PASS in the process to %q", got)
+	}
+
+	// An explicit Env is extended rather than replaced by the current environment.
+	cmd = exec.Command("sh", "-c", `test "$GSM_TEST_INHERITED" = "" && test "$EXTRA" = set || exit 3`)
+	cmd.Env = []string{"EXTRA=set"}
+	if err := ExecWithSecrets(ctx, cmd, map[string]string{"DB_PASS": "db-password"}); err != nil {
+		t.Errorf("ExecWithSecrets() with Env error = %v", err)
+	}
+
+	cmd = exec.Command("sh", "-c", "exit 3")
+	var ee *exec.ExitError
+	if err := ExecWithSecrets(ctx, cmd, nil); !errors.As(err, &ee) || ee.ExitCode() != 3 {
+		t.Errorf("ExecWithSecrets(exit 3) error = %v, want *exec.ExitError", err)
+	}
+
+	for _, secrets := range []map[string]string{{"OK": "db-password", "BAD": "missing"}, {"1BAD": "db-password"}} {
+		cmd := exec.Command("sh", "-c", "exit 0")
+		if err := ExecWithSecrets(ctx, cmd, secrets); err == nil || cmd.Process != nil {
+			t.Errorf("ExecWithSecrets(%v) error = %v, started = %v; want error and not started", secrets, err, cmd.Process != nil)
+		}
+	}
+}
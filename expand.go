@@ -0,0 +1,84 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// placeholderRegex matches ${gsm:REF} placeholders.
+var placeholderRegex = regexp.MustCompile(`\$\{gsm:([^}]*)\}`)
+
+// Expand replaces ${gsm:...} placeholders in text with secrets from the
+// current project using the default client. See Client.Expand.
+func Expand(ctx context.Context, text string) (string, error) {
+	return defaultClient.Expand(ctx, text)
+}
+
+// ExpandFromProject replaces ${gsm:...} placeholders in text with secrets
+// from a specific project using the default client.
+func ExpandFromProject(ctx context.Context, pid, text string) (string, error) {
+	return defaultClient.ExpandFromProject(ctx, pid, text)
+}
+
+// Expand replaces each ${gsm:[PROJECT/]SECRET[#VERSION]} placeholder in text
+// with the value of the secret it names, so config files can reference
+// secrets declaratively:
+//
+//	dsn: postgres://app:${gsm:db-password}@db/app
+//	api_key: ${gsm:other-project/api-key#3}
+//
+// Secrets are fetched concurrently with one access token, and each is fetched
+// once however often it appears. Values are inserted verbatim and are not
+// themselves expanded. The text is returned unchanged, with an error, if any
+// placeholder is malformed or any secret fails to load; the errors for every
+// secret that failed are returned together, each prefixed with its reference.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) Expand(ctx context.Context, text string) (string, error) {
+	return c.expand(ctx, "", text)
+}
+
+// ExpandFromProject is Expand with secrets read from a specific project unless
+// their placeholders name another.
+func (c *Client) ExpandFromProject(ctx context.Context, pid, text string) (string, error) {
+	if !projectIDRegex.MatchString(pid) {
+		return text, fmt.Errorf("invalid project ID format: %q", pid)
+	}
+	return c.expand(ctx, pid, text)
+}
+
+func (c *Client) expand(ctx context.Context, pid, text string) (string, error) {
+	s := &Spec{Project: pid}
+	seen := map[string]bool{}
+	for _, m := range placeholderRegex.FindAllStringSubmatch(text, -1) {
+		ref := m[1]
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		e := EnvSpec{Name: ref}
+		secret, version, pinned := strings.Cut(ref, "#")
+		e.Version = version
+		err := e.setRef(secret)
+		if err == nil && pinned && version == "" {
+			err = errors.New("empty version")
+		}
+		if err != nil {
+			return text, fmt.Errorf("placeholder %s: %w", m[0], err)
+		}
+		s.Env = append(s.Env, e)
+	}
+	if len(s.Env) == 0 {
+		return text, nil
+	}
+
+	values, err := c.resolveSpec(ctx, s)
+	if err != nil {
+		return text, err
+	}
+	return placeholderRegex.ReplaceAllStringFunc(text, func(m string) string {
+		return values[strings.TrimSuffix(strings.TrimPrefix(m, "${gsm:"), "}")]
+	}), nil
+}
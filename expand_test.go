@@ -0,0 +1,53 @@
+package gsm
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	var accesses atomic.Int32
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		accesses.Add(1)
+		parts := strings.Split(r.URL.Path, "/")
+		project, name, version := parts[2], parts[4], strings.TrimSuffix(parts[6], ":access")
+		if name == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writePayload(w, "projects/"+project+"/secrets/"+name+"/versions/1", project+":"+name+":"+version)
+	})
+	ctx := context.Background()
+
+	text := "dsn: ${gsm:db-password}@db\nkey: ${gsm:other-project/api-key#3}\nagain: ${gsm:db-password}\nshell: ${HOME}\n"
+	got, err := Expand(ctx, text)
+	want := "dsn: test-project:db-password:latest@db\nkey: other-project:api-key:3\nagain: test-project:db-password:latest\nshell: ${HOME}\n"
+	if err != nil || got != want {
+		t.Errorf("Expand() = %q, %v, want %q", got, err, want)
+	}
+	if n := accesses.Load(); n != 2 {
+		t.Errorf("Expand() made %d accesses, want 2", n)
+	}
+
+	got, err = ExpandFromProject(ctx, "my-project", "${gsm:token}")
+	if err != nil || got != "my-project:token:latest" {
+		t.Errorf("ExpandFromProject() = %q, %v", got, err)
+	}
+
+	if got, err := Expand(ctx, "no placeholders"); err != nil || got != "no placeholders" {
+		t.Errorf("Expand(no placeholders) = %q, %v", got, err)
+	}
+
+	for _, text := range []string{"${gsm:missing} ${gsm:db-password}", "${gsm:bad name}", "${gsm:name#}", "${gsm:name#x}"} {
+		got, err := Expand(ctx, text)
+		if err == nil || got != text {
+			t.Errorf("Expand(%q) = %q, %v, want unchanged text and error", text, got, err)
+		}
+	}
+	if _, err := ExpandFromProject(ctx, "Bad_Project", "x"); err == nil {
+		t.Error("ExpandFromProject(Bad_Project) expected error")
+	}
+}
@@ -0,0 +1,266 @@
+package gsm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ExportFormat is the document format written by Export.
+type ExportFormat string
+
+// Export formats.
+const (
+	FormatJSON ExportFormat = "json"
+	FormatYAML ExportFormat = "yaml"
+	// FormatDotenv writes NAME="VALUE" lines, readable by Import, and requires
+	// WithPayloads. Metadata is not exported, and binary values are refused.
+	FormatDotenv ExportFormat = "dotenv"
+)
+
+// ExportOption configures the behavior of Export and ExportFromProject.
+type ExportOption func(*exportOptions)
+
+type exportOptions struct {
+	filter   string
+	format   ExportFormat
+	payloads bool
+}
+
+// WithPayloads includes the latest value of each secret in the export.
+// Without it, only names and metadata are exported.
+func WithPayloads() ExportOption {
+	return func(o *exportOptions) {
+		o.payloads = true
+	}
+}
+
+// WithExportFilter exports only secrets matching a Secret Manager list filter
+// expression; see Client.ListInProject.
+func WithExportFilter(filter string) ExportOption {
+	return func(o *exportOptions) {
+		o.filter = filter
+	}
+}
+
+// WithExportFormat selects the document format. The default is FormatJSON.
+func WithExportFormat(f ExportFormat) ExportOption {
+	return func(o *exportOptions) {
+		o.format = f
+	}
+}
+
+// ExportDocument is the structure written by Export.
+type ExportDocument struct {
+	Project string           `json:"project"`
+	Secrets []ExportedSecret `json:"secrets"`
+}
+
+// ExportedSecret is one secret in an ExportDocument.
+type ExportedSecret struct {
+	Labels           map[string]string `json:"labels,omitempty"`
+	Annotations      map[string]string `json:"annotations,omitempty"`
+	CreateTime       string            `json:"createTime,omitempty"`
+	ExpireTime       string            `json:"expireTime,omitempty"`
+	NextRotationTime string            `json:"nextRotationTime,omitempty"`
+	RotationPeriod   string            `json:"rotationPeriod,omitempty"`
+	Name             string            `json:"name"`
+	Topics           []string          `json:"topics,omitempty"`
+	// Version is the version ID Value was read from.
+	Version string `json:"version,omitempty"`
+	// Value is the latest payload, if payloads were exported and the secret has an
+	// enabled latest version. Payloads that are not valid UTF-8 are base64-encoded.
+	Value *string `json:"value,omitempty"`
+	// Encoding is "base64" if Value is base64-encoded.
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Export writes the secrets in the current project to w as a JSON, YAML, or
+// dotenv document using the default client.
+// The project ID is auto-detected from the GCP metadata server.
+func Export(ctx context.Context, w io.Writer, opts ...ExportOption) error {
+	return defaultClient.Export(ctx, w, opts...)
+}
+
+// ExportFromProject writes the secrets in a specific project to w as a JSON,
+// YAML, or dotenv document using the default client.
+func ExportFromProject(ctx context.Context, pid string, w io.Writer, opts ...ExportOption) error {
+	return defaultClient.ExportFromProject(ctx, pid, w, opts...)
+}
+
+// Export writes the secrets in the current project to w as a JSON, YAML, or dotenv document.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) Export(ctx context.Context, w io.Writer, opts ...ExportOption) error {
+	p, err := c.projectID(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.ExportFromProject(ctx, p, w, opts...)
+}
+
+// ExportFromProject writes the names and metadata of the secrets in a specific
+// project, and optionally their latest values, to w as a JSON or YAML
+// ExportDocument for backup and migration. Secrets are sorted by name. Exporting
+// payloads requires secretmanager.versions.access; treat the output accordingly.
+func (c *Client) ExportFromProject(ctx context.Context, pid string, w io.Writer, opts ...ExportOption) error {
+	if !projectIDRegex.MatchString(pid) {
+		return fmt.Errorf("invalid project ID format: %q", pid)
+	}
+	o := exportOptions{format: FormatJSON}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	switch o.format {
+	case FormatJSON, FormatYAML:
+	case FormatDotenv:
+		if !o.payloads {
+			return errors.New("dotenv export requires payloads")
+		}
+	default:
+		return fmt.Errorf("unsupported export format %q", o.format)
+	}
+
+	tok, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	secrets, err := c.listSecrets(ctx, tok, pid, o.filter)
+	if err != nil {
+		return err
+	}
+	slices.SortFunc(secrets, func(a, b *Secret) int { return strings.Compare(a.Name, b.Name) })
+
+	doc := ExportDocument{Project: pid, Secrets: make([]ExportedSecret, len(secrets))}
+	for i, s := range secrets {
+		doc.Secrets[i] = exportedSecret(s)
+	}
+	if o.payloads {
+		if err := c.exportPayloads(ctx, tok, pid, doc.Secrets); err != nil {
+			return err
+		}
+	}
+
+	if o.format == FormatDotenv {
+		data, err := encodeDotenv(doc.Secrets)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if o.format == FormatYAML {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		data = encodeYAML(v)
+	} else {
+		data = append(data, '\n')
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// dotenvEscaper escapes values for double quotes, as parseDotenv unescapes them.
+var dotenvEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+// encodeDotenv writes one NAME="VALUE" line per secret. Secrets without a
+// value are noted in comments.
+func encodeDotenv(secrets []ExportedSecret) ([]byte, error) {
+	var b bytes.Buffer
+	for _, e := range secrets {
+		switch {
+		case e.Value == nil:
+			fmt.Fprintf(&b, "# %s has no accessible latest version\n", e.Name)
+		case e.Encoding != "":
+			return nil, fmt.Errorf("secret %s: binary values can't be exported as dotenv", e.Name)
+		default:
+			fmt.Fprintf(&b, "%s=\"%s\"\n", e.Name, dotenvEscaper.Replace(*e.Value))
+		}
+	}
+	return b.Bytes(), nil
+}
+
+// exportedSecret converts secret metadata to its export form.
+func exportedSecret(s *Secret) ExportedSecret {
+	e := ExportedSecret{
+		Labels:           s.Labels,
+		Annotations:      s.Annotations,
+		CreateTime:       exportTime(s.CreateTime),
+		ExpireTime:       exportTime(s.ExpireTime),
+		NextRotationTime: exportTime(s.NextRotationTime),
+		Name:             s.Name,
+		Topics:           s.Topics,
+	}
+	if s.RotationPeriod > 0 {
+		e.RotationPeriod = formatDuration(s.RotationPeriod)
+	}
+	return e
+}
+
+func exportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// exportPayloads fills in the latest value of each secret concurrently. Secrets
+// without an accessible latest version (none added yet, or disabled) are left without one.
+func (c *Client) exportPayloads(ctx context.Context, tok, pid string, secrets []ExportedSecret) error {
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs []error
+		sem  = make(chan struct{}, maxConcurrentFetches)
+	)
+	for i := range secrets {
+		e := &secrets[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			value, version, err := c.accessVersion(ctx, tok, pid, e.Name, "latest")
+			if noVersion(err) {
+				return
+			}
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("secret %s: %w", e.Name, err))
+				mu.Unlock()
+				return
+			}
+			c.emitAudit(ctx, "fetch", pid, e.Name, version)
+
+			e.Version = version
+			if !utf8.ValidString(value) {
+				value = base64.StdEncoding.EncodeToString([]byte(value))
+				e.Encoding = "base64"
+			}
+			e.Value = &value
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,114 @@
+package gsm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/secrets"):
+			if f := r.URL.Query().Get("filter"); f != "labels.env=prod" {
+				t.Errorf("filter = %q, want labels.env=prod", f)
+			}
+			_, _ = w.Write([]byte(`{"secrets":[
+				{"name":"projects/test-project/secrets/db-password","labels":{"env":"prod"},"createTime":"2024-01-02T03:04:05Z","rotation":{"rotationPeriod":"86400s"}},
+				{"name":"projects/test-project/secrets/binary"},
+				{"name":"projects/test-project/secrets/empty"}]}`)) //nolint:errcheck // test mock server
+		case strings.Contains(r.URL.Path, "/db-password/"):
+			writePayload(w, "projects/test-project/secrets/db-password/versions/3", "line1\nline2")
+		case strings.Contains(r.URL.Path, "/binary/"):
+			writePayload(w, "projects/test-project/secrets/binary/versions/1", "\xff\x00")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	var buf bytes.Buffer
+	ctx := context.Background()
+	if err := ExportFromProject(ctx, "test-project", &buf, WithPayloads(), WithExportFilter("labels.env=prod")); err != nil {
+		t.Fatalf("ExportFromProject() unexpected error = %v", err)
+	}
+	var doc ExportDocument
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("export is not JSON: %v", err)
+	}
+	if doc.Project != "test-project" || len(doc.Secrets) != 3 {
+		t.Fatalf("export = %+v, want 3 secrets from test-project", doc)
+	}
+	bin, db, empty := doc.Secrets[0], doc.Secrets[1], doc.Secrets[2]
+	if bin.Name != "binary" || bin.Encoding != "base64" || bin.Value == nil || *bin.Value != "/wA=" {
+		t.Errorf("binary secret = %+v, want base64 value", bin)
+	}
+	if db.Name != "db-password" || db.Version != "3" || db.Value == nil || *db.Value != "line1\nline2" ||
+		db.Labels["env"] != "prod" || db.CreateTime != "2024-01-02T03:04:05Z" || db.RotationPeriod != "86400s" {
+		t.Errorf("db-password secret = %+v", db)
+	}
+	if empty.Name != "empty" || empty.Value != nil {
+		t.Errorf("empty secret = %+v, want no value", empty)
+	}
+
+	// YAML round-trips to the same document.
+	var yamlBuf bytes.Buffer
+	if err := ExportFromProject(ctx, "test-project", &yamlBuf, WithPayloads(), WithExportFilter("labels.env=prod"), WithExportFormat(FormatYAML)); err != nil {
+		t.Fatalf("ExportFromProject(yaml) unexpected error = %v", err)
+	}
+	v, err := parseYAML(yamlBuf.Bytes())
+	if err != nil {
+		t.Fatalf("YAML export does not parse: %v\n%s", err, yamlBuf.String())
+	}
+	got, _ := json.Marshal(v) //nolint:errcheck // decoded YAML always marshals
+	var fromYAML ExportDocument
+	if err := json.Unmarshal(got, &fromYAML); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := json.Marshal(doc)       //nolint:errcheck // plain struct
+	again, _ := json.Marshal(fromYAML) //nolint:errcheck // plain struct
+	if !bytes.Equal(want, again) {
+		t.Errorf("YAML export = %s, want %s", again, want)
+	}
+
+	if err := ExportFromProject(ctx, "test-project", &buf, WithExportFormat("xml")); err == nil {
+		t.Error("ExportFromProject() with format xml succeeded, want error")
+	}
+}
+
+func TestExportDotenv(t *testing.T) {
+	values := map[string]string{"db-password": "line1\n\"quoted\" \\ $HOME", "api-key": "k"}
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/secrets") {
+			_, _ = w.Write([]byte(`{"secrets":[{"name":"projects/test-project/secrets/db-password"},{"name":"projects/test-project/secrets/api-key"},{"name":"projects/test-project/secrets/empty"}]}`)) //nolint:errcheck // test mock server
+			return
+		}
+		for name, v := range values {
+			if strings.Contains(r.URL.Path, "/"+name+"/") {
+				writePayload(w, "projects/test-project/secrets/"+name+"/versions/1", v)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	if err := ExportFromProject(ctx, "test-project", &buf, WithPayloads(), WithExportFormat(FormatDotenv)); err != nil {
+		t.Fatalf("ExportFromProject(dotenv) unexpected error = %v", err)
+	}
+	want := "api-key=\"k\"\ndb-password=\"line1\\n\\\"quoted\\\" \\\\ $HOME\"\n# empty has no accessible latest version\n"
+	if buf.String() != want {
+		t.Errorf("dotenv export = %q, want %q", buf.String(), want)
+	}
+	got, err := ParseImport(buf.Bytes(), ImportDotenv)
+	if err != nil || len(got) != 2 || got["db-password"] != values["db-password"] || got["api-key"] != "k" {
+		t.Errorf("dotenv export imports as %q, %v, want %q", got, err, values)
+	}
+
+	if err := ExportFromProject(ctx, "test-project", &buf, WithExportFormat(FormatDotenv)); err == nil {
+		t.Error("ExportFromProject(dotenv) without payloads succeeded, want error")
+	}
+}
@@ -0,0 +1,55 @@
+package gsm
+
+import (
+	"context"
+	"time"
+)
+
+// FetchOption configures the behavior of Fetch and FetchFromProject.
+type FetchOption func(*fetchOptions)
+
+type fetchOptions struct {
+	rolloutPercent int
+	rolloutRamp    time.Duration
+	timeout        time.Duration
+	strict         bool
+}
+
+// WithTimeout bounds the whole fetch, including project and token lookups and
+// retries, to d, on top of any deadline ctx already has, so one slow fetch
+// can't consume a request's entire budget. When it expires, the fetch fails
+// with context.DeadlineExceeded, unless WithStaleIfError serves a cached value.
+func WithTimeout(d time.Duration) FetchOption {
+	return func(o *fetchOptions) {
+		o.timeout = d
+	}
+}
+
+// context returns the context bounding a fetch.
+func (o fetchOptions) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.timeout > 0 {
+		return context.WithTimeout(ctx, o.timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// WithRollout progressively rolls out newly added secret versions. For ramp
+// after the latest version is created, only percent of instances receive it;
+// the rest keep receiving the previous enabled version. Instances are bucketed
+// deterministically by their metadata server instance ID (or hostname off-GCP)
+// and the secret's project and name, so each instance sees a consistent value
+// during the ramp.
+func WithRollout(percent int, ramp time.Duration) FetchOption {
+	return func(o *fetchOptions) {
+		o.rolloutPercent = min(max(percent, 0), 100)
+		o.rolloutRamp = ramp
+	}
+}
+
+func newFetchOptions(opts []FetchOption) fetchOptions {
+	var o fetchOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,220 @@
+package gsm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+	"time"
+)
+
+// versionsSuffix names the directory holding a secret's versions. Secret names
+// cannot contain '.', so it never collides with a secret.
+const versionsSuffix = ".versions"
+
+// FS returns a read-only file system exposing the secrets of project, or of
+// the current project if project is empty, so libraries that read templates
+// or certificates from an fs.FS can read secrets directly. If c is nil, the
+// default client is used.
+//
+// The root directory holds one file per secret, containing its latest value,
+// and for each secret a NAME.versions directory holding one file per enabled
+// version, named by version number:
+//
+//	db-password
+//	db-password.versions/1
+//	db-password.versions/2
+//
+// Opening a file fetches its value, and reading a directory lists secrets or
+// versions, so every call may make API requests; open files by name rather
+// than walking a large project. Operations are not bounded by a
+// context beyond the client's own retries. Missing secrets and versions, and
+// secrets with no enabled version, are reported as fs.ErrNotExist, and denied
+// access as fs.ErrPermission.
+func FS(c *Client, project string) fs.FS {
+	if c == nil {
+		c = defaultClient
+	}
+	return &secretFS{c: c, pid: project}
+}
+
+// secretFS implements fs.FS over a project's secrets.
+type secretFS struct {
+	c   *Client
+	pid string
+}
+
+func (f *secretFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	file, err := f.open(context.Background(), name)
+	if err != nil {
+		switch {
+		case noVersion(err):
+			err = fs.ErrNotExist
+		case errors.Is(err, ErrPermissionDenied):
+			err = fs.ErrPermission
+		}
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return file, nil
+}
+
+func (f *secretFS) open(ctx context.Context, name string) (fs.File, error) {
+	pid := f.pid
+	if pid == "" {
+		var err error
+		if pid, err = f.c.projectID(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if name == "." {
+		secrets, err := f.c.ListInProject(ctx, pid, "")
+		if err != nil {
+			return nil, err
+		}
+		var entries []fs.DirEntry
+		for _, s := range secrets {
+			entries = append(entries,
+				&secretDirEntry{fsys: f, path: s.Name},
+				&secretDirEntry{fsys: f, path: s.Name + versionsSuffix, dir: true})
+		}
+		return newSecretDir(".", entries), nil
+	}
+
+	dir, version, nested := strings.Cut(name, "/")
+	secret, isVersions := strings.CutSuffix(dir, versionsSuffix)
+	if !secretNameRegex.MatchString(secret) || nested && (!isVersions || !specVersionRegex.MatchString(version)) {
+		return nil, fs.ErrNotExist
+	}
+
+	switch {
+	case nested:
+		tok, err := f.c.accessToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+		value, got, err := f.c.accessVersion(ctx, tok, pid, secret, version)
+		if err != nil {
+			return nil, err
+		}
+		f.c.emitAudit(ctx, "fetch", pid, secret, got)
+		return newSecretFile(version, value), nil
+	case isVersions:
+		tok, err := f.c.accessToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+		versions, err := f.c.listVersions(ctx, tok, pid, secret, "state:ENABLED")
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]fs.DirEntry, len(versions))
+		for i, v := range versions {
+			entries[i] = &secretDirEntry{fsys: f, path: dir + "/" + versionID(v.Name)}
+		}
+		return newSecretDir(dir, entries), nil
+	default:
+		value, err := f.c.FetchFromProject(ctx, pid, secret)
+		if err != nil {
+			return nil, err
+		}
+		return newSecretFile(secret, value), nil
+	}
+}
+
+// secretInfo describes a secret file or directory.
+type secretInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (i secretInfo) Name() string       { return i.name }
+func (i secretInfo) Size() int64        { return i.size }
+func (i secretInfo) ModTime() time.Time { return time.Time{} }
+func (i secretInfo) IsDir() bool        { return i.dir }
+func (i secretInfo) Sys() any           { return nil }
+
+func (i secretInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+// secretFile is an open secret value. It supports Seek and ReadAt as well as Read.
+type secretFile struct {
+	*bytes.Reader
+	info secretInfo
+}
+
+func newSecretFile(name, value string) *secretFile {
+	return &secretFile{Reader: bytes.NewReader([]byte(value)), info: secretInfo{name: name, size: int64(len(value))}}
+}
+
+func (f *secretFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *secretFile) Close() error               { return nil }
+
+// secretDir is an open directory listing.
+type secretDir struct {
+	info    secretInfo
+	entries []fs.DirEntry
+}
+
+func newSecretDir(name string, entries []fs.DirEntry) *secretDir {
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return &secretDir{info: secretInfo{name: name, dir: true}, entries: entries}
+}
+
+func (d *secretDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *secretDir) Close() error               { return nil }
+
+func (d *secretDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *secretDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(d.entries))
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
+
+// secretDirEntry is a directory entry whose Info is fetched on demand, as
+// listing secrets does not return their values.
+type secretDirEntry struct {
+	fsys fs.FS
+	path string
+	dir  bool
+}
+
+func (e *secretDirEntry) Name() string { return e.path[strings.LastIndex(e.path, "/")+1:] }
+func (e *secretDirEntry) IsDir() bool  { return e.dir }
+
+func (e *secretDirEntry) Type() fs.FileMode {
+	if e.dir {
+		return fs.ModeDir
+	}
+	return 0
+}
+
+func (e *secretDirEntry) Info() (fs.FileInfo, error) {
+	if e.dir {
+		return secretInfo{name: e.Name(), dir: true}, nil
+	}
+	return fs.Stat(e.fsys, e.path)
+}
@@ -0,0 +1,81 @@
+package gsm
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFS(t *testing.T) {
+	// versions holds the enabled versions of each secret, oldest first.
+	versions := map[string][]string{
+		"db-password": {"old", "new"},
+		"tls-cert":    {"-----BEGIN CERTIFICATE-----\n"},
+	}
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/") // projects/P/secrets[/NAME/versions[/V:access]]
+		if len(parts) == 3 {
+			var secrets []map[string]string
+			for name := range versions {
+				secrets = append(secrets, map[string]string{"name": "projects/test-project/secrets/" + name})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"secrets": secrets}) //nolint:errcheck // test mock server
+			return
+		}
+		vs, ok := versions[parts[3]]
+		if !ok || parts[1] != "test-project" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if len(parts) == 5 {
+			if r.URL.Query().Get("filter") != "state:ENABLED" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			var list []map[string]string
+			for i := range vs {
+				list = append(list, map[string]string{"name": r.URL.Path[1:] + "/" + string(rune('1'+i)), "state": "ENABLED"})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"versions": list}) //nolint:errcheck // test mock server
+			return
+		}
+		v := strings.TrimSuffix(parts[5], ":access")
+		i := len(vs)
+		if v != "latest" {
+			i = int(v[0] - '0')
+		}
+		if i < 1 || i > len(vs) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writePayload(w, "projects/test-project/secrets/"+parts[3]+"/versions/"+string(rune('0'+i)), vs[i-1])
+	})
+
+	fsys := FS(nil, "")
+	if err := fstest.TestFS(fsys, "db-password", "db-password.versions/1", "db-password.versions/2", "tls-cert", "tls-cert.versions/1"); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{"db-password": "new", "db-password.versions/1": "old", "tls-cert": versions["tls-cert"][0]} {
+		if got, err := fs.ReadFile(fsys, name); err != nil || string(got) != want {
+			t.Errorf("ReadFile(%s) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+
+	for _, name := range []string{"missing", "db-password.versions/9", "db-password/1", "bad.name", "db-password.versions/x"} {
+		if _, err := fsys.Open(name); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Open(%s) error = %v, want fs.ErrNotExist", name, err)
+		}
+	}
+	if _, err := fsys.Open("/db-password"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("Open(/db-password) error = %v, want fs.ErrInvalid", err)
+	}
+
+	if _, err := fs.ReadFile(FS(nil, "other-project"), "db-password"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ReadFile(other-project) error = %v, want fs.ErrNotExist", err)
+	}
+}
@@ -0,0 +1,296 @@
+package gsm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+// Generator produces new random secret values for StoreGenerated. Its Generate
+// method also fits FetchOrStore, e.g. gsm.FetchOrStore(ctx, name, gsm.HexKey{}.Generate).
+// All generators in this package read from crypto/rand.
+type Generator interface {
+	Generate() (string, error)
+}
+
+// defaultPasswordCharset is letters, digits, and symbols that need no quoting
+// in shells, URLs, or connection strings.
+const defaultPasswordCharset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_.~"
+
+// Password generates passwords of characters drawn uniformly from Charset.
+type Password struct {
+	// Charset is the characters to choose from. The default is ASCII letters,
+	// digits, and "-_.~".
+	Charset string
+	// Length is the number of characters. The default is 32.
+	Length int
+	// RequireEachClass makes every class of character present in Charset
+	// (lowercase, uppercase, digit, and other) appear at least once, for
+	// systems with composition rules.
+	RequireEachClass bool
+}
+
+// Generate implements Generator.
+func (p Password) Generate() (string, error) {
+	charset := []rune(p.Charset)
+	if len(charset) == 0 {
+		charset = []rune(defaultPasswordCharset)
+	}
+	length := p.Length
+	if length == 0 {
+		length = 32
+	}
+	if length < 0 {
+		return "", fmt.Errorf("invalid password length %d", length)
+	}
+	var want []int
+	if p.RequireEachClass {
+		want = runeClasses(charset)
+		if length < len(want) {
+			return "", fmt.Errorf("password length %d is too short to include all %d character classes", length, len(want))
+		}
+	}
+
+	out := make([]rune, length)
+	// Rejection sampling keeps the distribution uniform over passwords that
+	// satisfy the policy; retries are rare for any practical length.
+	for {
+		for i := range out {
+			n, err := randIndex(len(charset))
+			if err != nil {
+				return "", err
+			}
+			out[i] = charset[n]
+		}
+		if hasClasses(out, want) {
+			return string(out), nil
+		}
+	}
+}
+
+// runeClass returns r's class: 0 lowercase, 1 uppercase, 2 digit, 3 other.
+func runeClass(r rune) int {
+	switch {
+	case unicode.IsLower(r):
+		return 0
+	case unicode.IsUpper(r):
+		return 1
+	case unicode.IsDigit(r):
+		return 2
+	default:
+		return 3
+	}
+}
+
+// runeClasses returns the distinct classes of the runes in rs.
+func runeClasses(rs []rune) []int {
+	var seen [4]bool
+	var classes []int
+	for _, r := range rs {
+		if c := runeClass(r); !seen[c] {
+			seen[c] = true
+			classes = append(classes, c)
+		}
+	}
+	return classes
+}
+
+func hasClasses(rs []rune, want []int) bool {
+	var seen [4]bool
+	for _, r := range rs {
+		seen[runeClass(r)] = true
+	}
+	for _, c := range want {
+		if !seen[c] {
+			return false
+		}
+	}
+	return true
+}
+
+// HexKey generates random keys encoded as lowercase hex.
+type HexKey struct {
+	// Bytes is the key size before encoding. The default is 32 (256 bits).
+	Bytes int
+}
+
+// Generate implements Generator.
+func (k HexKey) Generate() (string, error) {
+	b, err := randomKey(k.Bytes)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Base64Key generates random keys encoded as padded standard base64.
+type Base64Key struct {
+	// Bytes is the key size before encoding. The default is 32 (256 bits).
+	Bytes int
+	// URL selects the unpadded URL-safe alphabet instead.
+	URL bool
+}
+
+// Generate implements Generator.
+func (k Base64Key) Generate() (string, error) {
+	b, err := randomKey(k.Bytes)
+	if err != nil {
+		return "", err
+	}
+	if k.URL {
+		return base64.RawURLEncoding.EncodeToString(b), nil
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+func randomKey(n int) ([]byte, error) {
+	if n == 0 {
+		n = 32
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("invalid key size %d", n)
+	}
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Passphrase generates passphrases of words drawn uniformly from Wordlist.
+type Passphrase struct {
+	// Separator joins the words. The default is "-".
+	Separator string
+	// Wordlist is the words to choose from. The default is a built-in list of
+	// 256 short English words, giving 8 bits of entropy per word; use a larger
+	// list, such as the EFF long wordlist, for shorter passphrases.
+	Wordlist []string
+	// Words is the number of words. The default is 10 (80 bits with the
+	// built-in list).
+	Words int
+}
+
+// Generate implements Generator.
+func (p Passphrase) Generate() (string, error) {
+	list := p.Wordlist
+	if len(list) == 0 {
+		list = passphraseWords[:]
+	}
+	words := p.Words
+	if words == 0 {
+		words = 10
+	}
+	if words < 0 {
+		return "", fmt.Errorf("invalid passphrase word count %d", words)
+	}
+	sep := p.Separator
+	if sep == "" {
+		sep = "-"
+	}
+	out := make([]string, words)
+	for i := range out {
+		n, err := randIndex(len(list))
+		if err != nil {
+			return "", err
+		}
+		out[i] = list[n]
+	}
+	return strings.Join(out, sep), nil
+}
+
+// randIndex returns a uniform random integer in [0, n).
+func randIndex(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(v.Int64()), nil
+}
+
+// StoreGenerated generates a value with gen and stores it as a new version of a
+// secret in the current project using the default client, returning the value.
+// The project ID is auto-detected from the GCP metadata server.
+func StoreGenerated(ctx context.Context, name string, gen Generator, opts ...StoreOption) (string, error) {
+	return defaultClient.StoreGenerated(ctx, name, gen, opts...)
+}
+
+// StoreGeneratedInProject generates a value with gen and stores it as a new version
+// of a secret in a specific project using the default client, returning the value.
+func StoreGeneratedInProject(ctx context.Context, pid, name string, gen Generator, opts ...StoreOption) (string, error) {
+	return defaultClient.StoreGeneratedInProject(ctx, pid, name, gen, opts...)
+}
+
+// StoreGenerated generates a value with gen and stores it as a new version of a
+// secret in the current project, returning the value. Unlike FetchOrStore it always
+// adds a version, so it suits rotation as well as bootstrapping.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) StoreGenerated(ctx context.Context, name string, gen Generator, opts ...StoreOption) (string, error) {
+	if !secretNameRegex.MatchString(name) {
+		return "", errors.New("invalid secret name format")
+	}
+
+	p, err := c.projectID(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return c.StoreGeneratedInProject(ctx, p, name, gen, opts...)
+}
+
+// StoreGeneratedInProject generates a value with gen and stores it as a new version
+// of a secret in a specific project, returning the value.
+func (c *Client) StoreGeneratedInProject(ctx context.Context, pid, name string, gen Generator, opts ...StoreOption) (string, error) {
+	if !projectIDRegex.MatchString(pid) {
+		return "", fmt.Errorf("invalid project ID format: %q", pid)
+	}
+	if !secretNameRegex.MatchString(name) {
+		return "", errors.New("invalid secret name format")
+	}
+	value, err := gen.Generate()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate secret %s: %w", name, err)
+	}
+	if err := c.StoreInProject(ctx, pid, name, value, opts...); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// passphraseWords is the default Passphrase wordlist. Its length is a power of
+// two so each word carries a whole number of bits.
+var passphraseWords = [256]string{
+	"acorn", "actor", "adobe", "agent", "alarm", "album", "alert", "alley", "amber",
+	"angle", "ankle", "apple", "apron", "arena", "armor", "arrow", "aspen", "atlas",
+	"attic", "award", "bacon", "badge", "bagel", "baker", "banjo", "barn", "basil", "basin",
+	"beach", "beard", "bench", "berry", "bison", "blade", "blaze", "blimp", "bloom",
+	"board", "bonus", "boot", "brass", "bread", "brick", "bride", "brook", "broom", "brush",
+	"buddy", "bugle", "cabin", "cable", "camel", "canal", "candy", "canoe", "cargo",
+	"cedar", "chair", "chalk", "charm", "chess", "chief", "chimp", "cider", "cliff",
+	"clock", "cloud", "coach", "cobra", "cocoa", "comet", "coral", "couch", "crane",
+	"crate", "crown", "crumb", "cubic", "daisy", "dance", "delta", "denim", "desk", "diary",
+	"dingo", "diver", "donut", "dozen", "drift", "drum", "eagle", "easel", "elbow", "elder",
+	"ember", "envoy", "fable", "fairy", "fancy", "ferry", "fiber", "field", "flame",
+	"flask", "fleet", "flint", "flute", "focus", "fox", "frost", "fudge", "gecko", "ghost",
+	"giant", "globe", "glove", "goose", "grape", "habit", "hammer", "harbor", "hazel",
+	"heron", "hinge", "hippo", "honey", "hotel", "husky", "igloo", "index", "ivory",
+	"jacket", "jaguar", "jelly", "jewel", "jockey", "juice", "jungle", "kayak", "kettle",
+	"kiosk", "kite", "koala", "ladder", "lagoon", "lemon", "lever", "lilac", "lily",
+	"linen", "lizard", "llama", "locket", "lotus", "lunar", "magnet", "mango", "maple",
+	"marble", "meadow", "melon", "metro", "mint", "mirror", "mocha", "moose", "mosaic",
+	"motel", "mural", "music", "napkin", "nectar", "noodle", "nugget", "oasis", "ocean",
+	"olive", "onion", "opal", "orbit", "orchid", "otter", "oven", "owl", "paddle", "panda",
+	"parrot", "pasta", "peach", "pebble", "pepper", "piano", "pickle", "pilot", "pine",
+	"pixel", "planet", "plum", "pony", "poppy", "prism", "puzzle", "quail", "quartz",
+	"quill", "rabbit", "radar", "radio", "raven", "recipe", "ribbon", "river", "robin",
+	"rocket", "rodeo", "saddle", "salad", "salmon", "scarf", "shadow", "shell", "sierra",
+	"silver", "siren", "sketch", "sled", "sloth", "snack", "sonic", "spark", "spider",
+	"spruce", "squid", "stamp", "straw", "sugar", "summit", "sunny", "swan", "table",
+	"tango", "teapot", "tiger", "toast", "tomato", "topaz", "torch", "tulip", "tundra",
+	"turtle", "umbra", "valley", "velvet", "violin", "walnut", "walrus",
+}
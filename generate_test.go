@@ -0,0 +1,147 @@
+package gsm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGenerators(t *testing.T) {
+	tests := []struct {
+		gen   Generator
+		check func(string) bool
+		name  string
+	}{
+		{
+			name: "password default",
+			gen:  Password{},
+			check: func(s string) bool {
+				return len(s) == 32 && strings.Trim(s, defaultPasswordCharset) == ""
+			},
+		},
+		{
+			name: "password charset",
+			gen:  Password{Charset: "ab", Length: 64},
+			check: func(s string) bool {
+				return len(s) == 64 && strings.Trim(s, "ab") == ""
+			},
+		},
+		{
+			name: "password classes",
+			gen:  Password{Charset: "abcdefghijklmnopqrstuvwxyz0!", Length: 3, RequireEachClass: true},
+			check: func(s string) bool {
+				return len(s) == 3 && strings.Contains(s, "0") && strings.Contains(s, "!")
+			},
+		},
+		{
+			name: "hex",
+			gen:  HexKey{Bytes: 16},
+			check: func(s string) bool {
+				b, err := hex.DecodeString(s)
+				return err == nil && len(b) == 16
+			},
+		},
+		{
+			name: "base64",
+			gen:  Base64Key{},
+			check: func(s string) bool {
+				b, err := base64.StdEncoding.DecodeString(s)
+				return err == nil && len(b) == 32
+			},
+		},
+		{
+			name: "base64 url",
+			gen:  Base64Key{Bytes: 33, URL: true},
+			check: func(s string) bool {
+				b, err := base64.RawURLEncoding.DecodeString(s)
+				return err == nil && len(b) == 33
+			},
+		},
+		{
+			name:  "passphrase",
+			gen:   Passphrase{Words: 4, Separator: " ", Wordlist: []string{"x"}},
+			check: func(s string) bool { return s == "x x x x" },
+		},
+		{
+			name:  "passphrase default",
+			gen:   Passphrase{},
+			check: func(s string) bool { return len(strings.Split(s, "-")) == 10 },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := tt.gen.Generate()
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			if !tt.check(a) {
+				t.Errorf("Generate() = %q, which does not match the policy", a)
+			}
+			if b, _ := tt.gen.Generate(); a == b && tt.name != "passphrase" { //nolint:errcheck // checked above
+				t.Errorf("Generate() returned %q twice", a)
+			}
+		})
+	}
+
+	for _, gen := range []Generator{Password{Length: -1}, Password{Charset: "a1", Length: 1, RequireEachClass: true}, HexKey{Bytes: -1}, Passphrase{Words: -1}} {
+		if _, err := gen.Generate(); err == nil {
+			t.Errorf("%#v.Generate() succeeded, want an error", gen)
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, w := range passphraseWords {
+		if w == "" || seen[w] {
+			t.Errorf("passphrase wordlist has empty or duplicate word %q", w)
+		}
+		seen[w] = true
+	}
+}
+
+type failingGenerator struct{}
+
+func (failingGenerator) Generate() (string, error) { return "", errors.New("no entropy") }
+
+func TestStoreGenerated(t *testing.T) {
+	var stored string
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Query().Get("secretId") != "":
+			w.WriteHeader(http.StatusConflict)
+		case strings.HasSuffix(r.URL.Path, ":addVersion"):
+			var req struct {
+				Payload struct {
+					Data string `json:"data"`
+				} `json:"payload"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("decoding addVersion body: %v", err)
+			}
+			b, _ := base64.StdEncoding.DecodeString(req.Payload.Data) //nolint:errcheck // compared below
+			stored = string(b)
+			_, _ = w.Write([]byte(`{"name":"projects/test-project/secrets/db-password/versions/2"}`)) //nolint:errcheck // test mock server
+		}
+	})
+
+	value, err := StoreGenerated(context.Background(), "db-password", Password{Length: 20})
+	if err != nil {
+		t.Fatalf("StoreGenerated() error = %v", err)
+	}
+	if len(value) != 20 || value != stored {
+		t.Errorf("StoreGenerated() = %q, stored %q", value, stored)
+	}
+
+	stored = ""
+	if _, err := StoreGenerated(context.Background(), "db-password", failingGenerator{}); err == nil || !strings.Contains(err.Error(), "no entropy") {
+		t.Errorf("StoreGenerated() error = %v, want the generator's error", err)
+	}
+	if stored != "" {
+		t.Errorf("stored %q after the generator failed", stored)
+	}
+}
@@ -0,0 +1,180 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HealthState describes whether a Client can currently reach Secret Manager.
+type HealthState int32
+
+// Health states.
+const (
+	StateHealthy HealthState = iota
+	StateDegraded
+)
+
+func (s HealthState) String() string {
+	if s == StateDegraded {
+		return "degraded"
+	}
+	return "healthy"
+}
+
+// StateChangeFunc receives health state transitions. err is the failure that
+// caused a transition to StateDegraded, or nil on recovery. It is called
+// synchronously by the operation that caused the transition, without any
+// client lock held, so it may call the client. Operations on different
+// goroutines may call it concurrently, and transitions close together may be
+// delivered out of order; use Client.State for the current state.
+type StateChangeFunc func(state HealthState, err error)
+
+// defaultDegradedAfter is the number of consecutive failures after which a
+// client is considered degraded if WithStateChangeHook does not say otherwise.
+const defaultDegradedAfter = 3
+
+// ErrCircuitOpen is returned (wrapped) for Secret Manager requests refused
+// without being sent because the client's circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// health tracks consecutive failures to reach Secret Manager.
+type health struct {
+	openUntil  time.Time
+	state      atomic.Int32
+	mu         sync.Mutex
+	failures   int
+	limit      int
+	hook       StateChangeFunc
+	breakAfter int
+	cooldown   time.Duration
+}
+
+// WithStateChangeHook registers fn to be called when the client becomes degraded
+// or recovers, so services can flip readiness probes or shed load. The client
+// becomes degraded after failures consecutive operations fail to reach Secret
+// Manager (after retries), or immediately when credentials are lost: an access
+// token cannot be obtained or the API rejects it. It recovers on the next
+// successful API response. Client errors such as a missing secret or denied
+// permission show that Secret Manager is reachable and count as successes.
+// If failures is not positive, the client degrades after 3 consecutive failures.
+func WithStateChangeHook(failures int, fn StateChangeFunc) Option {
+	return func(c *Client) {
+		c.health.limit = failures
+		c.health.hook = fn
+	}
+}
+
+// WithCircuitBreaker stops sending Secret Manager API requests for cooldown
+// once failures consecutive operations have failed to reach it (after retries),
+// so an outage fails calls at once with ErrCircuitOpen instead of tying up
+// goroutines in retries and timeouts. WithStaleIfError still serves cached
+// values while the breaker is open. After cooldown one request is let through
+// as a trial: success closes the breaker, and failure keeps it open for
+// another cooldown. Failures count as for WithStateChangeHook.
+func WithCircuitBreaker(failures int, cooldown time.Duration) Option {
+	return func(c *Client) {
+		c.health.breakAfter = failures
+		c.health.cooldown = cooldown
+	}
+}
+
+// allowRequest returns ErrCircuitOpen if the circuit breaker is open. Once the
+// cooldown has passed it lets one trial request through and rearms the cooldown.
+func (c *Client) allowRequest() error {
+	h := &c.health
+	if h.breakAfter <= 0 {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.failures < h.breakAfter {
+		return nil
+	}
+	now := time.Now()
+	if now.Before(h.openUntil) {
+		return fmt.Errorf("%w after %d consecutive failures; retrying after %s",
+			ErrCircuitOpen, h.failures, h.openUntil.Format(time.RFC3339))
+	}
+	h.openUntil = now.Add(h.cooldown)
+	return nil
+}
+
+// State reports whether the client can currently reach Secret Manager.
+func (c *Client) State() HealthState {
+	return HealthState(c.health.state.Load())
+}
+
+// recordSuccess notes that Secret Manager answered a request.
+func (c *Client) recordSuccess() {
+	h := &c.health
+	h.mu.Lock()
+	h.failures = 0
+	changed := h.transition(StateHealthy)
+	h.mu.Unlock()
+	if changed {
+		c.notifyState(StateHealthy, nil)
+	}
+}
+
+// recordFailure notes that an operation could not reach Secret Manager. Failures
+// caused by the caller's context ending say nothing about Secret Manager and are ignored.
+// Credential failures degrade the client immediately.
+func (c *Client) recordFailure(ctx context.Context, err error, credential bool) {
+	if ctx.Err() != nil {
+		return
+	}
+	h := &c.health
+	h.mu.Lock()
+	h.failures++
+	limit := h.limit
+	if limit <= 0 {
+		limit = defaultDegradedAfter
+	}
+	changed := (credential || h.failures >= limit) && h.transition(StateDegraded)
+	opened := h.breakAfter > 0 && h.failures == h.breakAfter
+	if opened {
+		h.openUntil = time.Now().Add(h.cooldown)
+	}
+	h.mu.Unlock()
+
+	if opened {
+		c.log().Warn("circuit breaker open", "cooldown", h.cooldown, "error", err)
+	}
+	if changed {
+		c.notifyState(StateDegraded, err)
+	}
+}
+
+// recordStatus notes a client error response. A rejected access token means
+// credentials were lost; anything else shows Secret Manager is reachable.
+func (c *Client) recordStatus(ctx context.Context, err *APIError) {
+	if err.StatusCode == http.StatusUnauthorized {
+		c.recordFailure(ctx, err, true)
+		return
+	}
+	c.recordSuccess()
+}
+
+// transition changes the state, reporting whether it changed. The caller must
+// hold h.mu, and must call notifyState after releasing it if it changed.
+func (h *health) transition(s HealthState) bool {
+	return HealthState(h.state.Swap(int32(s))) != s
+}
+
+// notifyState logs a state transition and passes it to the hook. It must not be
+// called with h.mu held, so that the hook can use the client.
+func (c *Client) notifyState(s HealthState, err error) {
+	if s == StateDegraded {
+		c.log().Warn("secret manager unreachable", "error", err)
+	} else {
+		c.log().Info("secret manager reachable again")
+	}
+	if c.health.hook != nil {
+		c.health.hook(s, err)
+	}
+}
@@ -0,0 +1,158 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStateChangeHook(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		if code := int(status.Load()); code != http.StatusOK {
+			w.WriteHeader(code)
+			return
+		}
+		writePayload(w, "projects/test-project/secrets/s/versions/1", "v")
+	})
+
+	var got []HealthState
+	c := New(WithStateChangeHook(2, func(s HealthState, err error) {
+		if (s == StateDegraded) != (err != nil) {
+			t.Errorf("hook(%v, %v): want an error exactly when degraded", s, err)
+		}
+		got = append(got, s)
+	}))
+	ctx := context.Background()
+
+	if _, err := c.FetchFromProject(ctx, "test-project", "s"); err == nil {
+		t.Fatal("FetchFromProject() succeeded against a failing server")
+	}
+	if c.State() != StateHealthy || len(got) != 0 {
+		t.Errorf("after 1 failure: State() = %v, transitions = %v, want healthy and none", c.State(), got)
+	}
+	_, _ = c.FetchFromProject(ctx, "test-project", "s") //nolint:errcheck // failure expected
+	if c.State() != StateDegraded {
+		t.Errorf("after 2 failures: State() = %v, want degraded", c.State())
+	}
+
+	// A missing secret proves Secret Manager is reachable.
+	status.Store(http.StatusNotFound)
+	_, _ = c.FetchFromProject(ctx, "test-project", "s") //nolint:errcheck // failure expected
+	if c.State() != StateHealthy {
+		t.Errorf("after 404: State() = %v, want healthy", c.State())
+	}
+
+	// A rejected token is credential loss and degrades immediately.
+	status.Store(http.StatusUnauthorized)
+	_, _ = c.FetchFromProject(ctx, "test-project", "s") //nolint:errcheck // failure expected
+	if c.State() != StateDegraded {
+		t.Errorf("after 401: State() = %v, want degraded", c.State())
+	}
+
+	status.Store(http.StatusOK)
+	if _, err := c.FetchFromProject(ctx, "test-project", "s"); err != nil {
+		t.Fatalf("FetchFromProject() unexpected error = %v", err)
+	}
+	want := []HealthState{StateDegraded, StateHealthy, StateDegraded, StateHealthy}
+	if len(got) != len(want) {
+		t.Fatalf("transitions = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("transitions = %v, want %v", got, want)
+			break
+		}
+	}
+
+	// Losing the metadata server's token endpoint degrades immediately.
+	metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(metadataServer.Close)
+	metadataURL = metadataServer.URL
+	if _, err := c.FetchFromProject(ctx, "test-project", "s"); err == nil {
+		t.Fatal("FetchFromProject() succeeded without a token")
+	}
+	if c.State() != StateDegraded {
+		t.Errorf("after token failure: State() = %v, want degraded", c.State())
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var status, requests atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		if code := int(status.Load()); code != http.StatusOK {
+			w.WriteHeader(code)
+			return
+		}
+		writePayload(w, "projects/test-project/secrets/s/versions/1", "v")
+	})
+
+	ctx := context.Background()
+	c := New(WithCircuitBreaker(2, 50*time.Millisecond), WithAPIRetry(RetryPolicy{Attempts: 1}))
+	for range 2 {
+		_, _ = c.FetchFromProject(ctx, "test-project", "s") //nolint:errcheck // failure expected
+	}
+	if _, err := c.FetchFromProject(ctx, "test-project", "s"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("FetchFromProject() with the breaker open = %v, want ErrCircuitOpen", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("API requests = %d, want 2 (none while open)", n)
+	}
+
+	// After the cooldown a trial request goes through; a failure reopens the breaker.
+	time.Sleep(60 * time.Millisecond)
+	_, _ = c.FetchFromProject(ctx, "test-project", "s") //nolint:errcheck // failure expected
+	if _, err := c.FetchFromProject(ctx, "test-project", "s"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("FetchFromProject() after a failed trial = %v, want ErrCircuitOpen", err)
+	}
+
+	// A successful trial closes it.
+	time.Sleep(60 * time.Millisecond)
+	status.Store(http.StatusOK)
+	for range 2 {
+		if _, err := c.FetchFromProject(ctx, "test-project", "s"); err != nil {
+			t.Errorf("FetchFromProject() after recovery unexpected error = %v", err)
+		}
+	}
+	if n := requests.Load(); n != 5 {
+		t.Errorf("API requests = %d, want 5", n)
+	}
+}
+
+func TestStateChangeHookUsesClient(t *testing.T) {
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	// The hook runs without the client's health lock held, so it may call the client.
+	var c *Client
+	var calls atomic.Int32
+	c = New(WithCircuitBreaker(1, time.Hour), WithAPIRetry(RetryPolicy{Attempts: 1}),
+		WithStateChangeHook(1, func(HealthState, error) {
+			calls.Add(1)
+			_, _ = c.FetchFromProject(context.Background(), "test-project", "s") //nolint:errcheck // failure expected
+		}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = c.FetchFromProject(context.Background(), "test-project", "s") //nolint:errcheck // failure expected
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("FetchFromProject() deadlocked in the state change hook")
+	}
+	if c.State() != StateDegraded || calls.Load() != 1 {
+		t.Errorf("State() = %v after %d hook calls, want degraded after 1", c.State(), calls.Load())
+	}
+}
@@ -0,0 +1,180 @@
+package gsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGrantAccess(t *testing.T) {
+	tests := []struct {
+		name      string
+		member    string
+		existing  []iamBinding
+		conflicts int
+		wantSets  int
+		wantErr   bool
+	}{
+		{name: "new binding", member: "app@p.iam.gserviceaccount.com", wantSets: 1},
+		{
+			name:     "append to existing binding",
+			member:   "serviceAccount:app@p.iam.gserviceaccount.com",
+			existing: []iamBinding{{Role: AccessorRole, Members: []string{"user:a@example.com"}}},
+			wantSets: 1,
+		},
+		{
+			name:     "already granted",
+			member:   "app@p.iam.gserviceaccount.com",
+			existing: []iamBinding{{Role: AccessorRole, Members: []string{"serviceAccount:app@p.iam.gserviceaccount.com"}}},
+			wantSets: 0,
+		},
+		{name: "retries etag conflict", member: "app@p.iam.gserviceaccount.com", conflicts: 1, wantSets: 2},
+		{name: "gives up after repeated conflicts", member: "app@p.iam.gserviceaccount.com", conflicts: maxRetries, wantSets: maxRetries, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sets := 0
+			var written iamPolicy
+			setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, ":getIamPolicy"):
+					_ = json.NewEncoder(w).Encode(iamPolicy{Etag: "BwX1", Bindings: tt.existing}) //nolint:errcheck // test mock server
+				case strings.HasSuffix(r.URL.Path, ":setIamPolicy"):
+					sets++
+					if sets <= tt.conflicts {
+						w.WriteHeader(http.StatusConflict)
+						return
+					}
+					var req struct {
+						Policy iamPolicy `json:"policy"`
+					}
+					if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+						t.Errorf("decoding setIamPolicy body: %v", err)
+					}
+					written = req.Policy
+					_ = json.NewEncoder(w).Encode(req.Policy) //nolint:errcheck // test mock server
+				}
+			})
+
+			err := GrantAccessInProject(context.Background(), "test-project", "test-secret", tt.member)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GrantAccessInProject() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if sets != tt.wantSets {
+				t.Errorf("setIamPolicy called %d times, want %d", sets, tt.wantSets)
+			}
+			if tt.wantErr || tt.wantSets == 0 {
+				return
+			}
+			if written.Etag != "BwX1" {
+				t.Errorf("written etag = %q, want BwX1", written.Etag)
+			}
+			var members []string
+			for _, b := range written.Bindings {
+				if b.Role == AccessorRole {
+					members = b.Members
+				}
+			}
+			if !slices.Contains(members, "serviceAccount:app@p.iam.gserviceaccount.com") {
+				t.Errorf("accessor members = %v, want service account added", members)
+			}
+		})
+	}
+}
+
+func TestGrantAccessRetryPolicy(t *testing.T) {
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ":setIamPolicy") {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		_, _ = w.Write([]byte(`{"etag":"BwX1"}`)) //nolint:errcheck // test mock server
+	})
+
+	// Conflict retries are reported to the retry hook and bounded by the budget.
+	var events []RetryEvent
+	c := New(WithAPIRetry(RetryPolicy{Attempts: 10, Delay: 20 * time.Millisecond, Budget: 50 * time.Millisecond}),
+		WithRetryHook(func(_ context.Context, ev RetryEvent) { events = append(events, ev) }))
+	err := c.GrantAccessInProject(context.Background(), "test-project", "test-secret", "app@p.iam.gserviceaccount.com")
+	if !errors.Is(err, ErrRetryBudget) {
+		t.Errorf("GrantAccessInProject() error = %v, want ErrRetryBudget", err)
+	}
+	if len(events) == 0 || events[0].Op != "update IAM policy" || !hasStatus(events[0].Err, http.StatusConflict) {
+		t.Errorf("retry events = %+v, want IAM policy update retries", events)
+	}
+}
+
+func TestAddMemberKeepsConditionalBindings(t *testing.T) {
+	p := &iamPolicy{Bindings: []iamBinding{{
+		Role:      AccessorRole,
+		Members:   []string{"user:a@example.com"},
+		Condition: json.RawMessage(`{"expression":"request.time < timestamp(\"2030-01-01T00:00:00Z\")"}`),
+	}}}
+
+	if !addMember(p, AccessorRole, "serviceAccount:b@p.iam.gserviceaccount.com") {
+		t.Fatal("addMember() = false, want policy changed")
+	}
+	if len(p.Bindings) != 2 {
+		t.Fatalf("got %d bindings, want conditional binding left alone plus a new one", len(p.Bindings))
+	}
+	if len(p.Bindings[0].Members) != 1 {
+		t.Errorf("conditional binding members = %v, want unchanged", p.Bindings[0].Members)
+	}
+	if p.Version != 3 {
+		t.Errorf("policy version = %d, want 3 for conditional bindings", p.Version)
+	}
+}
+
+func TestCanAccess(t *testing.T) {
+	tests := []struct {
+		name        string
+		granted     []string
+		check       []string
+		errContains string
+	}{
+		{name: "default access granted", granted: []string{PermissionAccess}},
+		{name: "default access missing", errContains: "missing secretmanager.versions.access on projects/test-project/secrets/test-secret"},
+		{name: "all requested granted", granted: []string{PermissionAccess, PermissionAddVersion}, check: []string{PermissionAccess, PermissionAddVersion}},
+		{name: "some requested missing", granted: []string{PermissionAccess}, check: []string{PermissionAccess, PermissionAddVersion}, errContains: "missing secretmanager.versions.add"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+				if !strings.HasSuffix(r.URL.Path, ":testIamPermissions") || r.Method != http.MethodPost {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+				var req struct {
+					Permissions []string `json:"permissions"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Errorf("decoding body: %v", err)
+				}
+				var granted []string
+				for _, p := range req.Permissions {
+					if slices.Contains(tt.granted, p) {
+						granted = append(granted, p)
+					}
+				}
+				_ = json.NewEncoder(w).Encode(map[string][]string{"permissions": granted}) //nolint:errcheck // test mock server
+			})
+
+			err := CanAccess(context.Background(), "test-secret", tt.check...)
+			if tt.errContains == "" {
+				if err != nil {
+					t.Errorf("CanAccess() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("CanAccess() error = %v, want error containing %q", err, tt.errContains)
+			}
+		})
+	}
+}
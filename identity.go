@@ -0,0 +1,248 @@
+package gsm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// IdentityHeader is the request header carrying the instance identity token
+// when the client is created with WithIdentityHeader.
+const IdentityHeader = "X-Instance-Identity"
+
+// InstanceIdentity is the identity of the VM or Cloud Run instance making
+// requests, taken from a Google-signed identity token issued by the metadata
+// server. Only claims present in the token are populated; Cloud Run tokens
+// carry no Compute Engine instance details.
+type InstanceIdentity struct {
+	// Email is the service account the token was issued to.
+	Email string
+	// Subject is the unique ID of the service account.
+	Subject string
+	// InstanceID is the Compute Engine instance ID.
+	InstanceID string
+	// InstanceName is the Compute Engine instance name.
+	InstanceName string
+	// ProjectID is the project the instance runs in.
+	ProjectID string
+	// Zone is the zone the instance runs in.
+	Zone string
+	// Revision is the Cloud Run revision from K_REVISION. It is read from the
+	// environment and is not part of the signed token.
+	Revision string
+}
+
+type cachedIdentity struct {
+	expiry time.Time
+	id     *InstanceIdentity
+	raw    string
+}
+
+// WithInstanceIdentity includes the instance identity in audit events. The
+// identity comes from a metadata server identity token issued for audience,
+// which is refreshed shortly before it expires.
+func WithInstanceIdentity(audience string) Option {
+	return func(c *Client) {
+		c.identityAudience = audience
+	}
+}
+
+// WithIdentityHeader sends the raw instance identity token in IdentityHeader on
+// every Secret Manager API request, so proxies and access logs can tie requests
+// to a specific instance. It requires WithInstanceIdentity.
+func WithIdentityHeader() Option {
+	return func(c *Client) {
+		c.identityHeader = true
+	}
+}
+
+// WithIdentityAuth authenticates API requests with an identity token for audience
+// instead of an OAuth access token. Use it with WithEndpoint for custom secret
+// brokers that accept Google-signed identity tokens, e.g. Cloud Run or IAP services.
+func WithIdentityAuth(audience string) Option {
+	return func(c *Client) {
+		c.identityAuth = audience
+	}
+}
+
+// IdentityToken returns a Google-signed identity token for audience from the
+// metadata server using the default client.
+func IdentityToken(ctx context.Context, audience string) (string, error) {
+	return defaultClient.IdentityToken(ctx, audience)
+}
+
+// IdentityToken returns a Google-signed identity token for audience from the
+// metadata server, for authenticating to IAP-protected or Cloud Run services.
+// Tokens are cached per audience and refreshed shortly before they expire.
+func (c *Client) IdentityToken(ctx context.Context, audience string) (string, error) {
+	if audience == "" {
+		return "", errors.New("identity token requires an audience")
+	}
+
+	c.mu.Lock()
+	cached, ok := c.idTokens[audience]
+	c.mu.Unlock()
+	if ok && time.Until(cached.expiry) > time.Minute {
+		return cached.raw, nil
+	}
+
+	q := url.Values{"audience": {audience}}
+	body, err := c.metadataGet(ctx, c.serviceAccountPath()+"/identity?"+q.Encode(), "identity token")
+	if err != nil {
+		return "", err
+	}
+	raw := strings.TrimSpace(string(body))
+
+	id, expiry, err := parseIdentityToken(raw, audience)
+	if err != nil {
+		return "", fmt.Errorf("invalid identity token: %w", err)
+	}
+
+	c.mu.Lock()
+	if c.idTokens == nil {
+		c.idTokens = map[string]*cachedIdentity{}
+	}
+	c.idTokens[audience] = &cachedIdentity{expiry: expiry, id: id, raw: raw}
+	c.mu.Unlock()
+	return raw, nil
+}
+
+// ServiceAccountEmail returns the email of the service account the default
+// client authenticates as.
+func ServiceAccountEmail(ctx context.Context) (string, error) {
+	return defaultClient.ServiceAccountEmail(ctx)
+}
+
+// ServiceAccountEmail returns the email of the service account the client
+// authenticates as: the client_email of a service account key, the account
+// chosen with WithServiceAccount, or else the instance's default service
+// account from the metadata server, cached once known. User
+// credentials and emulators have no service account.
+func (c *Client) ServiceAccountEmail(ctx context.Context) (string, error) {
+	if c.emulator {
+		return "", errors.New("emulator clients have no service account")
+	}
+	creds, err := c.credentials()
+	if err != nil {
+		return "", err
+	}
+	if creds != nil {
+		if creds.key == nil {
+			return "", errors.New("user credentials have no service account")
+		}
+		return creds.file.ClientEmail, nil
+	}
+
+	if c.metadataAccount != "" {
+		return c.metadataAccount, nil
+	}
+
+	c.mu.Lock()
+	email := c.saEmail
+	c.mu.Unlock()
+	if email != "" {
+		return email, nil
+	}
+
+	body, err := c.metadataGet(ctx, c.serviceAccountPath()+"/email", "service account email")
+	if err != nil {
+		return "", err
+	}
+	email = strings.TrimSpace(string(body))
+	if email == "" {
+		return "", errors.New("metadata server returned an empty service account email")
+	}
+
+	c.mu.Lock()
+	c.saEmail = email
+	c.mu.Unlock()
+	return email, nil
+}
+
+// instanceIdentity returns the decoded identity and raw token, fetching a new token when needed.
+func (c *Client) instanceIdentity(ctx context.Context) (*InstanceIdentity, string, error) {
+	if c.identityAudience == "" {
+		return nil, "", errors.New("instance identity requires WithInstanceIdentity")
+	}
+
+	c.mu.Lock()
+	cached := c.identity
+	c.mu.Unlock()
+	if cached != nil && time.Until(cached.expiry) > time.Minute {
+		return cached.id, cached.raw, nil
+	}
+
+	q := url.Values{"audience": {c.identityAudience}, "format": {"full"}}
+	body, err := c.metadataGet(ctx, c.serviceAccountPath()+"/identity?"+q.Encode(), "identity token")
+	if err != nil {
+		return nil, "", err
+	}
+	raw := strings.TrimSpace(string(body))
+
+	id, expiry, err := parseIdentityToken(raw, c.identityAudience)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid identity token: %w", err)
+	}
+
+	c.mu.Lock()
+	c.identity = &cachedIdentity{expiry: expiry, id: id, raw: raw}
+	c.mu.Unlock()
+	return id, raw, nil
+}
+
+// parseIdentityToken decodes the claims of a metadata server identity token and
+// checks its issuer and audience. The signature is not checked: the token was
+// received directly from the metadata server.
+func parseIdentityToken(raw, audience string) (*InstanceIdentity, time.Time, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, time.Time{}, errors.New("malformed JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("decoding claims: %w", err)
+	}
+
+	var claims struct {
+		Google struct {
+			ComputeEngine struct {
+				InstanceID   string `json:"instance_id"`
+				InstanceName string `json:"instance_name"`
+				ProjectID    string `json:"project_id"`
+				Zone         string `json:"zone"`
+			} `json:"compute_engine"`
+		} `json:"google"`
+		Audience string `json:"aud"`
+		Email    string `json:"email"`
+		Issuer   string `json:"iss"`
+		Subject  string `json:"sub"`
+		Expiry   int64  `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, time.Time{}, fmt.Errorf("decoding claims: %w", err)
+	}
+
+	if claims.Issuer != "https://accounts.google.com" && claims.Issuer != "accounts.google.com" {
+		return nil, time.Time{}, fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+	if claims.Audience != audience {
+		return nil, time.Time{}, fmt.Errorf("unexpected audience %q", claims.Audience)
+	}
+
+	ce := claims.Google.ComputeEngine
+	return &InstanceIdentity{
+		Email:        claims.Email,
+		Subject:      claims.Subject,
+		InstanceID:   ce.InstanceID,
+		InstanceName: ce.InstanceName,
+		ProjectID:    ce.ProjectID,
+		Zone:         ce.Zone,
+		Revision:     os.Getenv("K_REVISION"),
+	}, time.Unix(claims.Expiry, 0), nil
+}
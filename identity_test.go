@@ -0,0 +1,262 @@
+package gsm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestInstanceIdentityInAuditEvents(t *testing.T) {
+	var header string
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get(IdentityHeader)
+		writePayload(w, "projects/test-project/secrets/test-secret/versions/4", "value")
+	})
+
+	var events []AuditEvent
+	c := New(
+		WithAuditHook(func(_ context.Context, ev AuditEvent) { events = append(events, ev) }),
+		WithInstanceIdentity("https://audit.example.com"),
+		WithIdentityHeader(),
+	)
+
+	if _, err := c.FetchFromProject(context.Background(), "test-project", "test-secret"); err != nil {
+		t.Fatalf("FetchFromProject() unexpected error = %v", err)
+	}
+
+	if header != fakeIdentityToken("https://audit.example.com") {
+		t.Errorf("%s header = %q, want identity token", IdentityHeader, header)
+	}
+	if len(events) != 1 {
+		t.Fatalf("got %d audit events, want 1", len(events))
+	}
+	ev := events[0]
+	if ev.Operation != "fetch" || ev.Project != "test-project" || ev.Secret != "test-secret" || ev.Version != "4" {
+		t.Errorf("audit event = %+v, want fetch of test-project/test-secret version 4", ev)
+	}
+	if ev.Identity == nil {
+		t.Fatal("audit event has no identity")
+	}
+	if ev.Identity.InstanceID != "1234567890" || ev.Identity.InstanceName != "test-vm" || ev.Identity.Zone != "us-central1-a" {
+		t.Errorf("identity = %+v, want test-vm instance details", ev.Identity)
+	}
+	if ev.Identity.Email != "sa@test-project.iam.gserviceaccount.com" {
+		t.Errorf("identity email = %q", ev.Identity.Email)
+	}
+}
+
+func TestAuditWithoutIdentity(t *testing.T) {
+	var header string
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get(IdentityHeader)
+		writePayload(w, "projects/test-project/secrets/test-secret/versions/1", "value")
+	})
+
+	var got *AuditEvent
+	c := New(WithAuditHook(func(_ context.Context, ev AuditEvent) { got = &ev }))
+	if _, err := c.FetchFromProject(context.Background(), "test-project", "test-secret"); err != nil {
+		t.Fatalf("FetchFromProject() unexpected error = %v", err)
+	}
+	if header != "" {
+		t.Errorf("%s header = %q, want none", IdentityHeader, header)
+	}
+	if got == nil || got.Identity != nil {
+		t.Fatalf("audit event = %+v, want event without identity", got)
+	}
+	if got.ServiceAccount != "sa@test-project.iam.gserviceaccount.com" {
+		t.Errorf("audit event ServiceAccount = %q, want default service account", got.ServiceAccount)
+	}
+}
+
+func TestAuditServiceAccountLookedUpOnce(t *testing.T) {
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		writePayload(w, "projects/test-project/secrets/s/versions/1", "value")
+	})
+	var lookups atomic.Int32
+	metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/email") {
+			lookups.Add(1)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"test-token","expires_in":3599}`)) //nolint:errcheck // test mock server
+	}))
+	t.Cleanup(metadataServer.Close)
+
+	// A failed lookup is not repeated for every event, including cache hits.
+	var events []AuditEvent
+	c := New(WithMetadataEndpoint(metadataServer.URL), WithCache(time.Minute),
+		WithAuditHook(func(_ context.Context, ev AuditEvent) { events = append(events, ev) }))
+	for range 3 {
+		if _, err := c.FetchFromProject(context.Background(), "test-project", "s"); err != nil {
+			t.Fatalf("FetchFromProject() unexpected error = %v", err)
+		}
+	}
+	n := lookups.Load()
+	if _, err := c.FetchFromProject(context.Background(), "test-project", "s"); err != nil {
+		t.Fatalf("FetchFromProject() unexpected error = %v", err)
+	}
+	if len(events) != 4 || events[3].ServiceAccount != "" {
+		t.Errorf("audit events = %+v, want 4 without a service account", events)
+	}
+	if got := lookups.Load(); n == 0 || got != n {
+		t.Errorf("service account lookups = %d, then %d after another cache hit; want one lookup", n, got)
+	}
+}
+
+func TestAuditLabels(t *testing.T) {
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		writePayload(w, "projects/test-project/secrets/s/versions/4", "value")
+	})
+
+	var events []AuditEvent
+	c := New(WithCache(time.Minute), WithAuditHook(func(_ context.Context, ev AuditEvent) { events = append(events, ev) }))
+	ctx := ContextWithAuditLabels(context.Background(), map[string]string{"request": "r1", "user": "alice"})
+	ctx = ContextWithAuditLabels(ctx, map[string]string{"request": "r2"})
+	for range 2 {
+		if _, err := c.FetchFromProject(ctx, "test-project", "s"); err != nil {
+			t.Fatalf("FetchFromProject() unexpected error = %v", err)
+		}
+	}
+
+	if len(events) != 2 || events[0].Operation != "fetch" || events[1].Operation != "cache" {
+		t.Fatalf("audit events = %+v, want a fetch then a cache hit", events)
+	}
+	for _, ev := range events {
+		if ev.Labels["request"] != "r2" || ev.Labels["user"] != "alice" || ev.Version != "4" || ev.Time.IsZero() {
+			t.Errorf("audit event = %+v, want merged labels, version 4, and a time", ev)
+		}
+	}
+}
+
+func TestServiceAccountEmail(t *testing.T) {
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	ctx := context.Background()
+	c := New()
+	got, err := c.ServiceAccountEmail(ctx)
+	if err != nil {
+		t.Fatalf("ServiceAccountEmail() unexpected error = %v", err)
+	}
+	if got != "sa@test-project.iam.gserviceaccount.com" {
+		t.Errorf("ServiceAccountEmail() = %q, want default service account", got)
+	}
+
+	// Once known, the email is served from the cache.
+	metadataURL = "http://127.0.0.1:1"
+	if got, err := c.ServiceAccountEmail(ctx); err != nil || got != "sa@test-project.iam.gserviceaccount.com" {
+		t.Errorf("cached ServiceAccountEmail() = %q, %v", got, err)
+	}
+
+	key, _ := writeServiceAccountKey(t, "http://127.0.0.1:1")
+	if got, err := New(WithCredentialsFile(key)).ServiceAccountEmail(ctx); err != nil || got != "ci@key-project.iam.gserviceaccount.com" {
+		t.Errorf("ServiceAccountEmail() with key file = %q, %v, want client_email", got, err)
+	}
+
+	t.Setenv(EmulatorHostEnv, "localhost:8085")
+	if _, err := New().ServiceAccountEmail(ctx); err == nil {
+		t.Error("ServiceAccountEmail() with emulator succeeded, want error")
+	}
+}
+
+func TestParseIdentityToken(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         string
+		audience    string
+		errContains string
+	}{
+		{name: "valid", raw: fakeIdentityToken("aud"), audience: "aud"},
+		{name: "wrong audience", raw: fakeIdentityToken("other"), audience: "aud", errContains: "unexpected audience"},
+		{name: "not a jwt", raw: "garbage", audience: "aud", errContains: "malformed JWT"},
+		{name: "bad claims", raw: "a.!!!.c", audience: "aud", errContains: "decoding claims"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, _, err := parseIdentityToken(tt.raw, tt.audience)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("parseIdentityToken() error = %v, want error containing %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseIdentityToken() unexpected error = %v", err)
+			}
+			if id.ProjectID != "test-project" {
+				t.Errorf("ProjectID = %q, want test-project", id.ProjectID)
+			}
+		})
+	}
+}
+
+func TestIdentityToken(t *testing.T) {
+	var auth string
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		writePayload(w, "projects/test-project/secrets/test-secret/versions/1", "value")
+	})
+
+	ctx := context.Background()
+	got, err := IdentityToken(ctx, "https://service.example.com")
+	if err != nil {
+		t.Fatalf("IdentityToken() unexpected error = %v", err)
+	}
+	if got != fakeIdentityToken("https://service.example.com") {
+		t.Errorf("IdentityToken() = %q, want token for the audience", got)
+	}
+	if _, err := IdentityToken(ctx, ""); err == nil {
+		t.Error("IdentityToken() without audience succeeded, want error")
+	}
+
+	c := New(WithIdentityAuth("https://broker.example.com"))
+	if _, err := c.FetchFromProject(ctx, "test-project", "test-secret"); err != nil {
+		t.Fatalf("FetchFromProject() unexpected error = %v", err)
+	}
+	if want := "Bearer " + fakeIdentityToken("https://broker.example.com"); auth != want {
+		t.Errorf("Authorization = %q, want identity token", auth)
+	}
+}
+
+func TestWithServiceAccount(t *testing.T) {
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		writePayload(w, "projects/test-project/secrets/s/versions/1", "v")
+	})
+	var paths []string
+	metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "/identity") {
+			_, _ = w.Write([]byte(fakeIdentityToken(r.URL.Query().Get("audience")))) //nolint:errcheck // test mock server
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"test-token","expires_in":3599}`)) //nolint:errcheck // test mock server
+	}))
+	t.Cleanup(metadataServer.Close)
+
+	ctx := context.Background()
+	const email = "reader@test-project.iam.gserviceaccount.com"
+	c := New(WithServiceAccount(email), WithMetadataEndpoint(metadataServer.URL))
+	if _, err := c.FetchFromProject(ctx, "test-project", "s"); err != nil {
+		t.Fatalf("FetchFromProject() unexpected error = %v", err)
+	}
+	if _, err := c.IdentityToken(ctx, "https://service.example.com"); err != nil {
+		t.Fatalf("IdentityToken() unexpected error = %v", err)
+	}
+	want := []string{
+		"/instance/service-accounts/" + email + "/token",
+		"/instance/service-accounts/" + email + "/identity",
+	}
+	if strings.Join(paths, " ") != strings.Join(want, " ") {
+		t.Errorf("metadata requests = %v, want %v", paths, want)
+	}
+	if got, err := c.ServiceAccountEmail(ctx); err != nil || got != email {
+		t.Errorf("ServiceAccountEmail() = %q, %v, want %q", got, err, email)
+	}
+}
@@ -0,0 +1,226 @@
+package gsm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ImportFormat is the format of a file read by Import.
+type ImportFormat string
+
+// Import formats.
+const (
+	ImportDotenv ImportFormat = "dotenv"
+	ImportJSON   ImportFormat = "json"
+	ImportYAML   ImportFormat = "yaml"
+)
+
+// ImportFormatOf guesses the format of a secrets file from its name:
+// .json, .yaml or .yml, and .env or names starting with ".env" (e.g. ".env.production").
+func ImportFormatOf(path string) (ImportFormat, error) {
+	base := filepath.Base(path)
+	switch ext := filepath.Ext(base); {
+	case ext == ".json":
+		return ImportJSON, nil
+	case ext == ".yaml" || ext == ".yml":
+		return ImportYAML, nil
+	case ext == ".env" || strings.HasPrefix(base, ".env"):
+		return ImportDotenv, nil
+	default:
+		return "", fmt.Errorf("cannot tell the format of %s; want .env, .json, .yaml, or .yml", base)
+	}
+}
+
+// ParseImport decodes a secrets file into values keyed by secret name.
+//
+// Dotenv files hold KEY=VALUE lines with optional "export" prefixes, comments,
+// and single- or double-quoted (possibly multiline) values. JSON and YAML files
+// hold a flat mapping of names to scalar values, or an ExportDocument, whose
+// secrets with values are imported. Plain YAML scalars are imported as written,
+// so "pin: 0123" imports "0123", and null values are an error.
+func ParseImport(data []byte, f ImportFormat) (map[string]string, error) {
+	switch f {
+	case ImportDotenv:
+		return parseDotenv(string(data))
+	case ImportJSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		var doc any
+		if err := dec.Decode(&doc); err != nil {
+			return nil, err
+		}
+		return importValues(doc)
+	case ImportYAML:
+		doc, err := parseYAMLText(data)
+		if err != nil {
+			return nil, err
+		}
+		return importValues(doc)
+	default:
+		return nil, fmt.Errorf("unsupported import format %q", f)
+	}
+}
+
+// Import creates or updates the secrets in a file in the current project using
+// the default client. See Client.ImportInProject.
+// The project ID is auto-detected from the GCP metadata server.
+func Import(ctx context.Context, data []byte, f ImportFormat, opts ...StoreOption) (map[string]error, error) {
+	return defaultClient.Import(ctx, data, f, opts...)
+}
+
+// ImportInProject creates or updates the secrets in a file in a specific project
+// using the default client. See Client.ImportInProject.
+func ImportInProject(ctx context.Context, pid string, data []byte, f ImportFormat, opts ...StoreOption) (map[string]error, error) {
+	return defaultClient.ImportInProject(ctx, pid, data, f, opts...)
+}
+
+// Import creates or updates the secrets in a file in the current project.
+// See ImportInProject.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) Import(ctx context.Context, data []byte, f ImportFormat, opts ...StoreOption) (map[string]error, error) {
+	p, err := c.projectID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.ImportInProject(ctx, p, data, f, opts...)
+}
+
+// ImportInProject parses a dotenv, JSON, or YAML secrets file (see ParseImport)
+// and stores every value in a specific project, creating missing secrets. Values
+// that already match the latest version are skipped, so re-running an import
+// adds no versions. Results are reported per secret as with StoreManyInProject.
+func (c *Client) ImportInProject(ctx context.Context, pid string, data []byte, f ImportFormat, opts ...StoreOption) (map[string]error, error) {
+	if !projectIDRegex.MatchString(pid) {
+		return nil, fmt.Errorf("invalid project ID format: %q", pid)
+	}
+	values, err := ParseImport(data, f)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.storeMany(ctx, pid, values, newStoreOptions(opts), storeIfChanged)
+}
+
+// importValues extracts secret values from a decoded JSON or YAML document.
+func importValues(doc any) (map[string]string, error) {
+	m, ok := doc.(map[string]any)
+	if !ok {
+		return nil, errors.New("import file must be a mapping of secret names to values")
+	}
+	if secrets, ok := m["secrets"].([]any); ok {
+		return importExportDocument(secrets)
+	}
+
+	values := make(map[string]string, len(m))
+	for k, v := range m {
+		switch v.(type) {
+		case nil:
+			return nil, fmt.Errorf("value of %s is null; quote it (\"\") to import an empty value", k)
+		case map[string]any, []any:
+			return nil, fmt.Errorf("value of %s must be a string", k)
+		}
+		values[k] = yamlString(v)
+	}
+	return values, nil
+}
+
+// importExportDocument extracts the values from the secrets of an ExportDocument.
+func importExportDocument(secrets []any) (map[string]string, error) {
+	values := make(map[string]string, len(secrets))
+	for i, item := range secrets {
+		s, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("secret %d is not a mapping", i)
+		}
+		name, _ := s["name"].(string) //nolint:errcheck // checked below
+		if name == "" {
+			return nil, fmt.Errorf("secret %d has no name", i)
+		}
+		v, ok := s["value"].(string)
+		if !ok {
+			continue
+		}
+		if s["encoding"] == "base64" {
+			b, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return nil, fmt.Errorf("secret %s: %w", name, err)
+			}
+			v = string(b)
+		}
+		values[name] = v
+	}
+	return values, nil
+}
+
+// parseDotenv parses KEY=VALUE lines.
+func parseDotenv(text string) (map[string]string, error) {
+	values := map[string]string{}
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, rest, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("dotenv: line %d: expected KEY=VALUE", i+1)
+		}
+		rest = strings.TrimLeft(rest, " \t")
+
+		if rest == "" || (rest[0] != '"' && rest[0] != '\'') {
+			if j := strings.Index(rest, " #"); j >= 0 {
+				rest = rest[:j]
+			}
+			values[key] = strings.TrimSpace(rest)
+			continue
+		}
+
+		// Quoted values may continue over following lines until the closing quote.
+		start := i
+		quote := rest[0]
+		body := rest[1:]
+		end := dotenvQuoteEnd(body, quote)
+		for end < 0 && i+1 < len(lines) {
+			i++
+			body += "\n" + lines[i]
+			end = dotenvQuoteEnd(body, quote)
+		}
+		if end < 0 {
+			return nil, fmt.Errorf("dotenv: line %d: unterminated quoted value", start+1)
+		}
+		if trailing := strings.TrimSpace(body[end+1:]); trailing != "" && !strings.HasPrefix(trailing, "#") {
+			return nil, fmt.Errorf("dotenv: line %d: unexpected text after quoted value", i+1)
+		}
+
+		v := body[:end]
+		if quote == '"' {
+			v = strings.NewReplacer(`\n`, "\n", `\r`, "\r", `\t`, "\t", `\"`, `"`, `\\`, `\`).Replace(v)
+		}
+		values[key] = v
+	}
+	return values, nil
+}
+
+// dotenvQuoteEnd returns the index of the closing quote in s, or -1. Inside
+// double quotes, backslash escapes the next character.
+func dotenvQuoteEnd(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
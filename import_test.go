@@ -0,0 +1,128 @@
+package gsm
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseImport(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      ImportFormat
+		in          string
+		want        map[string]string
+		errContains string
+	}{
+		{
+			name:   "dotenv",
+			format: ImportDotenv,
+			in: `# database
+DB_PASSWORD=hunter2
+export API_KEY = abc # trailing comment
+EMPTY=
+DOUBLE="a \"quoted\"\nvalue"
+SINGLE='no \n escapes'
+PEM="-----BEGIN KEY-----
+line
+-----END KEY-----"
+HASH=a#b
+`,
+			want: map[string]string{
+				"DB_PASSWORD": "hunter2",
+				"API_KEY":     "abc",
+				"EMPTY":       "",
+				"DOUBLE":      "a \"quoted\"\nvalue",
+				"SINGLE":      `no \n escapes`,
+				"PEM":         "-----BEGIN KEY-----\nline\n-----END KEY-----",
+				"HASH":        "a#b",
+			},
+		},
+		{name: "dotenv missing equals", format: ImportDotenv, in: "JUSTAKEY\n", errContains: "line 1: expected KEY=VALUE"},
+		{name: "dotenv unterminated", format: ImportDotenv, in: "A=\"open\nB=2\n", errContains: "line 1: unterminated"},
+		{
+			name:   "json",
+			format: ImportJSON,
+			in:     `{"db-password":"hunter2","port":5432,"debug":true}`,
+			want:   map[string]string{"db-password": "hunter2", "port": "5432", "debug": "true"},
+		},
+		{name: "json nested value", format: ImportJSON, in: `{"a":{"b":"c"}}`, errContains: "value of a must be a string"},
+		{
+			name:   "yaml",
+			format: ImportYAML,
+			in:     "db-password: hunter2\ntls-key: |\n  line1\n  line2\n",
+			want:   map[string]string{"db-password": "hunter2", "tls-key": "line1\nline2\n"},
+		},
+		{
+			name:   "yaml plain scalars kept as written",
+			format: ImportYAML,
+			in:     "pin: 0123\nid: 007\ndelta: +5\nratio: .5\nflag: yes\nport: 5432\n",
+			want:   map[string]string{"pin": "0123", "id": "007", "delta": "+5", "ratio": ".5", "flag": "yes", "port": "5432"},
+		},
+		{name: "yaml null", format: ImportYAML, in: "a: ~\n", errContains: "value of a is null"},
+		{name: "yaml null word", format: ImportYAML, in: "a: null\n", errContains: "value of a is null"},
+		{name: "yaml empty value", format: ImportYAML, in: "a:\n", errContains: "value of a is null"},
+		{
+			name:   "yaml quoted empty value",
+			format: ImportYAML,
+			in:     "a: \"\"\n",
+			want:   map[string]string{"a": ""},
+		},
+		{
+			name:   "export document",
+			format: ImportJSON,
+			in:     `{"project":"p","secrets":[{"name":"a","value":"1"},{"name":"b"},{"name":"c","value":"/wA=","encoding":"base64"}]}`,
+			want:   map[string]string{"a": "1", "c": "\xff\x00"},
+		},
+		{name: "unknown format", format: "toml", in: "", errContains: `unsupported import format "toml"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseImport([]byte(tt.in), tt.format)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("ParseImport() error = %v, want error containing %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseImport() unexpected error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseImport() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportFormatOf(t *testing.T) {
+	for path, want := range map[string]ImportFormat{
+		"secrets.json": ImportJSON, "a/b.yml": ImportYAML, "x.yaml": ImportYAML,
+		".env": ImportDotenv, ".env.production": ImportDotenv, "prod.env": ImportDotenv,
+	} {
+		if got, err := ImportFormatOf(path); err != nil || got != want {
+			t.Errorf("ImportFormatOf(%q) = %q, %v, want %q", path, got, err, want)
+		}
+	}
+	if _, err := ImportFormatOf("secrets.txt"); err == nil {
+		t.Error("ImportFormatOf(secrets.txt) succeeded, want error")
+	}
+}
+
+func TestImport(t *testing.T) {
+	fake := &fakeProjects{values: map[string]string{"test-project/same": "v"}}
+	setupFakes(t, fake.ServeHTTP)
+
+	results, err := Import(context.Background(), []byte("same=v\nnew=n\nbad.name=x\n"), ImportDotenv)
+	if err != nil {
+		t.Fatalf("Import() unexpected error = %v", err)
+	}
+	if results["same"] != nil || results["new"] != nil || results["bad.name"] == nil {
+		t.Errorf("Import() results = %v, want same and new to succeed and bad.name to fail", results)
+	}
+	if fake.values["test-project/new"] != "n" {
+		t.Errorf("imported value = %q, want n", fake.values["test-project/new"])
+	}
+}
@@ -0,0 +1,84 @@
+package gsm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// List returns metadata for the secrets in the current project matching filter
+// using the default client. See Client.ListInProject.
+// The project ID is auto-detected from the GCP metadata server.
+func List(ctx context.Context, filter string) ([]*Secret, error) {
+	return defaultClient.List(ctx, filter)
+}
+
+// ListInProject returns metadata for the secrets in a specific project matching
+// filter using the default client. See Client.ListInProject.
+func ListInProject(ctx context.Context, pid, filter string) ([]*Secret, error) {
+	return defaultClient.ListInProject(ctx, pid, filter)
+}
+
+// List returns metadata for the secrets in the current project matching filter.
+// See ListInProject.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) List(ctx context.Context, filter string) ([]*Secret, error) {
+	p, err := c.projectID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.ListInProject(ctx, p, filter)
+}
+
+// ListInProject returns metadata for the secrets in a specific project matching filter,
+// a Secret Manager list filter expression evaluated server-side, such as
+// "labels.env=prod", "name:myapp", or "create_time>2024-01-01T00:00:00Z AND labels.team=core".
+// An empty filter lists every secret. Listing requires secretmanager.secrets.list.
+// See https://cloud.google.com/secret-manager/docs/filtering for the syntax.
+func (c *Client) ListInProject(ctx context.Context, pid, filter string) ([]*Secret, error) {
+	if !projectIDRegex.MatchString(pid) {
+		return nil, fmt.Errorf("invalid project ID format: %q", pid)
+	}
+
+	tok, err := c.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.listSecrets(ctx, tok, pid, filter)
+}
+
+// listSecrets returns every secret in a project matching the server-side filter,
+// which may be empty, following pagination.
+func (c *Client) listSecrets(ctx context.Context, tok, pid, filter string) ([]*Secret, error) {
+	var secrets []*Secret
+	pageToken := ""
+	for {
+		q := url.Values{"pageSize": {"250"}}
+		if filter != "" {
+			q.Set("filter", filter)
+		}
+		if pageToken != "" {
+			q.Set("pageToken", pageToken)
+		}
+		u := fmt.Sprintf("%s/projects/%s/secrets?%s", c.apiEndpoint(), pid, q.Encode())
+
+		var page struct {
+			NextPageToken string           `json:"nextPageToken"`
+			Secrets       []secretResource `json:"secrets"`
+		}
+		if err := c.call(ctx, tok, "list secrets", http.MethodGet, u, nil, &page); err != nil {
+			return nil, err
+		}
+		for i := range page.Secrets {
+			secrets = append(secrets, page.Secrets[i].secret())
+		}
+
+		if page.NextPageToken == "" {
+			return secrets, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
@@ -0,0 +1,31 @@
+package gsm
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestList(t *testing.T) {
+	var gotFilter string
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		gotFilter = r.URL.Query().Get("filter")
+		_, _ = w.Write([]byte(`{"secrets":[{"name":"projects/test-project/secrets/db","labels":{"env":"prod"}}]}`)) //nolint:errcheck // test mock server
+	})
+
+	secrets, err := List(context.Background(), "labels.env=prod AND name:db")
+	if err != nil {
+		t.Fatalf("List() unexpected error = %v", err)
+	}
+	if gotFilter != "labels.env=prod AND name:db" {
+		t.Errorf("filter = %q, want it passed through unchanged", gotFilter)
+	}
+	if len(secrets) != 1 || secrets[0].Name != "db" || secrets[0].Labels["env"] != "prod" {
+		t.Errorf("List() = %+v, want secret db with env=prod", secrets)
+	}
+
+	if _, err := ListInProject(context.Background(), "Bad_Project", ""); err == nil || !strings.Contains(err.Error(), "invalid project ID format") {
+		t.Errorf("ListInProject() error = %v, want invalid project ID", err)
+	}
+}
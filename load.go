@@ -0,0 +1,253 @@
+package gsm
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Load fills the tagged fields of the struct dst points to with secrets from
+// the current project using the default client. See Client.Load.
+func Load(ctx context.Context, dst any) error {
+	return defaultClient.Load(ctx, dst)
+}
+
+// LoadFromProject fills the tagged fields of the struct dst points to with
+// secrets from a specific project using the default client.
+func LoadFromProject(ctx context.Context, pid string, dst any) error {
+	return defaultClient.LoadFromProject(ctx, pid, dst)
+}
+
+// Load fills the fields of the struct dst points to that carry a gsm tag with
+// the secrets they name, fetched concurrently with one access token:
+//
+//	type Config struct {
+//		DBPassword string `gsm:"db-password"`
+//		APIKey     string `gsm:"other-project/api-key,version=3"`
+//		SMTP       string `gsm:"smtp-password,optional"`
+//		Cache      struct {
+//			Token string `gsm:"cache-token"`
+//		}
+//	}
+//
+// A tag is a [PROJECT/]SECRET reference, as in a Spec, followed by options:
+// "optional" leaves the field unchanged if the secret does not exist, and
+// "version=N" reads version N instead of the latest. Untagged struct fields
+// are searched for tagged fields.
+//
+// Tagged fields must be exported, of a string, bool, integer, float,
+// time.Duration, url.URL, or *url.URL type, or a []byte holding a base64
+// value. Integers accept 0x and other Go prefixes, and surrounding whitespace
+// is ignored except by strings. No field is set unless every secret loads and
+// converts; the errors for every failed field are returned together, each
+// prefixed with the field path, e.g. "Cache.TTL".
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) Load(ctx context.Context, dst any) error {
+	return c.load(ctx, "", dst)
+}
+
+// LoadFromProject is Load with secrets read from a specific project unless
+// their tags name another.
+func (c *Client) LoadFromProject(ctx context.Context, pid string, dst any) error {
+	if !projectIDRegex.MatchString(pid) {
+		return fmt.Errorf("invalid project ID format: %q", pid)
+	}
+	return c.load(ctx, pid, dst)
+}
+
+// loadField is a struct field to fill, named by its path in spec.
+type loadField struct {
+	value reflect.Value
+	spec  EnvSpec
+}
+
+func (c *Client) load(ctx context.Context, pid string, dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("load requires a non-nil pointer to a struct, got %T", dst)
+	}
+	fields, err := loadFields(v.Elem(), "", nil)
+	if err != nil {
+		return err
+	}
+
+	s := &Spec{Project: pid, Env: make([]EnvSpec, len(fields))}
+	for i, f := range fields {
+		s.Env[i] = f.spec
+	}
+	values, err := c.resolveSpec(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	// Convert every value before setting any field.
+	converted := make([]reflect.Value, len(fields))
+	var errs []error
+	for i, f := range fields {
+		value, ok := values[f.spec.Name]
+		if !ok {
+			continue
+		}
+		if converted[i], err = convertLoadValue(f.value.Type(), value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.spec.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	for i, f := range fields {
+		if converted[i].IsValid() {
+			f.value.Set(converted[i])
+		}
+	}
+	return nil
+}
+
+var (
+	durationType = reflect.TypeFor[time.Duration]()
+	urlType      = reflect.TypeFor[url.URL]()
+)
+
+// loadable reports whether convertLoadValue supports t.
+func loadable(t reflect.Type) bool {
+	switch {
+	case t == durationType, t == urlType, t == reflect.PointerTo(urlType):
+		return true
+	case t.Kind() == reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// convertLoadValue converts a secret value to type t. Surrounding whitespace,
+// such as a trailing newline, is ignored except by strings.
+func convertLoadValue(t reflect.Type, value string) (reflect.Value, error) {
+	v := reflect.New(t).Elem()
+	trimmed := strings.TrimSpace(value)
+	switch {
+	case t == durationType:
+		d, err := time.ParseDuration(trimmed)
+		if err != nil {
+			return v, err
+		}
+		v.SetInt(int64(d))
+		return v, nil
+	case t == urlType, t == reflect.PointerTo(urlType):
+		u, err := url.Parse(trimmed)
+		if err != nil {
+			return v, err
+		}
+		if t == urlType {
+			return reflect.ValueOf(*u), nil
+		}
+		return reflect.ValueOf(u), nil
+	case t.Kind() == reflect.Slice:
+		b, err := base64.StdEncoding.DecodeString(trimmed)
+		if err != nil {
+			return v, fmt.Errorf("decoding base64: %w", err)
+		}
+		v.SetBytes(b)
+		return v, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(trimmed)
+		if err != nil {
+			return v, err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(trimmed, 0, t.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(trimmed, 0, t.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(trimmed, t.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetFloat(f)
+	default:
+		return v, fmt.Errorf("unsupported field type %s", t)
+	}
+	return v, nil
+}
+
+// loadFields appends the tagged fields of struct v, recursing into untagged
+// struct fields, with paths prefixed by prefix.
+func loadFields(v reflect.Value, prefix string, fields []loadField) ([]loadField, error) {
+	t := v.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		path := prefix + sf.Name
+		tag, tagged := sf.Tag.Lookup("gsm")
+		if !tagged {
+			if sf.Type.Kind() == reflect.Struct && sf.Type != urlType && sf.IsExported() {
+				var err error
+				if fields, err = loadFields(v.Field(i), path+".", fields); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		if tag == "-" {
+			continue
+		}
+		if !sf.IsExported() {
+			return nil, fmt.Errorf("%s: gsm tag on unexported field", path)
+		}
+		if !loadable(sf.Type) {
+			return nil, fmt.Errorf("%s: unsupported field type %s", path, sf.Type)
+		}
+		e, err := parseLoadTag(path, tag)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		fields = append(fields, loadField{value: v.Field(i), spec: e})
+	}
+	return fields, nil
+}
+
+// parseLoadTag parses a gsm struct tag into an EnvSpec named name.
+func parseLoadTag(name, tag string) (EnvSpec, error) {
+	ref, opts, _ := strings.Cut(tag, ",")
+	e := EnvSpec{Name: name}
+	for _, opt := range strings.Split(opts, ",") {
+		switch k, v, _ := strings.Cut(opt, "="); k {
+		case "":
+		case "optional":
+			e.HasDefault = true
+		case "version":
+			if v == "" {
+				return e, errors.New("empty version")
+			}
+			e.Version = v
+		default:
+			return e, fmt.Errorf("unknown tag option %q", opt)
+		}
+	}
+	return e, e.setRef(ref)
+}
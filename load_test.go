@@ -0,0 +1,170 @@
+package gsm
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+	values := map[string]string{
+		"/projects/test-project/secrets/db-password/versions/latest:access": "db",
+		"/projects/other-project/secrets/api-key/versions/3:access":         "key",
+		"/projects/test-project/secrets/cache-token/versions/latest:access": "cache",
+	}
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		v, ok := values[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writePayload(w, strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), ":access"), v)
+	})
+	ctx := context.Background()
+
+	type config struct {
+		DBPassword string `gsm:"db-password"`
+		APIKey     string `gsm:"other-project/api-key,version=3"`
+		SMTP       string `gsm:"smtp-password,optional"`
+		Ignored    string `gsm:"-"`
+		Plain      string
+		Cache      struct {
+			Token string `gsm:"cache-token"`
+		}
+	}
+	cfg := config{SMTP: "default"}
+	if err := Load(ctx, &cfg); err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if cfg.DBPassword != "db" || cfg.APIKey != "key" || cfg.SMTP != "default" || cfg.Cache.Token != "cache" {
+		t.Errorf("Load() = %+v", cfg)
+	}
+	if len(paths) != 4 {
+		t.Errorf("Load() made %d requests, want 4: %q", len(paths), paths)
+	}
+
+	var missing struct {
+		DB struct {
+			Password string `gsm:"missing"`
+		}
+		Kept string `gsm:"db-password"`
+	}
+	err := Load(ctx, &missing)
+	if err == nil || !strings.Contains(err.Error(), "DB.Password: ") {
+		t.Errorf("Load() error = %v, want one naming DB.Password", err)
+	}
+	if missing.Kept != "" {
+		t.Error("Load() set fields although another failed")
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	setupFakes(t, nil)
+	ctx := context.Background()
+	tests := []struct {
+		dst         any
+		name        string
+		errContains string
+	}{
+		{name: "not a pointer", dst: struct{}{}, errContains: "non-nil pointer to a struct"},
+		{name: "unsupported type", dst: &struct {
+			N []string `gsm:"n"`
+		}{}, errContains: "N: unsupported field type []string"},
+		{name: "unexported", dst: &struct {
+			n string `gsm:"n"`
+		}{}, errContains: "n: gsm tag on unexported field"},
+		{name: "bad option", dst: &struct {
+			N string `gsm:"n,required"`
+		}{}, errContains: `N: unknown tag option "required"`},
+		{name: "bad version", dst: &struct {
+			N string `gsm:"n,version=v1"`
+		}{}, errContains: `N: invalid version "v1"`},
+		{name: "bad name", dst: &struct {
+			N string `gsm:"n.m"`
+		}{}, errContains: "N: invalid secret name format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Load(ctx, tt.dst)
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("Load() error = %v, want error containing %q", err, tt.errContains)
+			}
+		})
+	}
+
+	if err := LoadFromProject(ctx, "BAD", &struct{}{}); err == nil {
+		t.Error("LoadFromProject() with an invalid project succeeded, want error")
+	}
+}
+
+func TestLoadConversions(t *testing.T) {
+	values := map[string]string{
+		"port":    "8080\n",
+		"mask":    "0xff",
+		"debug":   "true",
+		"ratio":   "0.25",
+		"timeout": "1m30s",
+		"key":     "c2VjcmV0",
+		"dsn":     "postgres://app@db/app",
+		"name":    " padded ",
+		"bad-int": "eighty",
+		"bad-dur": "5",
+	}
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		name := strings.Split(r.URL.Path, "/")[4]
+		writePayload(w, "projects/test-project/secrets/"+name+"/versions/1", values[name])
+	})
+	ctx := context.Background()
+
+	var cfg struct {
+		Port    int           `gsm:"port"`
+		Mask    uint8         `gsm:"mask"`
+		Debug   bool          `gsm:"debug"`
+		Ratio   float64       `gsm:"ratio"`
+		Timeout time.Duration `gsm:"timeout"`
+		Key     []byte        `gsm:"key"`
+		DSN     url.URL       `gsm:"dsn"`
+		DSNPtr  *url.URL      `gsm:"dsn"`
+		Name    string        `gsm:"name"`
+	}
+	if err := Load(ctx, &cfg); err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if cfg.Port != 8080 || cfg.Mask != 255 || !cfg.Debug || cfg.Ratio != 0.25 || cfg.Timeout != 90*time.Second ||
+		string(cfg.Key) != "secret" || cfg.DSN.Host != "db" || cfg.DSNPtr == nil || cfg.DSNPtr.User.Username() != "app" ||
+		cfg.Name != " padded " {
+		t.Errorf("Load() = %+v", cfg)
+	}
+
+	var bad struct {
+		Port    int `gsm:"port"`
+		Workers int `gsm:"bad-int"`
+		Cache   struct {
+			TTL time.Duration `gsm:"bad-dur"`
+		}
+		Small int8 `gsm:"mask"`
+	}
+	err := Load(ctx, &bad)
+	if err == nil {
+		t.Fatal("Load() succeeded, want conversion errors")
+	}
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "Workers: ") || !strings.HasPrefix(lines[1], "Cache.TTL: ") ||
+		!strings.HasPrefix(lines[2], "Small: ") {
+		t.Errorf("Load() error = %v, want one error per field in field order", err)
+	}
+	if bad.Port != 0 {
+		t.Error("Load() set fields although others failed to convert")
+	}
+}
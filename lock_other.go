@@ -0,0 +1,9 @@
+//go:build !unix
+
+package gsm
+
+// lockFile is a no-op where flock is unavailable; FileTokenCache still
+// replaces its file atomically, so concurrent writers can only lose an update.
+func lockFile(string, bool) (unlock func(), err error) {
+	return func() {}, nil
+}
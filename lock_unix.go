@@ -0,0 +1,29 @@
+//go:build unix
+
+package gsm
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an advisory flock on path, creating it with mode 0600 if needed.
+// Exclusive locks block writers and readers; shared locks only block writers.
+func lockFile(path string, exclusive bool) (unlock func(), err error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		f.Close() //nolint:errcheck,gosec // already failing
+		return nil, err
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN) //nolint:errcheck,gosec // closing releases the lock anyway
+		f.Close()                                   //nolint:errcheck,gosec // best effort close
+	}, nil
+}
@@ -0,0 +1,123 @@
+package gsm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"regexp"
+)
+
+// WithLogger sends the client's log output to logger instead of slog.Default,
+// e.g. one with a higher level to drop the Info line logged for each fetch. A
+// nil logger disables logging.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		if logger == nil {
+			logger = slog.New(discardHandler{})
+		}
+		c.logger = logger
+	}
+}
+
+// WithRedactedLogs replaces secret names and project IDs in the client's log
+// output, including those inside error messages and resource paths, with a
+// SHA-256 prefix such as "sha256:ab12cd34ef56". Equal names hash alike, so log
+// lines can still be correlated; names that are easy to guess can be recovered
+// by hashing candidates.
+func WithRedactedLogs() Option {
+	return func(c *Client) {
+		c.redactLogs = true
+	}
+}
+
+// log returns the client's logger, looking up slog.Default on each call so the
+// default client follows slog.SetDefault.
+func (c *Client) log() *slog.Logger {
+	l := c.logger
+	if l == nil {
+		l = slog.Default()
+	}
+	if c.redactLogs {
+		return slog.New(redactHandler{l.Handler()})
+	}
+	return l
+}
+
+// redactedKeys are the log attributes holding secret names or project IDs.
+var redactedKeys = map[string]bool{
+	"secret": true, "project": true, "project_id": true, "source": true, "destination": true,
+}
+
+// resourceRegex matches secret resource paths in URLs and API error messages.
+var resourceRegex = regexp.MustCompile(`projects/([^/\s"?]+)/secrets/([^/\s"?:]+)`)
+
+// redact returns the hashed form of a secret name or project ID.
+func redact(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return "sha256:" + hex.EncodeToString(sum[:6])
+}
+
+// redactText hashes the project IDs and secret names in resource paths within s.
+func redactText(s string) string {
+	return resourceRegex.ReplaceAllStringFunc(s, func(m string) string {
+		g := resourceRegex.FindStringSubmatch(m)
+		return "projects/" + redact(g[1]) + "/secrets/" + redact(g[2])
+	})
+}
+
+// redactHandler hashes secret names and project IDs before passing records on.
+type redactHandler struct {
+	slog.Handler
+}
+
+func (h redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, redactText(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.Handler.Handle(ctx, out)
+}
+
+func (h redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+	return redactHandler{h.Handler.WithAttrs(redacted)}
+}
+
+func (h redactHandler) WithGroup(name string) slog.Handler {
+	return redactHandler{h.Handler.WithGroup(name)}
+}
+
+func redactAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	switch {
+	case redactedKeys[a.Key]:
+		return slog.String(a.Key, redact(a.Value.String()))
+	case a.Value.Kind() == slog.KindGroup:
+		group := a.Value.Group()
+		redacted := make([]any, len(group))
+		for i, g := range group {
+			redacted[i] = redactAttr(g)
+		}
+		return slog.Group(a.Key, redacted...)
+	case a.Value.Kind() == slog.KindString:
+		return slog.String(a.Key, redactText(a.Value.String()))
+	}
+	// Errors often carry request URLs or API messages naming the secret.
+	if err, ok := a.Value.Any().(error); ok {
+		return slog.String(a.Key, redactText(err.Error()))
+	}
+	return a
+}
+
+// discardHandler drops all records.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
@@ -0,0 +1,324 @@
+package gsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Secret is metadata about a secret, without its payload.
+type Secret struct {
+	CreateTime       time.Time
+	ExpireTime       time.Time
+	NextRotationTime time.Time
+	Labels           map[string]string
+	Annotations      map[string]string
+	// Name is the short secret name, e.g. "db-password".
+	Name string
+	// Etag identifies this revision of the secret's metadata. Pass it to Update
+	// or Delete to fail with a ConflictError if the secret changed in between.
+	Etag           string
+	Topics         []string
+	RotationPeriod time.Duration
+}
+
+// SecretUpdate describes changes to a secret's metadata. Zero-valued fields are left unchanged.
+type SecretUpdate struct {
+	ExpireTime       time.Time
+	NextRotationTime time.Time
+	// Labels replaces all labels when non-nil.
+	Labels map[string]string
+	// Annotations replaces all annotations when non-nil.
+	Annotations map[string]string
+	// Etag, if set, makes the update conditional on the secret's current etag.
+	Etag string
+	// Topics replaces all notification topics when non-nil.
+	Topics         []string
+	TTL            time.Duration
+	RotationPeriod time.Duration
+}
+
+// ConflictError is returned when a conditional mutation's etag no longer
+// matches the resource, meaning someone else modified it concurrently.
+type ConflictError struct {
+	Err      error
+	Resource string
+	Etag     string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("etag %s no longer matches %s: modified concurrently", e.Etag, e.Resource)
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
+
+// secretResource is the Secret resource as encoded by the REST API.
+type secretResource struct {
+	CreateTime  time.Time         `json:"createTime"`
+	ExpireTime  time.Time         `json:"expireTime"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Rotation    *struct {
+		NextRotationTime time.Time `json:"nextRotationTime"`
+		RotationPeriod   string    `json:"rotationPeriod,omitempty"`
+	} `json:"rotation,omitempty"`
+	Name   string `json:"name"`
+	Etag   string `json:"etag,omitempty"`
+	Topics []struct {
+		Name string `json:"name"`
+	} `json:"topics,omitempty"`
+}
+
+func (r *secretResource) secret() *Secret {
+	s := &Secret{
+		CreateTime:  r.CreateTime,
+		ExpireTime:  r.ExpireTime,
+		Labels:      r.Labels,
+		Annotations: r.Annotations,
+		Name:        r.Name[strings.LastIndex(r.Name, "/")+1:],
+		Etag:        r.Etag,
+	}
+	for _, t := range r.Topics {
+		s.Topics = append(s.Topics, t.Name)
+	}
+	if r.Rotation != nil {
+		s.NextRotationTime = r.Rotation.NextRotationTime
+		s.RotationPeriod = parseDuration(r.Rotation.RotationPeriod)
+	}
+	return s
+}
+
+// Metadata returns metadata about a secret in the current project using the default client.
+func Metadata(ctx context.Context, name string) (*Secret, error) {
+	return defaultClient.Metadata(ctx, name)
+}
+
+// MetadataFromProject returns metadata about a secret in a specific project using the default client.
+func MetadataFromProject(ctx context.Context, pid, name string) (*Secret, error) {
+	return defaultClient.MetadataFromProject(ctx, pid, name)
+}
+
+// Update changes a secret's metadata in the current project using the default client.
+func Update(ctx context.Context, name string, u SecretUpdate) error {
+	return defaultClient.Update(ctx, name, u)
+}
+
+// UpdateInProject changes a secret's metadata in a specific project using the default client.
+func UpdateInProject(ctx context.Context, pid, name string, u SecretUpdate) error {
+	return defaultClient.UpdateInProject(ctx, pid, name, u)
+}
+
+// Delete deletes a secret and all its versions from the current project using the default client.
+func Delete(ctx context.Context, name, etag string) error {
+	return defaultClient.Delete(ctx, name, etag)
+}
+
+// DeleteFromProject deletes a secret and all its versions from a specific project using the default client.
+func DeleteFromProject(ctx context.Context, pid, name, etag string) error {
+	return defaultClient.DeleteFromProject(ctx, pid, name, etag)
+}
+
+// Metadata returns metadata about a secret in the current project, including its etag.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) Metadata(ctx context.Context, name string) (*Secret, error) {
+	if !secretNameRegex.MatchString(name) {
+		return nil, errors.New("invalid secret name format")
+	}
+
+	p, err := c.projectID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.MetadataFromProject(ctx, p, name)
+}
+
+// MetadataFromProject returns metadata about a secret in a specific project, including its etag.
+func (c *Client) MetadataFromProject(ctx context.Context, pid, name string) (*Secret, error) {
+	if !projectIDRegex.MatchString(pid) {
+		return nil, fmt.Errorf("invalid project ID format: %q", pid)
+	}
+	if !secretNameRegex.MatchString(name) {
+		return nil, errors.New("invalid secret name format")
+	}
+
+	tok, err := c.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var r secretResource
+	u := fmt.Sprintf("%s/projects/%s/secrets/%s", c.apiEndpoint(), pid, name)
+	if err := c.call(ctx, tok, "get secret", http.MethodGet, u, nil, &r); err != nil {
+		return nil, err
+	}
+	return r.secret(), nil
+}
+
+// Update changes a secret's metadata in the current project.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) Update(ctx context.Context, name string, u SecretUpdate) error {
+	if !secretNameRegex.MatchString(name) {
+		return errors.New("invalid secret name format")
+	}
+
+	p, err := c.projectID(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.UpdateInProject(ctx, p, name, u)
+}
+
+// UpdateInProject changes a secret's metadata in a specific project. If u.Etag is
+// set and the secret was modified since it was read, a *ConflictError is returned.
+func (c *Client) UpdateInProject(ctx context.Context, pid, name string, u SecretUpdate) error {
+	if !projectIDRegex.MatchString(pid) {
+		return fmt.Errorf("invalid project ID format: %q", pid)
+	}
+	if !secretNameRegex.MatchString(name) {
+		return errors.New("invalid secret name format")
+	}
+
+	body, mask := u.patch()
+	if len(mask) == 0 {
+		return errors.New("no changes in secret update")
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	tok, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	resource := fmt.Sprintf("projects/%s/secrets/%s", pid, name)
+	q := url.Values{"updateMask": {strings.Join(mask, ",")}}
+	err = c.call(ctx, tok, "update secret", http.MethodPatch, c.apiEndpoint()+"/"+resource+"?"+q.Encode(), data, nil)
+	return conflictError(err, resource, u.Etag)
+}
+
+// Delete deletes a secret and all its versions from the current project.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) Delete(ctx context.Context, name, etag string) error {
+	if !secretNameRegex.MatchString(name) {
+		return errors.New("invalid secret name format")
+	}
+
+	p, err := c.projectID(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.DeleteFromProject(ctx, p, name, etag)
+}
+
+// DeleteFromProject deletes a secret and all its versions from a specific project.
+// If etag is non-empty and the secret was modified since it was read, a
+// *ConflictError is returned and nothing is deleted.
+func (c *Client) DeleteFromProject(ctx context.Context, pid, name, etag string) error {
+	if !projectIDRegex.MatchString(pid) {
+		return fmt.Errorf("invalid project ID format: %q", pid)
+	}
+	if !secretNameRegex.MatchString(name) {
+		return errors.New("invalid secret name format")
+	}
+
+	tok, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	resource := fmt.Sprintf("projects/%s/secrets/%s", pid, name)
+	u := c.apiEndpoint() + "/" + resource
+	if etag != "" {
+		u += "?" + url.Values{"etag": {etag}}.Encode()
+	}
+	if err := c.call(ctx, tok, "delete secret", http.MethodDelete, u, nil, nil); err != nil {
+		return conflictError(err, resource, etag)
+	}
+
+	c.invalidateCached(pid, name)
+	return nil
+}
+
+// patch returns the PATCH body and update mask for u.
+func (u SecretUpdate) patch() (map[string]any, []string) {
+	body := map[string]any{}
+	var mask []string
+	if u.Etag != "" {
+		body["etag"] = u.Etag
+	}
+	if u.Labels != nil {
+		body["labels"] = u.Labels
+		mask = append(mask, "labels")
+	}
+	if u.Annotations != nil {
+		body["annotations"] = u.Annotations
+		mask = append(mask, "annotations")
+	}
+	if u.Topics != nil {
+		topics := make([]map[string]string, len(u.Topics))
+		for i, t := range u.Topics {
+			topics[i] = map[string]string{"name": t}
+		}
+		body["topics"] = topics
+		mask = append(mask, "topics")
+	}
+	if u.TTL > 0 {
+		body["ttl"] = formatDuration(u.TTL)
+		mask = append(mask, "ttl")
+	} else if !u.ExpireTime.IsZero() {
+		body["expireTime"] = u.ExpireTime.UTC().Format(time.RFC3339Nano)
+		mask = append(mask, "expire_time")
+	}
+	if !u.NextRotationTime.IsZero() || u.RotationPeriod > 0 {
+		rotation := map[string]string{}
+		if !u.NextRotationTime.IsZero() {
+			rotation["nextRotationTime"] = u.NextRotationTime.UTC().Format(time.RFC3339Nano)
+			mask = append(mask, "rotation.next_rotation_time")
+		}
+		if u.RotationPeriod > 0 {
+			rotation["rotationPeriod"] = formatDuration(u.RotationPeriod)
+			mask = append(mask, "rotation.rotation_period")
+		}
+		body["rotation"] = rotation
+	}
+	return body, mask
+}
+
+// conflictError converts etag mismatch responses into a *ConflictError.
+// Secret Manager reports them as ABORTED (409) or FAILED_PRECONDITION (400/412).
+func conflictError(err error, resource, etag string) error {
+	var ae *APIError
+	if etag == "" || !errors.As(err, &ae) {
+		return err
+	}
+	switch {
+	case ae.Status == "ABORTED",
+		ae.StatusCode == http.StatusConflict && ae.Status == "",
+		ae.StatusCode == http.StatusPreconditionFailed,
+		ae.Status == "FAILED_PRECONDITION" && strings.Contains(strings.ToLower(ae.Message), "etag"):
+		return &ConflictError{Err: err, Resource: resource, Etag: etag}
+	}
+	return err
+}
+
+// parseDuration decodes a protobuf JSON Duration such as "3600s", returning 0 if malformed.
+func parseDuration(s string) time.Duration {
+	secs, err := strconv.ParseFloat(strings.TrimSuffix(s, "s"), 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(secs * float64(time.Second))
+}
@@ -0,0 +1,145 @@
+package gsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMetadata(t *testing.T) {
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/projects/test-project/secrets/test-secret" || r.Method != http.MethodGet {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{
+			"name": "projects/123/secrets/test-secret",
+			"createTime": "2025-01-02T03:04:05Z",
+			"labels": {"env": "prod"},
+			"topics": [{"name": "projects/p/topics/t"}],
+			"rotation": {"nextRotationTime": "2025-02-01T00:00:00Z", "rotationPeriod": "86400s"},
+			"etag": "\"16f9f1a1\""
+		}`)) //nolint:errcheck // test mock server
+	})
+
+	s, err := Metadata(context.Background(), "test-secret")
+	if err != nil {
+		t.Fatalf("Metadata() unexpected error = %v", err)
+	}
+	want := &Secret{
+		Name:             "test-secret",
+		CreateTime:       time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		NextRotationTime: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		RotationPeriod:   24 * time.Hour,
+		Labels:           map[string]string{"env": "prod"},
+		Topics:           []string{"projects/p/topics/t"},
+		Etag:             `"16f9f1a1"`,
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("Metadata() = %+v, want %+v", s, want)
+	}
+}
+
+func TestUpdateWithEtag(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		wantConflict bool
+	}{
+		{name: "etag matches", status: http.StatusOK, body: `{"name":"projects/123/secrets/test-secret"}`},
+		{name: "etag mismatch", status: http.StatusConflict, body: `{"error":{"code":409,"status":"ABORTED"}}`, wantConflict: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotMask string
+			var gotBody map[string]any
+			setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPatch {
+					t.Errorf("method = %s, want PATCH", r.Method)
+				}
+				gotMask = r.URL.Query().Get("updateMask")
+				b, _ := io.ReadAll(r.Body)      //nolint:errcheck // decoded below
+				_ = json.Unmarshal(b, &gotBody) //nolint:errcheck // compared below
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body)) //nolint:errcheck // test mock server
+			})
+
+			err := UpdateInProject(context.Background(), "test-project", "test-secret", SecretUpdate{
+				Etag:   `"abc"`,
+				Labels: map[string]string{"env": "dev"},
+				TTL:    time.Hour,
+			})
+
+			if gotMask != "labels,ttl" {
+				t.Errorf("updateMask = %q, want labels,ttl", gotMask)
+			}
+			if gotBody["etag"] != `"abc"` || gotBody["ttl"] != "3600s" {
+				t.Errorf("body = %v, want etag and ttl", gotBody)
+			}
+
+			var ce *ConflictError
+			if got := errors.As(err, &ce); got != tt.wantConflict {
+				t.Fatalf("UpdateInProject() error = %v, want conflict %v", err, tt.wantConflict)
+			}
+			if tt.wantConflict && ce.Resource != "projects/test-project/secrets/test-secret" {
+				t.Errorf("ConflictError.Resource = %q", ce.Resource)
+			}
+			if !tt.wantConflict && err != nil {
+				t.Errorf("UpdateInProject() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestUpdateRequiresChanges(t *testing.T) {
+	if err := UpdateInProject(context.Background(), "test-project", "test-secret", SecretUpdate{Etag: "x"}); err == nil {
+		t.Error("UpdateInProject() with no changes expected error")
+	}
+}
+
+func TestDeleteWithEtag(t *testing.T) {
+	tests := []struct {
+		name         string
+		etag         string
+		status       int
+		wantConflict bool
+		wantErr      bool
+	}{
+		{name: "unconditional", status: http.StatusOK},
+		{name: "etag matches", etag: `"abc"`, status: http.StatusOK},
+		{name: "etag mismatch", etag: `"abc"`, status: http.StatusConflict, wantConflict: true, wantErr: true},
+		{name: "not found without etag", status: http.StatusNotFound, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotEtag string
+			setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodDelete {
+					t.Errorf("method = %s, want DELETE", r.Method)
+				}
+				gotEtag = r.URL.Query().Get("etag")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{}`)) //nolint:errcheck // test mock server
+			})
+
+			err := DeleteFromProject(context.Background(), "test-project", "test-secret", tt.etag)
+			if gotEtag != tt.etag {
+				t.Errorf("etag query = %q, want %q", gotEtag, tt.etag)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DeleteFromProject() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var ce *ConflictError
+			if errors.As(err, &ce) != tt.wantConflict {
+				t.Errorf("DeleteFromProject() error = %v, want conflict %v", err, tt.wantConflict)
+			}
+		})
+	}
+}
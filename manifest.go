@@ -0,0 +1,153 @@
+package gsm
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrManifestSignature is returned (wrapped) by Verify when a manifest's
+// signature does not match its contents under the client's manifest key.
+var ErrManifestSignature = errors.New("manifest signature mismatch")
+
+// ManifestEntry pins one secret to the version and SHA-256 checksum a deployment expects.
+type ManifestEntry struct {
+	Project string `json:"project"`
+	Secret  string `json:"secret"`
+	Version string `json:"version"`
+	SHA256  string `json:"sha256"`
+}
+
+// SecretManifest is a signed list of secret versions, produced by Manifest for
+// deploy pipelines to embed and checked at runtime by Verify.
+// It is designed to be serialized as JSON.
+type SecretManifest struct {
+	Entries   []ManifestEntry `json:"entries"`
+	Signature string          `json:"signature"`
+}
+
+// WithManifestKey sets the HMAC-SHA256 key used to sign manifests in Manifest
+// and to authenticate them in Verify.
+func WithManifestKey(key []byte) Option {
+	return func(c *Client) {
+		c.manifestKey = key
+	}
+}
+
+// Manifest records the latest version and checksum of each named secret in the
+// current project and signs the result with the client's manifest key.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) Manifest(ctx context.Context, names ...string) (*SecretManifest, error) {
+	for _, name := range names {
+		if !secretNameRegex.MatchString(name) {
+			return nil, errors.New("invalid secret name format")
+		}
+	}
+
+	p, err := c.projectID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.ManifestFromProject(ctx, p, names...)
+}
+
+// ManifestFromProject records the latest version and checksum of each named secret
+// in a specific project and signs the result with the client's manifest key.
+func (c *Client) ManifestFromProject(ctx context.Context, pid string, names ...string) (*SecretManifest, error) {
+	if !projectIDRegex.MatchString(pid) {
+		return nil, fmt.Errorf("invalid project ID format: %q", pid)
+	}
+	for _, name := range names {
+		if !secretNameRegex.MatchString(name) {
+			return nil, errors.New("invalid secret name format")
+		}
+	}
+	if len(c.manifestKey) == 0 {
+		return nil, errors.New("manifest key not configured: use WithManifestKey")
+	}
+
+	tok, err := c.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &SecretManifest{}
+	for _, name := range names {
+		value, version, err := c.accessVersion(ctx, tok, pid, name, "latest")
+		if err != nil {
+			return nil, fmt.Errorf("secret %s: %w", name, err)
+		}
+		m.Entries = append(m.Entries, ManifestEntry{
+			Project: pid,
+			Secret:  name,
+			Version: version,
+			SHA256:  sha256Hex(value),
+		})
+	}
+	slices.SortFunc(m.Entries, func(a, b ManifestEntry) int {
+		return strings.Compare(cacheKey(a.Project, a.Secret), cacheKey(b.Project, b.Secret))
+	})
+	m.Signature = c.signManifest(m.Entries)
+	return m, nil
+}
+
+// Verify authenticates m with the client's manifest key and confirms that the
+// latest version of every listed secret is exactly the version and content the
+// manifest expects. All mismatches are reported together.
+func (c *Client) Verify(ctx context.Context, m *SecretManifest) error {
+	if len(c.manifestKey) == 0 {
+		return errors.New("manifest key not configured: use WithManifestKey")
+	}
+	if !hmac.Equal([]byte(c.signManifest(m.Entries)), []byte(m.Signature)) {
+		return fmt.Errorf("failed to verify manifest: %w", ErrManifestSignature)
+	}
+
+	tok, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, e := range m.Entries {
+		if !projectIDRegex.MatchString(e.Project) || !secretNameRegex.MatchString(e.Secret) {
+			errs = append(errs, fmt.Errorf("invalid manifest entry %q", cacheKey(e.Project, e.Secret)))
+			continue
+		}
+		value, version, err := c.accessVersion(ctx, tok, e.Project, e.Secret, "latest")
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("secret %s: %w", cacheKey(e.Project, e.Secret), err))
+		case version != e.Version:
+			errs = append(errs, fmt.Errorf("secret %s: version %s, manifest expects %s", cacheKey(e.Project, e.Secret), version, e.Version))
+		case sha256Hex(value) != e.SHA256:
+			errs = append(errs, fmt.Errorf("secret %s version %s: %w", cacheKey(e.Project, e.Secret), version, ErrChecksumMismatch))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to verify manifest: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// signManifest returns the hex HMAC-SHA256 of a canonical encoding of entries.
+func (c *Client) signManifest(entries []ManifestEntry) string {
+	var b strings.Builder
+	for _, e := range entries {
+		fmt.Fprintf(&b, "%s\x00%s\x00%s\x00%s\n", e.Project, e.Secret, e.Version, e.SHA256)
+	}
+	mac := hmac.New(sha256.New, c.manifestKey)
+	mac.Write([]byte(b.String()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,62 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestManifestVerify(t *testing.T) {
+	versions := map[string]string{"db-password": "3", "api-key": "7"}
+	values := map[string]string{"db-password": "hunter2", "api-key": "abc"}
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		name := strings.Split(r.URL.Path, "/")[4]
+		writePayload(w, "projects/test-project/secrets/"+name+"/versions/"+versions[name], values[name])
+	})
+
+	ctx := context.Background()
+	c := New(WithManifestKey([]byte("deploy-key")))
+	m, err := c.Manifest(ctx, "db-password", "api-key")
+	if err != nil {
+		t.Fatalf("Manifest() unexpected error = %v", err)
+	}
+	if len(m.Entries) != 2 || m.Entries[0].Secret != "api-key" || m.Entries[1].Version != "3" {
+		t.Errorf("Manifest() entries = %+v, want api-key@7 and db-password@3", m.Entries)
+	}
+	if err := c.Verify(ctx, m); err != nil {
+		t.Errorf("Verify() unexpected error = %v", err)
+	}
+
+	t.Run("wrong key", func(t *testing.T) {
+		err := New(WithManifestKey([]byte("other-key"))).Verify(ctx, m)
+		if !errors.Is(err, ErrManifestSignature) {
+			t.Errorf("Verify() error = %v, want ErrManifestSignature", err)
+		}
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		tampered := *m
+		tampered.Entries = append([]ManifestEntry(nil), m.Entries...)
+		tampered.Entries[0].Version = "8"
+		if err := c.Verify(ctx, &tampered); !errors.Is(err, ErrManifestSignature) {
+			t.Errorf("Verify() error = %v, want ErrManifestSignature", err)
+		}
+	})
+
+	t.Run("rotated", func(t *testing.T) {
+		versions["db-password"] = "4"
+		values["api-key"] = "changed"
+		err := c.Verify(ctx, m)
+		if err == nil || !strings.Contains(err.Error(), "version 4, manifest expects 3") || !errors.Is(err, ErrChecksumMismatch) {
+			t.Errorf("Verify() error = %v, want version and checksum mismatches", err)
+		}
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		if _, err := New().Manifest(ctx, "db-password"); err == nil {
+			t.Error("Manifest() without key expected error")
+		}
+	})
+}
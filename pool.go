@@ -0,0 +1,112 @@
+package gsm
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// maxPooledBuffer is the largest buffer returned to bufferPool; bigger ones,
+// from unusually large payloads, are left to the garbage collector.
+const maxPooledBuffer = 64 * 1024
+
+// bufferPool recycles request body buffers across Store calls, so high-throughput
+// writers allocate roughly once per payload size rather than once per call.
+var bufferPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 1024)
+		return &b
+	},
+}
+
+func getBuffer() *[]byte {
+	return bufferPool.Get().(*[]byte) //nolint:errcheck,forcetypeassert // pool only holds *[]byte
+}
+
+// putBuffer zeroes b, which held secret material, and returns it to the pool.
+func putBuffer(b *[]byte) {
+	if cap(*b) > maxPooledBuffer {
+		return
+	}
+	clear((*b)[:cap(*b)])
+	*b = (*b)[:0]
+	bufferPool.Put(b)
+}
+
+// bodyLease shares a pooled buffer between the request bodies sent from it.
+// Transports may read a body after Do returns, e.g. HTTP/2 or middleware, so
+// the buffer returns to the pool only once its owner and every body have
+// released it. A body that is never closed leaves the buffer to the garbage
+// collector.
+type bodyLease struct {
+	buf  *[]byte
+	refs atomic.Int32
+}
+
+// newBodyLease leases buf, holding one reference for the caller.
+func newBodyLease(buf *[]byte) *bodyLease {
+	l := &bodyLease{buf: buf}
+	l.refs.Store(1)
+	return l
+}
+
+// release drops one reference, pooling the buffer when none remain.
+func (l *bodyLease) release() {
+	if l.refs.Add(-1) == 0 {
+		putBuffer(l.buf)
+	}
+}
+
+// setBody makes req send the leased buffer, including when the transport
+// rewinds the body with GetBody.
+func (l *bodyLease) setBody(req *http.Request) error {
+	body, err := l.body()
+	if err != nil {
+		return err
+	}
+	req.Body, req.ContentLength = body, int64(len(*l.buf))
+	req.GetBody = func() (io.ReadCloser, error) { return l.body() }
+	return nil
+}
+
+func (l *bodyLease) body() (io.ReadCloser, error) {
+	for {
+		n := l.refs.Load()
+		if n <= 0 {
+			return nil, errors.New("request body already released")
+		}
+		if l.refs.CompareAndSwap(n, n+1) {
+			return &leasedBody{Reader: bytes.NewReader(*l.buf), lease: l}, nil
+		}
+	}
+}
+
+// leasedBody reads a leased buffer and releases it on Close.
+type leasedBody struct {
+	*bytes.Reader
+	lease *bodyLease
+	once  sync.Once
+}
+
+func (b *leasedBody) Close() error {
+	b.once.Do(b.lease.release)
+	return nil
+}
+
+// appendVersionBody appends the JSON addVersion request for value to dst:
+// {"payload":{"data":"<base64>","dataCrc32c":"<decimal>"}}.
+// Both fields are safe to emit without escaping.
+func appendVersionBody(dst []byte, value string) []byte {
+	data := []byte(value)
+	dst = append(dst, `{"payload":{"data":"`...)
+	dst = base64.StdEncoding.AppendEncode(dst, data)
+	dst = append(dst, `","dataCrc32c":"`...)
+	dst = strconv.AppendUint(dst, uint64(crc32.Checksum(data, crc32cTable)), 10)
+	return append(dst, `"}}`...)
+}
@@ -0,0 +1,110 @@
+package gsm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAppendVersionBody(t *testing.T) {
+	for _, value := range []string{"", "secret-value", "multi\nline \"quoted\" \x00 ☃"} {
+		var req struct {
+			Payload struct {
+				Data       string `json:"data"`
+				DataCrc32c string `json:"dataCrc32c"`
+			} `json:"payload"`
+		}
+		body := appendVersionBody(nil, value)
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Fatalf("appendVersionBody(%q) = %s, invalid JSON: %v", value, body, err)
+		}
+		if got, _ := base64.StdEncoding.DecodeString(req.Payload.Data); string(got) != value { //nolint:errcheck // compared below
+			t.Errorf("appendVersionBody(%q) data decodes to %q", value, got)
+		}
+		if err := checkCRC32C([]byte(value), json.Number(req.Payload.DataCrc32c)); err != nil {
+			t.Errorf("appendVersionBody(%q) checksum: %v", value, err)
+		}
+	}
+}
+
+func TestPutBufferZeroes(t *testing.T) {
+	b := getBuffer()
+	*b = append(*b, "secret"...)
+	backing := (*b)[:cap(*b)]
+	putBuffer(b)
+	if len(*b) != 0 || strings.Contains(string(backing), "secret") {
+		t.Errorf("putBuffer() left secret material in pooled buffer")
+	}
+}
+
+func BenchmarkAppendVersionBody(b *testing.B) {
+	value := strings.Repeat("k", 256)
+	b.ReportAllocs()
+	for range b.N {
+		buf := getBuffer()
+		*buf = appendVersionBody(*buf, value)
+		putBuffer(buf)
+	}
+}
+
+func BenchmarkStore(b *testing.B) {
+	setupFakes(b, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)                                              //nolint:errcheck // test mock server
+		_, _ = w.Write([]byte(`{"name":"projects/test-project/secrets/s/versions/1"}`)) //nolint:errcheck // test mock server
+	})
+	old := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	b.Cleanup(func() { slog.SetDefault(old) })
+
+	ctx := context.Background()
+	value := strings.Repeat("k", 256)
+	b.ReportAllocs()
+	for range b.N {
+		if err := StoreInProject(ctx, "test-project", "s", value); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestBodyLease(t *testing.T) {
+	buf := getBuffer()
+	*buf = append(*buf, "secret"...)
+	backing := (*buf)[:cap(*buf)]
+	lease := newBodyLease(buf)
+
+	req, err := http.NewRequest(http.MethodPost, "http://example.com", http.NoBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lease.setBody(req); err != nil {
+		t.Fatal(err)
+	}
+	rewound, err := req.GetBody()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The owner is done, as when Do returns, but the transport still reads.
+	lease.release()
+	req.Body.Close() //nolint:errcheck,gosec // always nil
+	req.Body.Close() //nolint:errcheck,gosec // a second close must not release twice
+	if got, err := io.ReadAll(rewound); err != nil || string(got) != "secret" {
+		t.Errorf("read after release = %q, %v, want secret", got, err)
+	}
+	if !strings.Contains(string(backing), "secret") {
+		t.Error("buffer pooled while a body was still open")
+	}
+
+	rewound.Close() //nolint:errcheck,gosec // always nil
+	if strings.Contains(string(backing), "secret") {
+		t.Error("buffer not zeroed after every body closed")
+	}
+	if _, err := req.GetBody(); err == nil {
+		t.Error("GetBody() after release succeeded, want error")
+	}
+}
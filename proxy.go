@@ -0,0 +1,38 @@
+package gsm
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// WithProxy sends requests through the proxy chosen by proxy instead of the one
+// named by $HTTPS_PROXY, $HTTP_PROXY, and $NO_PROXY, e.g. http.ProxyURL(u) for a
+// fixed proxy, or nil to connect directly. The metadata server is always
+// reached directly. It configures a copy of the HTTP client's transport, like
+// WithTLSConfig.
+func WithProxy(proxy func(*http.Request) (*url.URL, error)) Option {
+	return func(c *Client) {
+		c.proxy = bypassMetadata(proxy)
+		c.proxySet = true
+	}
+}
+
+// proxyFromEnvironment is the package HTTP client's proxy: the one named by the
+// standard environment variables, except for the metadata server.
+var proxyFromEnvironment = bypassMetadata(http.ProxyFromEnvironment)
+
+// bypassMetadata returns proxy, or nil if proxy is nil, except that the
+// metadata server is reached directly: it is link-local, and a proxy would
+// see its access tokens.
+func bypassMetadata(proxy func(*http.Request) (*url.URL, error)) func(*http.Request) (*url.URL, error) {
+	if proxy == nil {
+		return nil
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		switch req.URL.Hostname() {
+		case "metadata.google.internal", "169.254.169.254":
+			return nil, nil //nolint:nilnil // nil URL means no proxy
+		}
+		return proxy(req)
+	}
+}
@@ -0,0 +1,50 @@
+package gsm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestWithProxy(t *testing.T) {
+	setupFakes(t, nil)
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		writePayload(w, "projects/test-project/secrets/s/versions/1", "value")
+	}))
+	t.Cleanup(proxy.Close)
+	proxyURL, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the API goes through the proxy; the fake metadata server is local.
+	c := New(WithEndpoint("http://secretmanager.example/v1"), WithProxy(func(req *http.Request) (*url.URL, error) {
+		if req.URL.Host == "secretmanager.example" {
+			return proxyURL, nil
+		}
+		return nil, nil //nolint:nilnil // direct
+	}))
+	v, err := c.FetchFromProject(context.Background(), "test-project", "s")
+	if err != nil || v != "value" {
+		t.Fatalf("FetchFromProject() = %q, %v, want value", v, err)
+	}
+	if want := "http://secretmanager.example/v1/projects/test-project/secrets/s/versions/latest:access"; proxied != want {
+		t.Errorf("proxy received %q, want %q", proxied, want)
+	}
+
+	always := bypassMetadata(http.ProxyURL(proxyURL))
+	for _, target := range []string{"http://metadata.google.internal/computeMetadata/v1/project/project-id", "http://169.254.169.254/"} {
+		req := httptest.NewRequest(http.MethodGet, target, http.NoBody)
+		if u, err := always(req); u != nil || err != nil {
+			t.Errorf("proxy for %s = %v, %v, want direct", target, u, err)
+		}
+	}
+	req := httptest.NewRequest(http.MethodGet, apiURL, http.NoBody)
+	if u, _ := always(req); u == nil || u.Host != proxyURL.Host { //nolint:errcheck // http.ProxyURL never fails
+		t.Errorf("proxy for %s = %v, want %v", apiURL, u, proxyURL)
+	}
+}
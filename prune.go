@@ -0,0 +1,142 @@
+package gsm
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+)
+
+// PruneVersions destroys old versions of a secret in the current project using the
+// default client, keeping the keep most recent enabled versions.
+// The project ID is auto-detected from the GCP metadata server.
+func PruneVersions(ctx context.Context, name string, keep int) ([]string, error) {
+	return defaultClient.PruneVersions(ctx, name, keep)
+}
+
+// PruneVersionsInProject destroys old versions of a secret in a specific project
+// using the default client, keeping the keep most recent enabled versions.
+func PruneVersionsInProject(ctx context.Context, pid, name string, keep int) ([]string, error) {
+	return defaultClient.PruneVersionsInProject(ctx, pid, name, keep)
+}
+
+// PruneVersions destroys old versions of a secret in the current project,
+// keeping the keep most recent enabled versions.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) PruneVersions(ctx context.Context, name string, keep int) ([]string, error) {
+	if !secretNameRegex.MatchString(name) {
+		return nil, errors.New("invalid secret name format")
+	}
+
+	p, err := c.projectID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.PruneVersionsInProject(ctx, p, name, keep)
+}
+
+// PruneVersionsInProject destroys every version of a secret older than its keep
+// most recent enabled versions, so frequently rotated secrets don't accumulate
+// stale versions. Disabled versions newer than those kept are left alone.
+// Destruction is irreversible, so keep must be at least 1. It returns the IDs of
+// the destroyed versions, newest first; if some could not be destroyed, their
+// errors are returned together with the IDs that were.
+func (c *Client) PruneVersionsInProject(ctx context.Context, pid, name string, keep int) ([]string, error) {
+	if !projectIDRegex.MatchString(pid) {
+		return nil, fmt.Errorf("invalid project ID format: %q", pid)
+	}
+	if !secretNameRegex.MatchString(name) {
+		return nil, errors.New("invalid secret name format")
+	}
+	if keep < 1 {
+		return nil, fmt.Errorf("keep must be at least 1, got %d", keep)
+	}
+
+	tok, err := c.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := c.listVersions(ctx, tok, pid, name, "")
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(versions, func(a, b secretVersion) int { return cmp.Compare(versionNumber(b), versionNumber(a)) })
+
+	var (
+		destroyed []string
+		errs      []error
+		enabled   int
+	)
+	for _, v := range versions {
+		if enabled < keep {
+			if v.State == "ENABLED" {
+				enabled++
+			}
+			continue
+		}
+		if v.State == "DESTROYED" {
+			continue
+		}
+
+		id := versionID(v.Name)
+		body, err := json.Marshal(map[string]string{"etag": v.Etag})
+		if err != nil {
+			return destroyed, err
+		}
+		u := fmt.Sprintf("%s/projects/%s/secrets/%s/versions/%s:destroy", c.apiEndpoint(), pid, name, id)
+		if err := c.call(ctx, tok, "destroy secret version", http.MethodPost, u, body, nil); err != nil {
+			errs = append(errs, fmt.Errorf("version %s: %w", id, err))
+			continue
+		}
+		c.log().Info("destroyed secret version", "secret", name, "version", id)
+		destroyed = append(destroyed, id)
+	}
+
+	return destroyed, errors.Join(errs...)
+}
+
+// listVersions returns every version of a secret matching filter, which may be empty.
+func (c *Client) listVersions(ctx context.Context, tok, pid, name, filter string) ([]secretVersion, error) {
+	var versions []secretVersion
+	pageToken := ""
+	for {
+		q := url.Values{}
+		if filter != "" {
+			q.Set("filter", filter)
+		}
+		if pageToken != "" {
+			q.Set("pageToken", pageToken)
+		}
+		u := fmt.Sprintf("%s/projects/%s/secrets/%s/versions?%s", c.apiEndpoint(), pid, name, q.Encode())
+
+		var page struct {
+			NextPageToken string          `json:"nextPageToken"`
+			Versions      []secretVersion `json:"versions"`
+		}
+		if err := c.call(ctx, tok, "list secret versions", http.MethodGet, u, nil, &page); err != nil {
+			return nil, err
+		}
+		versions = append(versions, page.Versions...)
+
+		if page.NextPageToken == "" {
+			return versions, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// versionNumber returns the numeric ID of v, or 0 if it is not numeric.
+func versionNumber(v secretVersion) int {
+	n, err := strconv.Atoi(versionID(v.Name))
+	if err != nil {
+		return 0
+	}
+	return n
+}
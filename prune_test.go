@@ -0,0 +1,73 @@
+package gsm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPruneVersions(t *testing.T) {
+	const base = "projects/test-project/secrets/s/versions/"
+	var (
+		mu        sync.Mutex
+		destroyed []string
+		etags     []string
+	)
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/versions"):
+			if r.URL.Query().Get("pageToken") == "" {
+				_ = json.NewEncoder(w).Encode(map[string]any{ //nolint:errcheck // test mock server
+					"nextPageToken": "p2",
+					"versions": []secretVersion{
+						{Name: base + "7", State: "ENABLED", Etag: `"e7"`},
+						{Name: base + "6", State: "DISABLED", Etag: `"e6"`},
+						{Name: base + "5", State: "ENABLED", Etag: `"e5"`},
+					},
+				})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{ //nolint:errcheck // test mock server
+				"versions": []secretVersion{
+					{Name: base + "1", State: "ENABLED", Etag: `"e1"`},
+					{Name: base + "3", State: "DISABLED", Etag: `"e3"`},
+					{Name: base + "2", State: "DESTROYED", Etag: `"e2"`},
+					{Name: base + "4", State: "ENABLED", Etag: `"e4"`},
+				},
+			})
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, ":destroy"):
+			var body struct {
+				Etag string `json:"etag"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body) //nolint:errcheck // test mock server
+			mu.Lock()
+			destroyed = append(destroyed, strings.TrimSuffix(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:], ":destroy"))
+			etags = append(etags, body.Etag)
+			mu.Unlock()
+			_, _ = w.Write([]byte(`{}`)) //nolint:errcheck // test mock server
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	ctx := context.Background()
+	got, err := PruneVersions(ctx, "s", 2)
+	if err != nil {
+		t.Fatalf("PruneVersions() unexpected error = %v", err)
+	}
+	want := []string{"4", "3", "1"}
+	if !reflect.DeepEqual(got, want) || !reflect.DeepEqual(destroyed, want) {
+		t.Errorf("PruneVersions() = %v, destroyed %v, want %v", got, destroyed, want)
+	}
+	if wantEtags := []string{`"e4"`, `"e3"`, `"e1"`}; !reflect.DeepEqual(etags, wantEtags) {
+		t.Errorf("destroy etags = %v, want %v", etags, wantEtags)
+	}
+
+	if _, err := PruneVersionsInProject(ctx, "test-project", "s", 0); err == nil {
+		t.Error("PruneVersionsInProject() with keep 0 succeeded, want error")
+	}
+}
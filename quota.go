@@ -0,0 +1,192 @@
+package gsm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultWriteBudget is the default pace of batch stores, in write requests per
+// minute. Secret Manager allows 600 writes per minute per project; the default
+// leaves headroom for other writers in the same project.
+const defaultWriteBudget = 500
+
+// WithWriteBudget sets how many write requests per minute StoreMany and Import
+// may send. Each secret costs up to two writes (creating the secret and adding
+// the version). Batches within a few seconds of budget run at full concurrency;
+// larger ones are spread out so they finish without hitting quota errors. Zero
+// means 500; a negative budget disables pacing. Single stores are never paced.
+func WithWriteBudget(perMinute int) StoreOption {
+	return func(o *storeOptions) {
+		o.writeBudget = perMinute
+	}
+}
+
+// WithCheckpoint records each secret StoreMany or Import stores successfully in
+// the file at path, and skips secrets already recorded there with the same value,
+// so an interrupted migration can be re-run and resume where it stopped. The
+// file holds secret names and HMAC-SHA256 digests of their values, never the
+// values themselves, and is removed once every secret in the batch has been
+// stored. The digests are keyed with a random key kept in the same file, so
+// they cannot be matched across checkpoints, but anyone who can read the file
+// can still confirm a guessed value: treat it as sensitive. It is created with
+// mode 0600.
+func WithCheckpoint(path string) StoreOption {
+	return func(o *storeOptions) {
+		o.checkpoint = path
+	}
+}
+
+// writePacer spreads write requests evenly over time, allowing a burst of up to
+// burst requests.
+type writePacer struct {
+	next     time.Time
+	mu       sync.Mutex
+	interval time.Duration
+	burst    time.Duration
+}
+
+// newWritePacer returns a pacer for perMinute writes, or nil if pacing is disabled.
+func newWritePacer(perMinute int) *writePacer {
+	if perMinute < 0 {
+		return nil
+	}
+	if perMinute == 0 {
+		perMinute = defaultWriteBudget
+	}
+	interval := time.Minute / time.Duration(perMinute)
+	// Allow a tenth of the budget at once so small batches aren't slowed down.
+	return &writePacer{interval: interval, burst: interval * time.Duration(max(perMinute/10, 1))}
+}
+
+// wait blocks until n more writes fit in the budget, or ctx is done.
+func (p *writePacer) wait(ctx context.Context, n int) error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	now := time.Now()
+	if earliest := now.Add(-p.burst); p.next.Before(earliest) {
+		p.next = earliest
+	}
+	p.next = p.next.Add(p.interval * time.Duration(n))
+	d := p.next.Sub(now)
+	p.mu.Unlock()
+
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// checkpoint is the record of a batch store kept by WithCheckpoint.
+//
+// The first line of the file is "key HEX", the random HMAC key; each following
+// line is "NAME HEX", the HMAC-SHA256 of a stored value under that key.
+type checkpoint struct {
+	done map[string]string
+	f    *os.File
+	log  *slog.Logger
+	key  []byte
+	mu   sync.Mutex
+	path string
+}
+
+// openCheckpoint loads the checkpoint at path, creating it if it does not exist.
+// Failures to update it are logged to log.
+func openCheckpoint(path string, log *slog.Logger) (*checkpoint, error) {
+	cp := &checkpoint{path: path, done: map[string]string{}, log: log}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	if sc.Scan() {
+		if k, ok := strings.CutPrefix(sc.Text(), "key "); ok {
+			if key, err := hex.DecodeString(k); err == nil && len(key) == sha256.Size {
+				cp.key = key
+			}
+		}
+	}
+	for cp.key != nil && sc.Scan() {
+		// A line cut short by a crash is ignored; its secret is simply stored again.
+		name, sum, ok := strings.Cut(sc.Text(), " ")
+		if ok && len(sum) == 2*sha256.Size {
+			cp.done[name] = sum
+		}
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if cp.key == nil {
+		// A new checkpoint, or one without a usable key: start afresh.
+		cp.key = make([]byte, sha256.Size)
+		if _, err := rand.Read(cp.key); err != nil {
+			return nil, err
+		}
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	cp.f = f
+	if flags&os.O_TRUNC != 0 {
+		if _, err := fmt.Fprintf(f, "key %x\n", cp.key); err != nil {
+			f.Close() //nolint:errcheck,gosec // already failing
+			return nil, err
+		}
+	}
+	return cp, nil
+}
+
+// sum returns the keyed digest of value recorded in the checkpoint.
+func (cp *checkpoint) sum(value string) string {
+	h := hmac.New(sha256.New, cp.key)
+	h.Write([]byte(value)) //nolint:errcheck,gosec // hash writes never fail
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// stored reports whether name was recorded with value.
+func (cp *checkpoint) stored(name, value string) bool {
+	sum, ok := cp.done[name]
+	return ok && hmac.Equal([]byte(sum), []byte(cp.sum(value)))
+}
+
+// record notes that name was stored with value.
+func (cp *checkpoint) record(name, value string) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	if _, err := cp.f.WriteString(name + " " + cp.sum(value) + "\n"); err != nil {
+		cp.log.Warn("failed to write checkpoint", "path", cp.path, "error", err)
+	}
+}
+
+// close closes the checkpoint, removing it if the batch is complete.
+func (cp *checkpoint) close(complete bool) {
+	cp.f.Close() //nolint:errcheck,gosec // best effort close
+	if !complete {
+		return
+	}
+	if err := os.Remove(cp.path); err != nil {
+		cp.log.Warn("failed to remove checkpoint", "path", cp.path, "error", err)
+	}
+}
@@ -0,0 +1,140 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWritePacer(t *testing.T) {
+	if newWritePacer(-1) != nil {
+		t.Error("newWritePacer(-1) != nil, want pacing disabled")
+	}
+	if p := newWritePacer(0); p.interval != time.Minute/defaultWriteBudget {
+		t.Errorf("default interval = %v, want %v", p.interval, time.Minute/defaultWriteBudget)
+	}
+
+	// 6000 writes per minute: 10ms apart, with a burst of 600 writes.
+	p := newWritePacer(6000)
+	ctx := context.Background()
+	start := time.Now()
+	if err := p.wait(ctx, 600); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 5*time.Millisecond {
+		t.Errorf("burst waited %v, want no wait", d)
+	}
+	if err := p.wait(ctx, 5); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 40*time.Millisecond {
+		t.Errorf("paced writes waited %v, want about 50ms", d)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := p.wait(ctx, 100); !errors.Is(err, context.Canceled) {
+		t.Errorf("wait() with canceled context = %v, want context.Canceled", err)
+	}
+}
+
+func TestStoreManyCheckpoint(t *testing.T) {
+	fake := &fakeProjects{values: map[string]string{}}
+	var failing atomic.Bool
+	failing.Store(true)
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() && strings.Contains(r.URL.Path, "/secrets/b:addVersion") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fake.ServeHTTP(w, r)
+	})
+
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	ctx := context.Background()
+	values := map[string]string{"a": "1", "b": "2"}
+
+	results, err := StoreMany(ctx, values, WithCheckpoint(path), WithWriteBudget(-1))
+	if err != nil || results["a"] != nil || results["b"] == nil {
+		t.Fatalf("StoreMany() = %v, %v, want a stored and b failed", results, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), "\na ") || strings.Contains(string(data), "\nb ") {
+		t.Fatalf("checkpoint = %q, %v, want only a recorded", data, err)
+	}
+	if strings.Contains(string(data), " 1\n") {
+		t.Errorf("checkpoint = %q, contains a value", data)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("checkpoint mode = %v, %v, want 0600", fi.Mode().Perm(), err)
+	}
+
+	// Re-running skips a; changing a's value would store it again.
+	failing.Store(false)
+	fake.values["test-project/a"] = "changed elsewhere"
+	results, err = StoreMany(ctx, values, WithCheckpoint(path))
+	if err != nil || results["a"] != nil || results["b"] != nil {
+		t.Fatalf("resumed StoreMany() = %v, %v", results, err)
+	}
+	if fake.values["test-project/a"] != "changed elsewhere" || fake.values["test-project/b"] != "2" {
+		t.Errorf("resumed values = %v, want only b stored", fake.values)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("checkpoint not removed after a complete batch: %v", err)
+	}
+}
+
+func TestOpenCheckpointTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	cp, err := openCheckpoint(path, slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp.record("a", "1")
+	cp.record("b", "2")
+	cp.close(false)
+
+	// Cut the last entry short, as a crash mid-write would.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data[:len(data)-10], 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cp, err = openCheckpoint(path, slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cp.close(false)
+	if !cp.stored("a", "1") || cp.stored("a", "2") || cp.stored("b", "2") {
+		t.Errorf("checkpoint entries = %v, want only a", cp.done)
+	}
+}
+
+func TestOpenCheckpointWithoutKey(t *testing.T) {
+	// Entries without a key line, such as unkeyed checksums, are discarded.
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	if err := os.WriteFile(path, []byte("a 90f599e3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cp, err := openCheckpoint(path, slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp.close(false)
+	if cp.stored("a", "1") {
+		t.Error("stored(a) = true for an entry without a key")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.HasPrefix(string(data), "key ") || strings.Contains(string(data), "a ") {
+		t.Errorf("checkpoint = %q, %v, want only a new key", data, err)
+	}
+}
@@ -0,0 +1,400 @@
+package gsm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how transient failures talking to one subsystem are retried.
+// Server errors, network failures, request timeouts (408), and rate limiting
+// (429) are retried. When a response asks for a delay with Retry-After or
+// RetryInfo, it replaces Delay; otherwise 408s and 429s back off exponentially.
+// The metadata server is local and usually fails fast, so it suits many quick
+// attempts; the Secret Manager API suits fewer, slower ones.
+type RetryPolicy struct {
+	// Attempts is the total number of attempts, including the first. Zero means 3.
+	Attempts int
+	// Delay is the wait before the first retry. Zero means one second.
+	Delay time.Duration
+	// MaxDelay enables exponential backoff: each retry waits twice as long as the
+	// previous one, up to MaxDelay. Zero means every retry waits Delay.
+	MaxDelay time.Duration
+	// Budget caps the time one operation spends across all its attempts and
+	// waits: no retry starts after Budget has elapsed since the first attempt,
+	// or waits past it. An attempt in flight is bounded by the HTTP client's
+	// timeout rather than the budget; bound whole calls with ctx or WithTimeout.
+	// Zero means no cap beyond Attempts.
+	Budget time.Duration
+	// AttemptTimeout bounds each attempt, including reading the response, so a
+	// hung connection fails that attempt and the next one starts promptly while
+	// ctx still bounds the operation as a whole. Zero means attempts are bounded
+	// only by ctx and the HTTP client's timeout.
+	AttemptTimeout time.Duration
+}
+
+// WithMetadataRetry sets how metadata server requests (project ID, access tokens,
+// instance identity) are retried.
+func WithMetadataRetry(p RetryPolicy) Option {
+	return func(c *Client) {
+		c.metadataRetry = p
+	}
+}
+
+// WithAPIRetry sets how Secret Manager API requests are retried.
+func WithAPIRetry(p RetryPolicy) Option {
+	return func(c *Client) {
+		c.apiRetry = p
+	}
+}
+
+// RetryEvent describes a retry about to be made; see WithRetryHook.
+type RetryEvent struct {
+	// Err is the failure being retried.
+	Err error
+	// Op describes the operation, e.g. "access secret".
+	Op string
+	// Attempt is the number of the upcoming attempt, counting the first as 1.
+	Attempt int
+	// Delay is the wait before the attempt.
+	Delay time.Duration
+}
+
+// RetryFunc receives retry events. It is called synchronously before each
+// wait, so slow handlers delay the retry.
+type RetryFunc func(ctx context.Context, ev RetryEvent)
+
+// WithRetryHook registers fn to be called before each retry of a metadata
+// server, token endpoint, or Secret Manager request, so applications can emit
+// their own metrics and alerts.
+func WithRetryHook(fn RetryFunc) Option {
+	return func(c *Client) {
+		c.retryHook = fn
+	}
+}
+
+// attempts returns the total number of attempts to make.
+func (p RetryPolicy) attempts() int {
+	if p.Attempts <= 0 {
+		return maxRetries
+	}
+	return p.Attempts
+}
+
+// delay returns the wait before the given attempt, counting the first attempt as 0.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.Delay
+	if d <= 0 {
+		d = retryDelay
+	}
+	if p.MaxDelay <= 0 {
+		return d
+	}
+	for i := 1; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxDelay)
+}
+
+// sleep waits d before the next attempt. It returns early if ctx is done,
+// and immediately if ctx's deadline would expire before another attempt could start,
+// rather than burning the caller's remaining time on a pointless wait.
+func sleep(ctx context.Context, d time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); remaining <= d {
+			return fmt.Errorf("retry delay %v exceeds remaining deadline %v: %w",
+				d, remaining.Round(time.Millisecond), context.DeadlineExceeded)
+		}
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// FailureClass is a coarse cause of a failed operation; see RetryReport.Class.
+type FailureClass string
+
+// Failure classes.
+const (
+	FailureQuota    FailureClass = "quota"    // rate limited (429)
+	FailureAuth     FailureClass = "auth"     // unauthenticated or permission denied (401, 403)
+	FailureClient   FailureClass = "client"   // another client error, e.g. not found (4xx)
+	FailureServer   FailureClass = "server"   // server-side error (5xx)
+	FailureNetwork  FailureClass = "network"  // no response: DNS, connection, or TLS failure
+	FailureCanceled FailureClass = "canceled" // the context was canceled or its deadline passed
+)
+
+// RetryAttempt is one HTTP attempt of an operation.
+type RetryAttempt struct {
+	// Time is when the attempt was sent.
+	Time time.Time `json:"time"`
+	// Delay is the wait before the attempt; zero for the first.
+	Delay time.Duration `json:"delay"`
+	// Status is the HTTP status of the response, or 0 if none was received.
+	Status int `json:"status,omitempty"`
+	// Error describes a failure to get a response.
+	Error string `json:"error,omitempty"`
+}
+
+// RetryReport is the attempt history of an operation that ultimately failed,
+// attached to the errors returned for metadata server, Secret Manager, and
+// token endpoint requests. It is transparent: Error returns the underlying
+// error's message, and errors.Is and errors.As see through it. Retrieve it
+// with errors.As:
+//
+//	var r *gsm.RetryReport
+//	if errors.As(err, &r) && r.Class() == gsm.FailureQuota { ... }
+type RetryReport struct {
+	// Err is the error the operation failed with.
+	Err error `json:"-"`
+	// Op describes the operation, e.g. "access secret".
+	Op       string         `json:"op"`
+	Attempts []RetryAttempt `json:"attempts"`
+}
+
+func (r *RetryReport) Error() string {
+	return r.Err.Error()
+}
+
+func (r *RetryReport) Unwrap() error {
+	return r.Err
+}
+
+// Class classifies the failure by its last attempt, so incident tooling can
+// tell quota exhaustion from credential, network, or server-side problems.
+func (r *RetryReport) Class() FailureClass {
+	if errors.Is(r.Err, context.Canceled) || errors.Is(r.Err, context.DeadlineExceeded) {
+		return FailureCanceled
+	}
+	if len(r.Attempts) == 0 {
+		return FailureNetwork
+	}
+	switch s := r.Attempts[len(r.Attempts)-1].Status; {
+	case s == http.StatusTooManyRequests:
+		return FailureQuota
+	case s == http.StatusUnauthorized || s == http.StatusForbidden:
+		return FailureAuth
+	case s >= 500:
+		return FailureServer
+	case s >= 400:
+		return FailureClient
+	default:
+		return FailureNetwork
+	}
+}
+
+// MarshalJSON encodes the report with its class and error message.
+func (r *RetryReport) MarshalJSON() ([]byte, error) {
+	type report RetryReport
+	return json.Marshal(struct {
+		*report
+		Class FailureClass `json:"class"`
+		Error string       `json:"error"`
+	}{(*report)(r), r.Class(), r.Error()})
+}
+
+// IsRetryable reports whether err is a failure this package retries: a network
+// error, a server error, request timeout (408), or rate limiting (429)
+// response, or a corrupted payload. It stays true once the retries have run
+// out, so wrappers with their own retry loops make the same call. It is false
+// for nil, canceled contexts, client errors such as ErrNotFound and
+// ErrPermissionDenied, invalid arguments, ErrNoCredentials, and ErrCircuitOpen.
+func IsRetryable(err error) bool {
+	switch {
+	case err == nil, errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, ErrCircuitOpen), errors.Is(err, ErrNoCredentials):
+		return false
+	case errors.Is(err, ErrChecksumMismatch):
+		return true
+	}
+	var ae *APIError
+	if errors.As(err, &ae) {
+		return false
+	}
+	var r *RetryReport
+	if errors.As(err, &r) {
+		c := r.Class()
+		return c == FailureNetwork || c == FailureServer || c == FailureQuota
+	}
+	var ne net.Error
+	return errors.As(err, &ne)
+}
+
+// IsTransient reports whether err stems from a condition that may clear without
+// intervention, such as an outage, throttling, or network trouble: any
+// IsRetryable error, and ErrCircuitOpen. Unlike IsRetryable errors, circuit
+// breaker refusals call for retrying later rather than at once.
+func IsTransient(err error) bool {
+	return IsRetryable(err) || errors.Is(err, ErrCircuitOpen)
+}
+
+// ErrRetryBudget is returned (wrapped) when an operation stops retrying because
+// its RetryPolicy.Budget is spent.
+var ErrRetryBudget = errors.New("retry budget exhausted")
+
+// maxRetryAfter caps the retry delay a server may request.
+const maxRetryAfter = 2 * time.Minute
+
+// clientError reports whether status is a client error that retrying won't fix.
+// Request timeouts (408) and rate limiting (429) are retried, with backoff.
+func clientError(status int) bool {
+	return status >= 400 && status < 500 && status != http.StatusRequestTimeout && status != http.StatusTooManyRequests
+}
+
+// retryAfter returns the delay a 429 or 503 response asks for, from its
+// Retry-After header or, failing that, a google.rpc.RetryInfo error detail,
+// or zero if it asks for none. A body it reads, bounded by ctx, is replaced
+// for the caller.
+func retryAfter(ctx context.Context, resp *http.Response) time.Duration {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0
+	}
+	if h := resp.Header.Get("Retry-After"); h != "" {
+		if secs, err := strconv.Atoi(h); err == nil {
+			return time.Duration(max(secs, 0)) * time.Second
+		}
+		if t, err := http.ParseTime(h); err == nil {
+			return max(time.Until(t), 0)
+		}
+	}
+
+	if resp.Body == nil {
+		return 0
+	}
+	body, err := readBody(ctx, resp.Body)
+	resp.Body.Close() //nolint:errcheck,gosec // best effort close
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return 0
+	}
+	var status struct {
+		Error struct {
+			Details []struct {
+				Type       string `json:"@type"`
+				RetryDelay string `json:"retryDelay"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &status) != nil {
+		return 0
+	}
+	for _, d := range status.Error.Details {
+		if d.Type == "type.googleapis.com/google.rpc.RetryInfo" {
+			if delay, err := time.ParseDuration(d.RetryDelay); err == nil {
+				return max(delay, 0)
+			}
+		}
+	}
+	return 0
+}
+
+// retryLog records the attempts of one operation for its RetryReport.
+type retryLog struct {
+	start    time.Time
+	policy   RetryPolicy
+	op       string
+	attempts []RetryAttempt
+	delay    time.Duration
+	after    time.Duration
+	hook     RetryFunc
+	tracer   Tracer
+	// throttled is set when the last response was 408 or 429.
+	throttled bool
+}
+
+func newRetryLog(p RetryPolicy, op string) *retryLog {
+	return &retryLog{policy: p, op: op, start: time.Now()}
+}
+
+// newRetryLog returns a retryLog reporting retries to the client's hook and
+// attempts to its tracer.
+func (c *Client) newRetryLog(p RetryPolicy, op string) *retryLog {
+	l := newRetryLog(p, op)
+	l.hook = c.retryHook
+	l.tracer = c.tracer
+	return l
+}
+
+// wait sleeps before the given attempt, which retries lastErr, noting the
+// delay: as long as the last response asked for, up to maxRetryAfter, otherwise
+// per the policy. Throttled requests back off exponentially even if the policy
+// doesn't.
+func (l *retryLog) wait(ctx context.Context, attempt int, lastErr error) error {
+	l.delay = l.policy.delay(attempt)
+	switch {
+	case l.after > 0:
+		l.delay = min(l.after, maxRetryAfter)
+	case l.throttled && l.policy.MaxDelay <= 0:
+		l.delay = min(l.delay<<(attempt-1), maxRetryAfter)
+	}
+	l.after, l.throttled = 0, false
+	if b := l.policy.Budget; b > 0 && time.Since(l.start)+l.delay >= b {
+		return fmt.Errorf("%w: retry budget of %v exhausted after %d attempts", ErrRetryBudget, b, len(l.attempts))
+	}
+	if l.hook != nil {
+		l.hook(ctx, RetryEvent{Err: lastErr, Op: l.op, Attempt: attempt + 1, Delay: l.delay})
+	}
+	return sleep(ctx, l.delay)
+}
+
+// do sends req with client and records the attempt.
+func (l *retryLog) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	a := RetryAttempt{Time: time.Now(), Delay: l.delay}
+	if l.tracer != nil {
+		var span Span
+		req, span = l.startSpan(req)
+		defer func() { endAttemptSpan(span, a) }()
+	}
+	parent, cancel := req.Context(), context.CancelFunc(func() {})
+	if d := l.policy.AttemptTimeout; d > 0 {
+		ctx, c := context.WithTimeout(parent, d)
+		req, cancel = req.WithContext(ctx), c
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// Report a timed out attempt as such, not as the caller's deadline,
+		// so it is retried and classified like any other network failure.
+		if req.Context().Err() != nil && parent.Err() == nil {
+			err = fmt.Errorf("attempt timed out after %v", l.policy.AttemptTimeout)
+		}
+		cancel()
+		a.Error = err.Error()
+	} else {
+		a.Status = resp.StatusCode
+		l.after = retryAfter(req.Context(), resp)
+		l.throttled = resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests
+		resp.Body = cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	}
+	l.attempts = append(l.attempts, a)
+	return resp, err
+}
+
+// cancelBody releases an attempt's timeout once its response body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// fail attaches the attempt history to err.
+func (l *retryLog) fail(err error) error {
+	return &RetryReport{Err: err, Op: l.op, Attempts: l.attempts}
+}
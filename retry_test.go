@@ -0,0 +1,366 @@
+package gsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		want   []time.Duration // delays before attempts 1, 2, 3, ...
+	}{
+		{name: "constant", policy: RetryPolicy{Delay: time.Second}, want: []time.Duration{time.Second, time.Second, time.Second}},
+		{
+			name:   "capped backoff",
+			policy: RetryPolicy{Delay: 100 * time.Millisecond, MaxDelay: 500 * time.Millisecond},
+			want:   []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 500 * time.Millisecond, 500 * time.Millisecond},
+		},
+		{name: "cap below delay", policy: RetryPolicy{Delay: time.Second, MaxDelay: time.Millisecond}, want: []time.Duration{time.Millisecond}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, want := range tt.want {
+				if got := tt.policy.delay(i + 1); got != want {
+					t.Errorf("delay(%d) = %v, want %v", i+1, got, want)
+				}
+			}
+		})
+	}
+
+	if got := (RetryPolicy{}).attempts(); got != maxRetries {
+		t.Errorf("zero policy attempts() = %d, want %d", got, maxRetries)
+	}
+}
+
+func TestRetryPolicyPerSubsystem(t *testing.T) {
+	var tokenAttempts, apiAttempts atomic.Int32
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		apiAttempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/token") && tokenAttempts.Add(1) < 5 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"test-token"}`)) //nolint:errcheck // test mock server
+	}))
+	t.Cleanup(metadataServer.Close)
+	metadataURL = metadataServer.URL
+
+	c := New(
+		WithMetadataRetry(RetryPolicy{Attempts: 5, Delay: time.Millisecond}),
+		WithAPIRetry(RetryPolicy{Attempts: 2, Delay: time.Millisecond, MaxDelay: 2 * time.Millisecond}),
+	)
+	_, err := c.FetchFromProject(context.Background(), "test-project", "s")
+	if err == nil || !strings.Contains(err.Error(), "failed to access secret") {
+		t.Errorf("FetchFromProject() error = %v, want access failure", err)
+	}
+	if n := tokenAttempts.Load(); n != 5 {
+		t.Errorf("token attempts = %d, want 5", n)
+	}
+	if n := apiAttempts.Load(); n != 2 {
+		t.Errorf("API attempts = %d, want 2", n)
+	}
+}
+
+func TestRetryReport(t *testing.T) {
+	var statuses []int
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(statuses[0])
+		statuses = statuses[1:]
+	})
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		statuses []int
+		want     FailureClass
+	}{
+		{name: "server", statuses: []int{503, 500, 502}, want: FailureServer},
+		{name: "quota", statuses: []int{503, 500, 429}, want: FailureQuota},
+		{name: "auth", statuses: []int{403}, want: FailureAuth},
+		{name: "client", statuses: []int{404}, want: FailureClient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statuses = tt.statuses
+			_, err := FetchFromProject(ctx, "test-project", "s")
+			var r *RetryReport
+			if !errors.As(err, &r) {
+				t.Fatalf("FetchFromProject() error = %v, want a *RetryReport", err)
+			}
+			if r.Class() != tt.want || r.Op != "access secret" || len(r.Attempts) != len(tt.statuses) {
+				t.Errorf("report = %s %s %+v, want %s with %d attempts", r.Op, r.Class(), r.Attempts, tt.want, len(tt.statuses))
+			}
+			for i, a := range r.Attempts {
+				if a.Status != tt.statuses[i] || a.Time.IsZero() || (i > 0) != (a.Delay > 0) {
+					t.Errorf("attempt %d = %+v, want status %d", i, a, tt.statuses[i])
+				}
+			}
+			if err.Error() != r.Err.Error() || !strings.HasPrefix(err.Error(), "failed to access secret") {
+				t.Errorf("report changed the error message: %q", err)
+			}
+		})
+	}
+
+	statuses = []int{404}
+	_, err := FetchFromProject(ctx, "test-project", "s")
+	if !hasStatus(err, http.StatusNotFound) {
+		t.Errorf("hasStatus() does not see through the report: %v", err)
+	}
+
+	statuses = []int{429, 429, 429}
+	_, err = FetchFromProject(ctx, "test-project", "s")
+	var r *RetryReport
+	errors.As(err, &r)
+	data, err := json.Marshal(r)
+	if err != nil || !strings.Contains(string(data), `"class":"quota"`) || !strings.Contains(string(data), `"status":429`) {
+		t.Errorf("json.Marshal(report) = %s, %v", data, err)
+	}
+}
+
+func TestRetryReportNetwork(t *testing.T) {
+	setupFakes(t, nil)
+	c := New(WithEndpoint("http://127.0.0.1:1/v1"), WithAPIRetry(RetryPolicy{Attempts: 2, Delay: time.Millisecond}))
+	_, err := c.FetchFromProject(context.Background(), "test-project", "s")
+	var r *RetryReport
+	if !errors.As(err, &r) || r.Class() != FailureNetwork || len(r.Attempts) != 2 || r.Attempts[1].Error == "" {
+		t.Errorf("FetchFromProject() = %v, want a network report with 2 attempts", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.FetchFromProject(ctx, "test-project", "s")
+	if !errors.As(err, &r) || r.Class() != FailureCanceled {
+		t.Errorf("FetchFromProject() with a canceled context = %v, want a canceled report", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header string
+		body   string
+		want   time.Duration
+	}{
+		{name: "seconds", status: 429, header: "7", want: 7 * time.Second},
+		{name: "retry info", status: 429, body: `{"error":{"details":[{"@type":"type.googleapis.com/google.rpc.RetryInfo","retryDelay":"1.5s"}]}}`, want: 1500 * time.Millisecond},
+		{name: "unavailable", status: 503, header: "2", want: 2 * time.Second},
+		{name: "none", status: 429, body: `{"error":{"code":429}}`},
+		{name: "not throttled", status: 500, header: "7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(tt.body))}
+			if tt.header != "" {
+				resp.Header.Set("Retry-After", tt.header)
+			}
+			if got := retryAfter(context.Background(), resp); got != tt.want {
+				t.Errorf("retryAfter() = %v, want %v", got, tt.want)
+			}
+			if body, _ := io.ReadAll(resp.Body); tt.header == "" && string(body) != tt.body { //nolint:errcheck // compared below
+				t.Errorf("body after retryAfter() = %q, want %q", body, tt.body)
+			}
+		})
+	}
+
+	var calls atomic.Int32
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error":{"details":[{"@type":"type.googleapis.com/google.rpc.RetryInfo","retryDelay":"0.05s"}]}}`)) //nolint:errcheck // test mock server
+			return
+		}
+		writePayload(w, "projects/test-project/secrets/s/versions/1", "v")
+	})
+	start := time.Now()
+	if _, err := FetchFromProject(context.Background(), "test-project", "s"); err != nil {
+		t.Fatalf("FetchFromProject() after 429 unexpected error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("retried after %v, want at least the requested 50ms", elapsed)
+	}
+}
+
+func TestRetryAfterBareResponse(t *testing.T) {
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		writePayload(w, "projects/test-project/secrets/s/versions/1", "v")
+	})
+
+	// A middleware or custom transport may answer without setting resp.Request.
+	var calls atomic.Int32
+	bare := func(next http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			if strings.Contains(req.URL.Path, "/secrets/") && calls.Add(1) == 1 {
+				return &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}, Body: http.NoBody}, nil
+			}
+			return next.RoundTrip(req)
+		})
+	}
+	c := New(WithMiddleware(bare), WithAPIRetry(RetryPolicy{Attempts: 2, Delay: time.Millisecond}))
+	if got, err := c.FetchFromProject(context.Background(), "test-project", "s"); err != nil || got != "v" {
+		t.Errorf("FetchFromProject() after a bare 429 = %q, %v, want v", got, err)
+	}
+}
+
+func TestThrottledBackoff(t *testing.T) {
+	var statuses []int
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(statuses[0])
+		statuses = statuses[1:]
+	})
+
+	c := New(WithAPIRetry(RetryPolicy{Attempts: 4, Delay: 5 * time.Millisecond}))
+	statuses = []int{429, 408, 429, 503}
+	_, err := c.FetchFromProject(context.Background(), "test-project", "s")
+	var r *RetryReport
+	if !errors.As(err, &r) || len(r.Attempts) != 4 {
+		t.Fatalf("FetchFromProject() = %v, want 4 attempts", err)
+	}
+	want := []time.Duration{0, 5 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond}
+	for i, a := range r.Attempts {
+		if a.Delay != want[i] {
+			t.Errorf("attempt %d delay = %v, want %v", i, a.Delay, want[i])
+		}
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	c := New(WithAPIRetry(RetryPolicy{Attempts: 10, Delay: 20 * time.Millisecond, Budget: 50 * time.Millisecond}))
+	start := time.Now()
+	_, err := c.FetchFromProject(context.Background(), "test-project", "s")
+	if !errors.Is(err, ErrRetryBudget) {
+		t.Fatalf("FetchFromProject() error = %v, want ErrRetryBudget", err)
+	}
+	var r *RetryReport
+	if !errors.As(err, &r) || len(r.Attempts) < 2 || len(r.Attempts) > 3 || r.Class() != FailureServer {
+		t.Errorf("FetchFromProject() = %v, want a server report with the attempts that fit the budget", err)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("FetchFromProject() took %v, want about the 50ms budget", elapsed)
+	}
+}
+
+func TestRetryHook(t *testing.T) {
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	var events []RetryEvent
+	c := New(
+		WithAPIRetry(RetryPolicy{Attempts: 3, Delay: time.Millisecond}),
+		WithRetryHook(func(_ context.Context, ev RetryEvent) {
+			events = append(events, ev)
+		}),
+	)
+	if _, err := c.FetchFromProject(context.Background(), "test-project", "s"); err == nil {
+		t.Fatal("FetchFromProject() succeeded, want error")
+	}
+	if len(events) != 2 {
+		t.Fatalf("hook called %d times, want 2", len(events))
+	}
+	for i, ev := range events {
+		if ev.Op != "access secret" || ev.Attempt != i+2 || ev.Delay != time.Millisecond || ev.Err == nil {
+			t.Errorf("event %d = %+v, want access secret attempt %d after 1ms with an error", i, ev, i+2)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		wantRetryable bool
+	}{
+		{name: "server error", status: http.StatusServiceUnavailable, wantRetryable: true},
+		{name: "rate limited", status: http.StatusTooManyRequests, wantRetryable: true},
+		{name: "not found", status: http.StatusNotFound},
+		{name: "permission denied", status: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+			})
+			_, err := New(WithAPIRetry(RetryPolicy{Attempts: 2, Delay: time.Millisecond})).FetchFromProject(context.Background(), "test-project", "s")
+			if got := IsRetryable(err); got != tt.wantRetryable {
+				t.Errorf("IsRetryable(%v) = %v, want %v", err, got, tt.wantRetryable)
+			}
+			if got := IsTransient(err); got != tt.wantRetryable {
+				t.Errorf("IsTransient(%v) = %v, want %v", err, got, tt.wantRetryable)
+			}
+		})
+	}
+
+	others := []struct {
+		err           error
+		name          string
+		wantRetryable bool
+		wantTransient bool
+	}{
+		{name: "nil"},
+		{name: "canceled", err: fmt.Errorf("failed: %w", context.Canceled)},
+		{name: "invalid name", err: errors.New("invalid secret name format")},
+		{name: "no credentials", err: noCredentials(&net.OpError{Op: "dial", Err: errors.New("refused")})},
+		{name: "circuit open", err: fmt.Errorf("access secret: %w", ErrCircuitOpen), wantTransient: true},
+		{name: "checksum", err: fmt.Errorf("payload: %w", ErrChecksumMismatch), wantRetryable: true, wantTransient: true},
+		{name: "network", err: &net.OpError{Op: "dial", Err: errors.New("refused")}, wantRetryable: true, wantTransient: true},
+	}
+	for _, tt := range others {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.wantRetryable {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.wantRetryable)
+			}
+			if got := IsTransient(tt.err); got != tt.wantTransient {
+				t.Errorf("IsTransient(%v) = %v, want %v", tt.err, got, tt.wantTransient)
+			}
+		})
+	}
+}
+
+func TestAttemptTimeout(t *testing.T) {
+	var calls atomic.Int32
+	var hangAll atomic.Bool
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 || hangAll.Load() {
+			<-r.Context().Done() // hang the attempt
+			return
+		}
+		writePayload(w, "projects/test-project/secrets/s/versions/1", "v")
+	})
+
+	c := New(WithAPIRetry(RetryPolicy{Attempts: 2, Delay: time.Millisecond, AttemptTimeout: 50 * time.Millisecond}))
+	start := time.Now()
+	got, err := c.FetchFromProject(context.Background(), "test-project", "s")
+	if err != nil || got != "v" {
+		t.Fatalf("FetchFromProject() = %q, %v, want v after the hung attempt times out", got, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("FetchFromProject() took %v, want about the 50ms attempt timeout", elapsed)
+	}
+
+	hangAll.Store(true)
+	_, err = c.FetchFromProject(context.Background(), "test-project", "s")
+	if !IsRetryable(err) || !strings.Contains(err.Error(), "attempt timed out") {
+		t.Errorf("FetchFromProject() error = %v, want a retryable attempt timeout", err)
+	}
+}
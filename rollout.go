@@ -0,0 +1,128 @@
+package gsm
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// secretVersion is the subset of the SecretVersion resource used by this package.
+type secretVersion struct {
+	CreateTime                     time.Time `json:"createTime"`
+	DestroyTime                    time.Time `json:"destroyTime"`
+	Etag                           string    `json:"etag"`
+	Name                           string    `json:"name"`
+	State                          string    `json:"state"`
+	ClientSpecifiedPayloadChecksum bool      `json:"clientSpecifiedPayloadChecksum"`
+}
+
+// rolloutVersion picks the version this instance should read while a newly
+// added version is ramping up. It returns a concrete version ID so the choice
+// can't race with another rotation between lookup and access.
+func (c *Client) rolloutVersion(ctx context.Context, tok, pid, name string, o fetchOptions) (string, error) {
+	var latest secretVersion
+	u := fmt.Sprintf("%s/projects/%s/secrets/%s/versions/latest", c.apiEndpoint(), pid, name)
+	if err := c.call(ctx, tok, "get secret version", http.MethodGet, u, nil, &latest); err != nil {
+		return "", err
+	}
+	latestID := versionID(latest.Name)
+	if latestID == "" {
+		return "", fmt.Errorf("unexpected version name %q", latest.Name)
+	}
+
+	age := time.Since(latest.CreateTime)
+	if age >= o.rolloutRamp {
+		return latestID, nil
+	}
+
+	b := c.rolloutBucket(ctx, pid, name)
+	if b < o.rolloutPercent {
+		c.log().Info("rollout: using new secret version", "version", latestID, "bucket", b, "percent", o.rolloutPercent)
+		return latestID, nil
+	}
+
+	prev, err := c.previousEnabledVersion(ctx, tok, pid, name, latestID)
+	if err != nil {
+		return "", err
+	}
+	if prev == "" {
+		// Nothing to fall back to, e.g. the first version of a secret.
+		return latestID, nil
+	}
+
+	c.log().Info("rollout: holding previous secret version",
+		"version", prev, "new_version", latestID, "bucket", b, "percent", o.rolloutPercent, "remaining", o.rolloutRamp-age)
+	return prev, nil
+}
+
+// previousEnabledVersion returns the newest enabled version older than latestID, or "" if none exists.
+func (c *Client) previousEnabledVersion(ctx context.Context, tok, pid, name, latestID string) (string, error) {
+	latestN, err := strconv.Atoi(latestID)
+	if err != nil {
+		return "", fmt.Errorf("unexpected version ID %q", latestID)
+	}
+
+	versions, err := c.listVersions(ctx, tok, pid, name, "state:ENABLED")
+	if err != nil {
+		return "", err
+	}
+
+	best := 0
+	for _, v := range versions {
+		n := versionNumber(v)
+		if v.State == "ENABLED" && n < latestN && n > best {
+			best = n
+		}
+	}
+
+	if best == 0 {
+		return "", nil
+	}
+	return strconv.Itoa(best), nil
+}
+
+// rolloutBucket deterministically maps this instance and secret to a bucket in [0, 100).
+// The project is included so that secrets of the same name in different projects
+// roll out independently.
+func (c *Client) rolloutBucket(ctx context.Context, pid, name string) int {
+	h := fnv.New32a()
+	h.Write([]byte(c.instanceKey(ctx) + "/" + pid + "/" + name)) //nolint:errcheck,gosec // hash writes never fail
+	return int(h.Sum32() % 100)
+}
+
+// instanceKey returns the metadata server instance ID, falling back to the hostname off-GCP.
+// An instance ID, or the hostname when the metadata server has none, is kept for
+// the life of the client so its rollout buckets never change. A hostname used
+// because the lookup failed is not kept, so a transient failure does not move
+// the instance to another bucket for good.
+func (c *Client) instanceKey(ctx context.Context) string {
+	c.mu.Lock()
+	key, ok := c.instKey, c.instKeySet
+	c.mu.Unlock()
+	if ok {
+		return key
+	}
+
+	id, lookupErr := c.metadataGet(ctx, "/instance/id", "instance ID")
+	key = strings.TrimSpace(string(id))
+	if key == "" {
+		h, err := os.Hostname()
+		if err != nil {
+			c.log().Warn("unable to determine instance identity for rollout", "error", err)
+		}
+		key = h
+	}
+
+	if lookupErr != nil {
+		return key
+	}
+	c.mu.Lock()
+	c.instKey, c.instKeySet = key, true
+	c.mu.Unlock()
+	return key
+}
@@ -0,0 +1,137 @@
+package gsm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchWithRollout(t *testing.T) {
+	const base = "projects/test-project/secrets/test-secret/versions/"
+
+	tests := []struct {
+		name         string
+		created      time.Duration // age of the latest version
+		percentDelta int           // rollout percent relative to this instance's bucket
+		want         string
+	}{
+		{name: "ramp finished", created: 2 * time.Hour, percentDelta: -100, want: "new"},
+		{name: "instance in rollout", created: time.Minute, percentDelta: 1, want: "new"},
+		{name: "instance held back", created: time.Minute, percentDelta: 0, want: "old"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/versions/latest"):
+					_ = json.NewEncoder(w).Encode(secretVersion{Name: base + "5", State: "ENABLED", CreateTime: time.Now().Add(-tt.created)}) //nolint:errcheck // test mock server
+				case strings.HasSuffix(r.URL.Path, "/versions"):
+					if r.URL.Query().Get("filter") != "state:ENABLED" {
+						t.Errorf("list filter = %q, want state:ENABLED", r.URL.Query().Get("filter"))
+					}
+					if r.URL.Query().Get("pageToken") == "" {
+						_ = json.NewEncoder(w).Encode(map[string]any{ //nolint:errcheck // test mock server
+							"versions":      []secretVersion{{Name: base + "5", State: "ENABLED"}, {Name: base + "4", State: "DISABLED"}},
+							"nextPageToken": "p2",
+						})
+						return
+					}
+					_ = json.NewEncoder(w).Encode(map[string]any{ //nolint:errcheck // test mock server
+						"versions": []secretVersion{{Name: base + "3", State: "ENABLED"}, {Name: base + "1", State: "ENABLED"}},
+					})
+				case strings.HasSuffix(r.URL.Path, "/versions/5:access"):
+					writePayload(w, base+"5", "new")
+				case strings.HasSuffix(r.URL.Path, "/versions/3:access"):
+					writePayload(w, base+"3", "old")
+				default:
+					t.Errorf("unexpected request %s", r.URL.Path)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			})
+
+			ctx := context.Background()
+			percent := defaultClient.rolloutBucket(ctx, "test-project", "test-secret") + tt.percentDelta
+			got, err := FetchFromProject(ctx, "test-project", "test-secret", WithRollout(percent, time.Hour))
+			if err != nil {
+				t.Fatalf("FetchFromProject() unexpected error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("FetchFromProject() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRolloutBucketDeterministic(t *testing.T) {
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNotFound) })
+
+	ctx := context.Background()
+	b := defaultClient.rolloutBucket(ctx, "test-project", "test-secret")
+	if b < 0 || b >= 100 {
+		t.Fatalf("rolloutBucket() = %d, want [0, 100)", b)
+	}
+	for range 5 {
+		if got := defaultClient.rolloutBucket(ctx, "test-project", "test-secret"); got != b {
+			t.Fatalf("rolloutBucket() = %d, want stable %d", got, b)
+		}
+	}
+
+	// The same secret name in another project gets its own bucket.
+	differs := false
+	for i := range 10 {
+		name := "secret-" + strconv.Itoa(i)
+		if defaultClient.rolloutBucket(ctx, "project-a", name) != defaultClient.rolloutBucket(ctx, "project-b", name) {
+			differs = true
+		}
+	}
+	if !differs {
+		t.Error("rolloutBucket() is the same in every project, want it to depend on the project")
+	}
+}
+
+func TestInstanceKeyCached(t *testing.T) {
+	host, err := os.Hostname()
+	if err != nil {
+		t.Skipf("no hostname: %v", err)
+	}
+	var status, requests atomic.Int32
+	metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(int(status.Load()))
+		_, _ = w.Write([]byte("1234567890")) //nolint:errcheck // test mock server
+	}))
+	t.Cleanup(metadataServer.Close)
+
+	ctx := context.Background()
+	c := New(WithMetadataEndpoint(metadataServer.URL), WithMetadataRetry(RetryPolicy{Attempts: 1}))
+
+	// A failed lookup falls back to the hostname without keeping it.
+	status.Store(http.StatusInternalServerError)
+	if got := c.instanceKey(ctx); got != host {
+		t.Errorf("instanceKey() with the metadata server failing = %q, want hostname %q", got, host)
+	}
+
+	// Once the instance ID is known, it is kept.
+	status.Store(http.StatusOK)
+	if got := c.instanceKey(ctx); got != "1234567890" {
+		t.Errorf("instanceKey() after recovery = %q, want instance ID", got)
+	}
+	status.Store(http.StatusInternalServerError)
+	n := requests.Load()
+	for range 3 {
+		if got := c.instanceKey(ctx); got != "1234567890" {
+			t.Errorf("instanceKey() = %q, want cached instance ID", got)
+		}
+	}
+	if got := requests.Load(); got != n {
+		t.Errorf("metadata requests = %d after repeated calls, want %d", got, n)
+	}
+}
@@ -2,17 +2,17 @@
 package gsm
 
 import (
-	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"log/slog"
+	"hash/crc32"
 	"net"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -24,6 +24,7 @@ var (
 	httpClient  = &http.Client{
 		Timeout: 30 * time.Second,
 		Transport: &http.Transport{
+			Proxy:               proxyFromEnvironment,
 			MaxIdleConns:        10,
 			IdleConnTimeout:     30 * time.Second,
 			MaxIdleConnsPerHost: 2,
@@ -32,14 +33,28 @@ var (
 )
 
 const (
-	maxRetries  = 3
+	maxRetries  = 3                // default attempts per request; see RetryPolicy
 	maxBodySize = 10 * 1024 * 1024 // 10MB limit for response bodies
 )
 
 // Note: This package intentionally uses simple retry logic without importing
 // external dependencies (including github.com/codeGROOVE-dev/retry) to maintain
-// zero dependencies. The metadata server and Secret Manager API are reliable
-// services that don't require exponential backoff with jitter.
+// zero dependencies. By default retries wait a constant delay; RetryPolicy adds
+// optional capped exponential backoff per subsystem.
+
+// ErrChecksumMismatch is returned (wrapped) when a secret payload does not match
+// the CRC32C checksum reported by Secret Manager.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ErrNotFound matches, with errors.Is, errors for secrets or versions that
+// don't exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrPermissionDenied matches, with errors.Is, errors for requests the caller's
+// credentials are not allowed to make.
+var ErrPermissionDenied = errors.New("permission denied")
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
 
 var (
 	projectIDRegex  = regexp.MustCompile(`^[a-z][a-z0-9-]{4,28}[a-z0-9]$`)
@@ -54,62 +69,108 @@ func isNotOnGCP(err error) bool {
 	return errors.As(err, &dnsErr) || errors.As(err, &opErr)
 }
 
+// Fetch retrieves the latest version of a secret from the current project
+// using the default client.
+// The project ID is auto-detected from the GCP metadata server.
+func Fetch(ctx context.Context, name string, opts ...FetchOption) (string, error) {
+	return defaultClient.Fetch(ctx, name, opts...)
+}
+
+// FetchFromProject retrieves the latest version of a secret from a specific project
+// using the default client.
+func FetchFromProject(ctx context.Context, pid, name string, opts ...FetchOption) (string, error) {
+	return defaultClient.FetchFromProject(ctx, pid, name, opts...)
+}
+
+// Store creates or updates a secret in the current project using the default client.
+// The project ID is auto-detected from the GCP metadata server.
+// If the secret doesn't exist, it will be created. If it exists, a new version will be added.
+func Store(ctx context.Context, name, value string, opts ...StoreOption) error {
+	return defaultClient.Store(ctx, name, value, opts...)
+}
+
+// StoreInProject creates or updates a secret in a specific project using the default client.
+// If the secret doesn't exist, it will be created. If it exists, a new version will be added.
+func StoreInProject(ctx context.Context, pid, name, value string, opts ...StoreOption) error {
+	return defaultClient.StoreInProject(ctx, pid, name, value, opts...)
+}
+
 // Fetch retrieves the latest version of a secret from the current project.
 // The project ID is auto-detected from the GCP metadata server.
-func Fetch(ctx context.Context, name string) (string, error) {
+func (c *Client) Fetch(ctx context.Context, name string, opts ...FetchOption) (string, error) {
 	if !secretNameRegex.MatchString(name) {
 		return "", errors.New("invalid secret name format")
 	}
 
-	p, err := projectID(ctx)
+	o := newFetchOptions(opts)
+	octx, cancel := o.context(ctx)
+	defer cancel()
+	p, err := c.projectID(octx)
 	if err != nil {
 		return "", err
 	}
 
-	return FetchFromProject(ctx, p, name)
+	return c.fetchFromProject(ctx, octx, p, name, o)
 }
 
 // projectID fetches the project ID from the GCP metadata server.
-func projectID(ctx context.Context) (string, error) {
+func (c *Client) projectID(ctx context.Context) (string, error) {
+	ctx, end := c.startSpan(ctx, "gsm.ProjectID")
+	pid, err := c.lookupProjectID(ctx)
+	end(err)
+	return pid, err
+}
+
+func (c *Client) lookupProjectID(ctx context.Context) (string, error) {
+	if c.emulator {
+		return emulatorProjectID()
+	}
+	creds, err := c.credentials()
+	if err != nil {
+		return "", fmt.Errorf("failed to get project ID: %w", err)
+	}
+	if creds != nil {
+		return credentialsProjectID(creds)
+	}
+
 	var p string
 	var lastErr error
 
-	for attempt := range maxRetries {
+	rl := c.newRetryLog(c.metadataRetry, "get project ID")
+	for attempt := range c.metadataRetry.attempts() {
 		if attempt > 0 {
-			slog.Info("retrying project ID fetch", "attempt", attempt+1)
-			select {
-			case <-time.After(retryDelay):
-			case <-ctx.Done():
-				return "", ctx.Err()
+			c.log().Info("retrying project ID fetch", "attempt", attempt+1)
+			if err := rl.wait(ctx, attempt, lastErr); err != nil {
+				return "", rl.fail(err)
 			}
 		}
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataURL+"/project/project-id", http.NoBody)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.metadataEndpoint()+"/project/project-id", http.NoBody)
 		if err != nil {
 			return "", err
 		}
 		req.Header.Set("Metadata-Flavor", "Google")
 
-		resp, err := httpClient.Do(req)
+		resp, err := rl.do(c.baseHTTP(), req)
 		if err != nil {
 			lastErr = err
 			// Don't retry if we're clearly not on GCP (DNS failure, connection refused)
 			if isNotOnGCP(err) {
-				slog.Debug("not running on GCP", "error", err)
-				return "", fmt.Errorf("not running on GCP: %w", err)
+				c.log().Debug("not running on GCP", "error", err)
+				return "", rl.fail(noCredentials(err))
 			}
-			slog.Warn("failed to get project ID", "attempt", attempt+1, "error", err)
+			c.log().Warn("failed to get project ID", "attempt", attempt+1, "error", err)
 			continue
 		}
 
 		if resp.StatusCode != http.StatusOK {
 			resp.Body.Close() //nolint:errcheck,gosec // best effort close
 			lastErr = fmt.Errorf("metadata server status %d", resp.StatusCode)
-			slog.Warn("failed to get project ID", "attempt", attempt+1, "status", resp.StatusCode)
+			c.log().Warn("failed to get project ID", "attempt", attempt+1, "status", resp.StatusCode)
 			continue
 		}
 
-		body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+		body, err := readBody(ctx, resp.Body)
 		resp.Body.Close() //nolint:errcheck,gosec // best effort close
 		if err != nil {
 			lastErr = err
@@ -118,63 +179,103 @@ func projectID(ctx context.Context) (string, error) {
 
 		p = strings.TrimSpace(string(body))
 		if p != "" {
-			slog.Info("fetched project ID from metadata server", "project_id", p, "length", len(p))
+			c.log().Info("fetched project ID from metadata server", "project_id", p, "length", len(p))
 			break
 		}
 		lastErr = errors.New("empty project ID")
 	}
 
 	if p == "" {
-		return "", fmt.Errorf("failed to get project ID: %w", lastErr)
+		return "", rl.fail(fmt.Errorf("failed to get project ID: %w", lastErr))
 	}
 
 	return p, nil
 }
 
-// accessToken fetches an access token from the GCP metadata server.
-func accessToken(ctx context.Context) (string, error) {
+// accessToken fetches an access token from the GCP metadata server. Tokens are
+// reused, in memory and through the client's token cache if one is configured,
+// until a few minutes before they expire; concurrent callers share one refresh.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	ctx, end := c.startSpan(ctx, "gsm.AccessToken")
+	tok, err := c.fetchAccessToken(ctx)
+	end(err)
+	return tok, err
+}
+
+func (c *Client) fetchAccessToken(ctx context.Context) (string, error) {
+	if c.emulator {
+		return emulatorToken, nil
+	}
+	if c.identityAuth != "" {
+		return c.IdentityToken(ctx, c.identityAuth)
+	}
+	creds, err := c.credentials()
+	if err == nil && creds != nil {
+		var t string
+		if t, err = c.credentialsToken(ctx, creds); err == nil {
+			return t, nil
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to get access token: %w", err)
+		c.recordFailure(ctx, err, true)
+		return "", err
+	}
+
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	if c.token != "" && time.Until(c.tokenExpires) > tokenRefreshMargin {
+		return c.token, nil
+	}
+	if t, ok := c.cachedToken(ctx); ok {
+		return t, nil
+	}
+
 	var t string
+	var expiresIn int
 	var lastErr error
 
-	for attempt := range maxRetries {
+	rl := c.newRetryLog(c.metadataRetry, "get access token")
+	for attempt := range c.metadataRetry.attempts() {
 		if attempt > 0 {
-			slog.Info("retrying access token fetch", "attempt", attempt+1)
-			select {
-			case <-time.After(retryDelay):
-			case <-ctx.Done():
-				return "", ctx.Err()
+			c.log().Info("retrying access token fetch", "attempt", attempt+1)
+			if err := rl.wait(ctx, attempt, lastErr); err != nil {
+				return "", rl.fail(err)
 			}
 		}
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataURL+"/instance/service-accounts/default/token", http.NoBody)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.metadataEndpoint()+c.metadataTokenPath(), http.NoBody)
 		if err != nil {
 			return "", err
 		}
 		req.Header.Set("Metadata-Flavor", "Google")
 
-		resp, err := httpClient.Do(req)
+		resp, err := rl.do(c.baseHTTP(), req)
 		if err != nil {
 			lastErr = err
 			// Don't retry if we're clearly not on GCP (DNS failure, connection refused)
 			if isNotOnGCP(err) {
-				slog.Debug("not running on GCP", "error", err)
-				return "", fmt.Errorf("not running on GCP: %w", err)
+				c.log().Debug("not running on GCP", "error", err)
+				err = rl.fail(noCredentials(err))
+				c.recordFailure(ctx, err, true)
+				return "", err
 			}
-			slog.Warn("failed to get access token", "attempt", attempt+1, "error", err)
+			c.log().Warn("failed to get access token", "attempt", attempt+1, "error", err)
 			continue
 		}
 
 		if resp.StatusCode != http.StatusOK {
 			resp.Body.Close() //nolint:errcheck,gosec // best effort close
 			lastErr = fmt.Errorf("metadata server status %d", resp.StatusCode)
-			slog.Warn("failed to get access token", "attempt", attempt+1, "status", resp.StatusCode)
+			c.log().Warn("failed to get access token", "attempt", attempt+1, "status", resp.StatusCode)
 			continue
 		}
 
 		var result struct {
 			AccessToken string `json:"access_token"`
+			ExpiresIn   int    `json:"expires_in"`
 		}
-		err = json.NewDecoder(io.LimitReader(resp.Body, maxBodySize)).Decode(&result)
+		err = decodeBody(ctx, resp.Body, &result)
 		resp.Body.Close() //nolint:errcheck,gosec // best effort close
 		if err != nil {
 			lastErr = err
@@ -183,20 +284,84 @@ func accessToken(ctx context.Context) (string, error) {
 
 		if result.AccessToken != "" {
 			t = result.AccessToken
+			expiresIn = result.ExpiresIn
 			break
 		}
 		lastErr = errors.New("empty access token")
 	}
 
 	if t == "" {
-		return "", fmt.Errorf("failed to get access token: %w", lastErr)
+		err := rl.fail(fmt.Errorf("failed to get access token: %w", lastErr))
+		c.recordFailure(ctx, err, true)
+		return "", err
 	}
 
+	// Without an expiry the token's lifetime is unknown, so it isn't reused.
+	if expiresIn > 0 {
+		expires := time.Now().Add(time.Duration(expiresIn) * time.Second)
+		c.token, c.tokenExpires = t, expires
+		if c.tokenCache != nil {
+			if err := c.tokenCache.Put(ctx, c.tokenCacheKey(), t, expires); err != nil {
+				c.log().Warn("failed to cache access token", "error", err)
+			}
+		}
+	}
 	return t, nil
 }
 
+// metadataGet fetches a value from the GCP metadata server, retrying transient failures.
+// The what argument describes the value in logs and errors, e.g. "instance ID".
+func (c *Client) metadataGet(ctx context.Context, path, what string) ([]byte, error) {
+	var lastErr error
+
+	rl := c.newRetryLog(c.metadataRetry, "get "+what)
+	for attempt := range c.metadataRetry.attempts() {
+		if attempt > 0 {
+			c.log().Info("retrying metadata fetch", "value", what, "attempt", attempt+1)
+			if err := rl.wait(ctx, attempt, lastErr); err != nil {
+				return nil, rl.fail(err)
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.metadataEndpoint()+path, http.NoBody)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Metadata-Flavor", "Google")
+
+		resp, err := rl.do(c.baseHTTP(), req)
+		if err != nil {
+			lastErr = err
+			// Don't retry if we're clearly not on GCP (DNS failure, connection refused)
+			if isNotOnGCP(err) {
+				c.log().Debug("not running on GCP", "error", err)
+				return nil, rl.fail(noCredentials(err))
+			}
+			c.log().Warn("failed to get "+what, "attempt", attempt+1, "error", err)
+			continue
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close() //nolint:errcheck,gosec // best effort close
+			lastErr = fmt.Errorf("metadata server status %d", resp.StatusCode)
+			c.log().Warn("failed to get "+what, "attempt", attempt+1, "status", resp.StatusCode)
+			continue
+		}
+
+		body, err := readBody(ctx, resp.Body)
+		resp.Body.Close() //nolint:errcheck,gosec // best effort close
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return body, nil
+	}
+
+	return nil, rl.fail(fmt.Errorf("failed to get %s: %w", what, lastErr))
+}
+
 // FetchFromProject retrieves the latest version of a secret from a specific project.
-func FetchFromProject(ctx context.Context, pid, name string) (string, error) {
+func (c *Client) FetchFromProject(ctx context.Context, pid, name string, opts ...FetchOption) (string, error) {
 	if !projectIDRegex.MatchString(pid) {
 		return "", fmt.Errorf("invalid project ID format: %q", pid)
 	}
@@ -204,51 +369,102 @@ func FetchFromProject(ctx context.Context, pid, name string) (string, error) {
 		return "", errors.New("invalid secret name format")
 	}
 
-	t, err := accessToken(ctx)
+	o := newFetchOptions(opts)
+	octx, cancel := o.context(ctx)
+	defer cancel()
+	return c.fetchFromProject(ctx, octx, pid, name, o)
+}
+
+// fetchFromProject fetches a secret through the cache. The work is bounded by
+// octx, the caller's ctx limited by any WithTimeout, so that a stale value can
+// still be served when only the operation's own timeout expired.
+func (c *Client) fetchFromProject(ctx, octx context.Context, pid, name string, o fetchOptions) (string, error) {
+	if value, ok, err := c.cached(ctx, pid, name); ok || err != nil {
+		return value, err
+	}
+
+	t, err := c.accessToken(octx)
+	if err == nil {
+		var value string
+		if value, err = c.fetch(octx, t, pid, name, o); err == nil {
+			return value, nil
+		}
+	}
+	if value, ok := c.staleValue(ctx, pid, name, err); ok {
+		return value, nil
+	}
+	return "", err
+}
+
+// fetch retrieves a secret using an existing access token, filling the cache and
+// emitting an audit event on success.
+func (c *Client) fetch(ctx context.Context, t, pid, name string, o fetchOptions) (string, error) {
+	var err error
+	version := "latest"
+	if o.rolloutRamp > 0 {
+		version, err = c.rolloutVersion(ctx, t, pid, name, o)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	value, got, err := c.accessVersion(ctx, t, pid, name, version)
 	if err != nil {
+		if c.misses != nil && hasStatus(err, http.StatusNotFound) {
+			c.misses.put(cacheKey(pid, name), err)
+		}
 		return "", err
 	}
 
-	url := fmt.Sprintf("%s/projects/%s/secrets/%s/versions/latest:access", apiURL, pid, name)
+	c.remember(pid, name, value, got)
+	c.emitAudit(ctx, "fetch", pid, name, got)
+	return value, nil
+}
+
+// accessVersion retrieves the payload of a specific secret version using an existing access token.
+// It returns the payload and the resolved version ID.
+func (c *Client) accessVersion(ctx context.Context, t, pid, name, version string) (string, string, error) {
+	url := fmt.Sprintf("%s/projects/%s/secrets/%s/versions/%s:access", c.apiEndpoint(), pid, name, version)
 
 	var lastErr error
-	for attempt := range maxRetries {
+	rl := c.newRetryLog(c.apiRetry, "access secret")
+	for attempt := range c.apiRetry.attempts() {
 		if attempt > 0 {
-			slog.Info("retrying secret access", "attempt", attempt+1)
-			select {
-			case <-time.After(retryDelay):
-			case <-ctx.Done():
-				return "", ctx.Err()
+			c.log().Info("retrying secret access", "attempt", attempt+1)
+			if err := rl.wait(ctx, attempt, lastErr); err != nil {
+				return "", "", rl.fail(err)
 			}
 		}
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+		req, err := c.apiRequest(ctx, http.MethodGet, url, t, nil)
 		if err != nil {
-			return "", err
+			return "", "", err
 		}
-		req.Header.Set("Authorization", "Bearer "+t)
 
-		resp, err := httpClient.Do(req)
+		resp, err := rl.do(c.apiHTTP(), req)
 		if err != nil {
 			lastErr = err
-			slog.Warn("failed to access secret", "attempt", attempt+1, "error", err)
+			c.log().Warn("failed to access secret", "attempt", attempt+1, "error", err)
 			continue
 		}
 
-		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
-			resp.Body.Close() //nolint:errcheck,gosec // best effort close
-			slog.Error("secret access denied", "status", resp.StatusCode)
-			return "", fmt.Errorf("failed to access secret: status %d", resp.StatusCode)
+		if clientError(resp.StatusCode) {
+			body, _ := readBody(ctx, resp.Body) //nolint:errcheck // best effort
+			resp.Body.Close()                   //nolint:errcheck,gosec // best effort close
+			c.log().Error("secret access denied", "status", resp.StatusCode)
+			err := newAPIError("access secret", resp.StatusCode, body)
+			c.recordStatus(ctx, err)
+			return "", "", rl.fail(err)
 		}
 
 		if resp.StatusCode != http.StatusOK {
 			resp.Body.Close() //nolint:errcheck,gosec // best effort close
 			lastErr = fmt.Errorf("status %d", resp.StatusCode)
-			slog.Warn("secret access failed", "attempt", attempt+1, "status", resp.StatusCode)
+			c.log().Warn("secret access failed", "attempt", attempt+1, "status", resp.StatusCode)
 			continue
 		}
 
-		body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+		body, err := readBody(ctx, resp.Body)
 		resp.Body.Close() //nolint:errcheck,gosec // best effort close
 		if err != nil {
 			lastErr = err
@@ -256,8 +472,10 @@ func FetchFromProject(ctx context.Context, pid, name string) (string, error) {
 		}
 
 		var result struct {
+			Name    string `json:"name"`
 			Payload struct {
-				Data string `json:"data"`
+				Data       string      `json:"data"`
+				DataCrc32c json.Number `json:"dataCrc32c"`
 			} `json:"payload"`
 		}
 		if err := json.Unmarshal(body, &result); err != nil {
@@ -272,167 +490,466 @@ func FetchFromProject(ctx context.Context, pid, name string) (string, error) {
 			continue
 		}
 
-		slog.Info("secret accessed successfully")
-		return string(decoded), nil
+		// A mismatch may be corruption in transit, so it is retried like other transient failures.
+		if err := checkCRC32C(decoded, result.Payload.DataCrc32c); err != nil {
+			lastErr = err
+			c.log().Warn("secret payload failed integrity check", "attempt", attempt+1, "error", err)
+			continue
+		}
+
+		c.recordSuccess()
+		c.log().Info("secret accessed successfully")
+		got := versionID(result.Name)
+		if got == "" {
+			got = version
+		}
+		value, err := c.openValue(ctx, t, name, decoded)
+		if err != nil {
+			return "", "", err
+		}
+		return value, got, nil
+	}
+
+	err := rl.fail(fmt.Errorf("failed to access secret: %w", lastErr))
+	c.recordFailure(ctx, err, false)
+	return "", "", err
+}
+
+// APIError is a non-retryable HTTP error response from the Secret Manager API.
+// Retrieve it with errors.As to inspect a failure; for the common cases,
+// errors.Is matches it against ErrNotFound, ErrPermissionDenied, and
+// ErrAlreadyExists.
+type APIError struct {
+	// Status is the google.rpc.Code name from the body, e.g. "NOT_FOUND", if any.
+	Status string
+	// Message is the error message from the body, if any.
+	Message string
+	op      string
+	// Details are the google.rpc.Status details from the body, such as ErrorInfo
+	// or BadRequest, each still encoded with its "@type".
+	Details []json.RawMessage
+	// Body is the raw response body.
+	Body []byte
+	// StatusCode is the HTTP status code.
+	StatusCode int
+}
+
+// newAPIError returns the error for a failed op, parsing the google.rpc.Status
+// in body if there is one.
+func newAPIError(op string, code int, body []byte) *APIError {
+	e := &APIError{op: op, Body: body, StatusCode: code}
+	var status struct {
+		Error struct {
+			Status  string            `json:"status"`
+			Message string            `json:"message"`
+			Details []json.RawMessage `json:"details"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &status) == nil {
+		e.Status = status.Error.Status
+		e.Message = status.Error.Message
+		e.Details = status.Error.Details
+	}
+	return e
+}
+
+// Error includes the RPC status and message if the body had them, and the raw
+// body otherwise.
+func (e *APIError) Error() string {
+	if e.Status == "" && e.Message == "" {
+		return fmt.Sprintf("failed to %s: status %d: %s", e.op, e.StatusCode, e.Body)
+	}
+	return fmt.Sprintf("failed to %s: status %d %s: %s", e.op, e.StatusCode, e.Status, e.Message)
+}
+
+// Is matches 404 responses to ErrNotFound, 403s to ErrPermissionDenied, and
+// 409 ALREADY_EXISTS responses to ErrAlreadyExists. Other 409s, such as etag
+// mismatches, match nothing.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrPermissionDenied:
+		return e.StatusCode == http.StatusForbidden
+	case ErrAlreadyExists:
+		return e.StatusCode == http.StatusConflict && e.Status == "ALREADY_EXISTS"
+	}
+	return false
+}
+
+// hasStatus reports whether err is an API error response with the given HTTP status code.
+func hasStatus(err error, code int) bool {
+	var se *APIError
+	return errors.As(err, &se) && se.StatusCode == code
+}
+
+// noVersion reports whether err means a secret has no accessible latest version:
+// the secret doesn't exist (NOT_FOUND), or the version is disabled or destroyed
+// (FAILED_PRECONDITION).
+func noVersion(err error) bool {
+	var ae *APIError
+	return errors.As(err, &ae) && (ae.StatusCode == http.StatusNotFound || ae.Status == "FAILED_PRECONDITION")
+}
+
+// call performs an authenticated JSON request against the Secret Manager API,
+// retrying transient failures. Client errors (4xx) are returned immediately.
+// The op argument describes the operation in logs and errors, e.g. "list versions".
+// If out is non-nil, the response body is decoded into it.
+func (c *Client) call(ctx context.Context, tok, op, method, url string, body []byte, out any) error {
+	var lastErr error
+	rl := c.newRetryLog(c.apiRetry, op)
+	for attempt := range c.apiRetry.attempts() {
+		if attempt > 0 {
+			c.log().Info("retrying "+op, "attempt", attempt+1)
+			if err := rl.wait(ctx, attempt, lastErr); err != nil {
+				return rl.fail(err)
+			}
+		}
+
+		req, err := c.apiRequest(ctx, method, url, tok, body)
+		if err != nil {
+			return err
+		}
+
+		resp, err := rl.do(c.apiHTTP(), req)
+		if err != nil {
+			lastErr = err
+			c.log().Warn("failed to "+op, "attempt", attempt+1, "error", err)
+			continue
+		}
+
+		respBody, err := readBody(ctx, resp.Body)
+		resp.Body.Close() //nolint:errcheck,gosec // best effort close
+
+		if clientError(resp.StatusCode) {
+			c.log().Error(op+" denied", "status", resp.StatusCode, "body", string(respBody))
+			err := newAPIError(op, resp.StatusCode, respBody)
+			c.recordStatus(ctx, err)
+			return rl.fail(err)
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			lastErr = fmt.Errorf("status %d: %s", resp.StatusCode, respBody)
+			c.log().Warn(op+" failed", "attempt", attempt+1, "status", resp.StatusCode)
+			continue
+		}
+
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if out != nil {
+			if err := json.Unmarshal(respBody, out); err != nil {
+				lastErr = err
+				continue
+			}
+		}
+		c.recordSuccess()
+		return nil
 	}
 
-	return "", fmt.Errorf("failed to access secret: %w", lastErr)
+	err := rl.fail(fmt.Errorf("failed to %s: %w", op, lastErr))
+	c.recordFailure(ctx, err, false)
+	return err
 }
 
 // Store creates or updates a secret in the current project.
 // The project ID is auto-detected from the GCP metadata server.
 // If the secret doesn't exist, it will be created. If it exists, a new version will be added.
-func Store(ctx context.Context, name, value string) error {
+func (c *Client) Store(ctx context.Context, name, value string, opts ...StoreOption) error {
 	if !secretNameRegex.MatchString(name) {
 		return errors.New("invalid secret name format")
 	}
 
-	p, err := projectID(ctx)
+	p, err := c.projectID(ctx)
 	if err != nil {
 		return err
 	}
 
-	return StoreInProject(ctx, p, name, value)
+	return c.StoreInProject(ctx, p, name, value, opts...)
 }
 
 // StoreInProject creates or updates a secret in a specific project.
 // If the secret doesn't exist, it will be created. If it exists, a new version will be added.
-func StoreInProject(ctx context.Context, pid, name, value string) error {
+func (c *Client) StoreInProject(ctx context.Context, pid, name, value string, opts ...StoreOption) error {
 	if !projectIDRegex.MatchString(pid) {
 		return fmt.Errorf("invalid project ID format: %q", pid)
 	}
 	if !secretNameRegex.MatchString(name) {
 		return errors.New("invalid secret name format")
 	}
+	_, err := c.store(ctx, pid, name, value, newStoreOptions(opts), storeAlways)
+	return err
+}
+
+// storeMode selects the conditional behavior of store.
+type storeMode int
 
-	tok, err := accessToken(ctx)
+const (
+	storeAlways    storeMode = iota
+	storeIfAbsent            // fail with ErrAlreadyExists if the secret exists
+	storeIfChanged           // skip the write if the latest version already holds the value
+	storeResume              // skip the create step; the secret is known to exist
+)
+
+// store runs the steps of a Store, each under its own timeout if one is configured,
+// and reports whether a new version was written.
+func (c *Client) store(ctx context.Context, pid, name, value string, o storeOptions, mode storeMode) (bool, error) {
+	if err := checkPayloadSize(name, value); err != nil {
+		return false, err
+	}
+	sctx, cancel := o.stepContext(ctx, StepToken)
+	tok, err := c.accessToken(sctx)
+	cancel()
 	if err != nil {
-		return err
+		return false, &StoreError{Project: pid, Secret: name, Step: StepToken, Err: err}
 	}
 
-	// First, try to create the secret
-	createURL := fmt.Sprintf("%s/projects/%s/secrets?secretId=%s", apiURL, pid, name)
-	createReqBody := map[string]any{
-		"replication": map[string]any{
-			"automatic": map[string]any{},
-		},
+	return c.storeWithToken(ctx, tok, pid, name, value, o, mode)
+}
+
+// storeWithToken runs the steps of a Store after StepToken using an existing access token.
+func (c *Client) storeWithToken(ctx context.Context, tok, pid, name, value string, o storeOptions, mode storeMode) (bool, error) {
+	var created bool
+	fail := func(step StoreStep, err error) error {
+		return &StoreError{Project: pid, Secret: name, Step: step, Created: created, Err: err}
+	}
+	if err := o.version.validate(); err != nil {
+		return false, err
+	}
+
+	if mode == storeIfChanged {
+		sctx, cancel := o.stepContext(ctx, StepCompare)
+		current, _, err := c.accessVersion(sctx, tok, pid, name, "latest")
+		cancel()
+		switch {
+		case err == nil && current == value:
+			c.log().Info("secret unchanged, skipping write")
+			return false, nil
+		case err != nil && !hasStatus(err, http.StatusNotFound):
+			return false, fail(StepCompare, err)
+		}
+	}
+
+	if mode != storeResume {
+		sctx, cancel := o.stepContext(ctx, StepCreate)
+		var err error
+		created, err = c.createSecret(sctx, tok, pid, name, o)
+		if err == nil && mode == storeIfAbsent && !created {
+			// Even a secret with no versions may belong to a concurrent
+			// StoreIfAbsent that has yet to add its version.
+			err = fmt.Errorf("failed to create secret %s: %w", name, ErrAlreadyExists)
+		}
+		cancel()
+		if err != nil {
+			return false, fail(StepCreate, err)
+		}
 	}
-	createData, err := json.Marshal(createReqBody)
+
+	sctx, cancel := o.stepContext(ctx, StepAddVersion)
+	version, err := c.addVersion(sctx, tok, pid, name, value)
+	cancel()
 	if err != nil {
-		return err
+		return false, fail(StepAddVersion, err)
+	}
+	c.invalidateCached(pid, name)
+	c.emitAudit(ctx, "store", pid, name, version)
+
+	if len(o.version.Metadata) > 0 {
+		if version == "" {
+			return true, fail(StepMetadata, errors.New("added version ID unknown"))
+		}
+		sctx, cancel = o.stepContext(ctx, StepMetadata)
+		err := c.annotateVersion(sctx, tok, pid, name, version, o.version.Metadata)
+		cancel()
+		if err != nil {
+			return true, fail(StepMetadata, err)
+		}
+	}
+
+	if o.verify {
+		sctx, cancel = o.stepContext(ctx, StepVerify)
+		err := c.verifyVersion(sctx, tok, pid, name, version, value)
+		cancel()
+		if err != nil {
+			return true, fail(StepVerify, err)
+		}
+	}
+	return true, nil
+}
+
+// createSecret creates the secret if it does not exist. It reports whether the
+// secret was created; an existing secret is not an error.
+func (c *Client) createSecret(ctx context.Context, tok, pid, name string, o storeOptions) (bool, error) {
+	createURL := fmt.Sprintf("%s/projects/%s/secrets?secretId=%s", c.apiEndpoint(), pid, name)
+	createData, err := json.Marshal(o.secretBody())
+	if err != nil {
+		return false, err
 	}
 
 	var createErr error
-	for attempt := range maxRetries {
+	rl := c.newRetryLog(c.apiRetry, "create secret")
+	for attempt := range c.apiRetry.attempts() {
 		if attempt > 0 {
-			slog.Info("retrying secret creation", "attempt", attempt+1)
-			select {
-			case <-time.After(retryDelay):
-			case <-ctx.Done():
-				return ctx.Err()
+			c.log().Info("retrying secret creation", "attempt", attempt+1)
+			if err := rl.wait(ctx, attempt, createErr); err != nil {
+				return false, rl.fail(err)
 			}
 		}
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, createURL, bytes.NewReader(createData))
+		req, err := c.apiRequest(ctx, http.MethodPost, createURL, tok, createData)
 		if err != nil {
-			return err
+			return false, err
 		}
-		req.Header.Set("Authorization", "Bearer "+tok)
-		req.Header.Set("Content-Type", "application/json")
 
-		resp, err := httpClient.Do(req)
+		resp, err := rl.do(c.apiHTTP(), req)
 		if err != nil {
 			createErr = err
-			slog.Warn("failed to create secret", "attempt", attempt+1, "error", err)
+			c.log().Warn("failed to create secret", "attempt", attempt+1, "error", err)
 			continue
 		}
 
 		if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated {
 			resp.Body.Close() //nolint:errcheck,gosec // best effort close
-			slog.Info("secret created successfully")
-			break
+			c.log().Info("secret created successfully")
+			return true, nil
 		}
 
 		// Read error body for logging
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodySize)) //nolint:errcheck // best effort
-		resp.Body.Close()                                             //nolint:errcheck,gosec // best effort close
+		body, _ := readBody(ctx, resp.Body) //nolint:errcheck // best effort
+		resp.Body.Close()                   //nolint:errcheck,gosec // best effort close
 
 		if resp.StatusCode == http.StatusConflict {
 			// Secret already exists, which is fine - we'll add a version
-			createErr = fmt.Errorf("secret already exists: status %d", resp.StatusCode)
-			break
+			return false, nil
 		}
 
-		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
-			slog.Error("secret creation denied", "status", resp.StatusCode, "body", string(body))
-			return fmt.Errorf("failed to create secret: status %d: %s", resp.StatusCode, body)
+		if clientError(resp.StatusCode) {
+			c.log().Error("secret creation denied", "status", resp.StatusCode, "body", string(body))
+			return false, rl.fail(newAPIError("create secret", resp.StatusCode, body))
 		}
 
 		createErr = fmt.Errorf("status %d: %s", resp.StatusCode, body)
-		slog.Warn("secret creation failed", "attempt", attempt+1, "status", resp.StatusCode)
+		c.log().Warn("secret creation failed", "attempt", attempt+1, "status", resp.StatusCode)
 	}
 
-	// If secret creation failed for reasons other than "already exists", return error
-	if createErr != nil && !strings.Contains(createErr.Error(), "secret already exists") {
-		return fmt.Errorf("failed to create secret: %w", createErr)
-	}
+	return false, rl.fail(fmt.Errorf("failed to create secret: %w", createErr))
+}
 
-	// Now add a new version with the value
-	versionURL := fmt.Sprintf("%s/projects/%s/secrets/%s:addVersion", apiURL, pid, name)
-	encoded := base64.StdEncoding.EncodeToString([]byte(value))
-	versionReqBody := map[string]any{
-		"payload": map[string]string{
-			"data": encoded,
-		},
-	}
-	versionData, err := json.Marshal(versionReqBody)
+// addVersion adds value as a new version of an existing secret and returns the new version ID.
+// The ID is empty if the response could not be decoded.
+func (c *Client) addVersion(ctx context.Context, tok, pid, name, value string) (string, error) {
+	value, err := c.sealValue(ctx, tok, name, value)
 	if err != nil {
-		return err
+		return "", err
+	}
+	// Encryption adds overhead, so a value under the limit may not be once sealed.
+	if err := checkPayloadSize(name, value); err != nil {
+		return "", err
 	}
+	versionURL := fmt.Sprintf("%s/projects/%s/secrets/%s:addVersion", c.apiEndpoint(), pid, name)
+	buf := getBuffer()
+	*buf = appendVersionBody(*buf, value)
+	versionData := *buf
+	lease := newBodyLease(buf)
+	defer lease.release()
 
 	var lastErr error
-	for attempt := range maxRetries {
+	rl := c.newRetryLog(c.apiRetry, "add secret version")
+	for attempt := range c.apiRetry.attempts() {
 		if attempt > 0 {
-			slog.Info("retrying add secret version", "attempt", attempt+1)
-			select {
-			case <-time.After(retryDelay):
-			case <-ctx.Done():
-				return ctx.Err()
+			c.log().Info("retrying add secret version", "attempt", attempt+1)
+			if err := rl.wait(ctx, attempt, lastErr); err != nil {
+				return "", rl.fail(err)
 			}
 		}
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, versionURL, bytes.NewReader(versionData))
+		req, err := c.apiRequest(ctx, http.MethodPost, versionURL, tok, versionData)
 		if err != nil {
-			return err
+			return "", err
+		}
+		if err := lease.setBody(req); err != nil {
+			return "", err
 		}
-		req.Header.Set("Authorization", "Bearer "+tok)
-		req.Header.Set("Content-Type", "application/json")
 
-		resp, err := httpClient.Do(req)
+		resp, err := rl.do(c.apiHTTP(), req)
 		if err != nil {
 			lastErr = err
-			slog.Warn("failed to add secret version", "attempt", attempt+1, "error", err)
+			c.log().Warn("failed to add secret version", "attempt", attempt+1, "error", err)
 			continue
 		}
 
 		if resp.StatusCode == http.StatusOK {
+			var result struct {
+				Name string `json:"name"`
+			}
+			if err := decodeBody(ctx, resp.Body, &result); err != nil {
+				c.log().Warn("failed to decode added version", "error", err)
+			}
 			resp.Body.Close() //nolint:errcheck,gosec // best effort close
-			slog.Info("secret version added successfully")
-			return nil
+			c.log().Info("secret version added successfully")
+			return versionID(result.Name), nil
 		}
 
 		// Read error body for logging
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodySize)) //nolint:errcheck // best effort
-		resp.Body.Close()                                             //nolint:errcheck,gosec // best effort close
+		body, _ := readBody(ctx, resp.Body) //nolint:errcheck // best effort
+		resp.Body.Close()                   //nolint:errcheck,gosec // best effort close
 
-		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
-			slog.Error("add secret version denied", "status", resp.StatusCode, "body", string(body))
-			return fmt.Errorf("failed to add secret version: status %d: %s", resp.StatusCode, body)
+		if clientError(resp.StatusCode) {
+			c.log().Error("add secret version denied", "status", resp.StatusCode, "body", string(body))
+			return "", rl.fail(newAPIError("add secret version", resp.StatusCode, body))
 		}
 
 		lastErr = fmt.Errorf("status %d: %s", resp.StatusCode, body)
-		slog.Warn("add secret version failed", "attempt", attempt+1, "status", resp.StatusCode)
+		c.log().Warn("add secret version failed", "attempt", attempt+1, "status", resp.StatusCode)
 	}
 
-	return fmt.Errorf("failed to add secret version: %w", lastErr)
+	return "", rl.fail(fmt.Errorf("failed to add secret version: %w", lastErr))
+}
+
+// verifyVersion reads a freshly added version back and compares its checksum against the stored value.
+func (c *Client) verifyVersion(ctx context.Context, tok, pid, name, version, value string) error {
+	if version == "" {
+		return errors.New("failed to verify secret: version name missing from response")
+	}
+
+	got, _, err := c.accessVersion(ctx, tok, pid, name, version)
+	if err != nil {
+		return fmt.Errorf("failed to verify secret version %s: %w", version, err)
+	}
+
+	if sha256.Sum256([]byte(got)) != sha256.Sum256([]byte(value)) {
+		c.log().Error("secret verification failed", "version", version)
+		return fmt.Errorf("failed to verify secret version %s: checksum mismatch", version)
+	}
+
+	c.log().Info("secret version verified", "version", version)
+	return nil
+}
+
+// checkCRC32C compares data against the decimal CRC32C checksum from an access response.
+// Responses without a checksum are accepted.
+func checkCRC32C(data []byte, want json.Number) error {
+	if want == "" {
+		return nil
+	}
+	w, err := strconv.ParseUint(string(want), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid payload checksum %q: %w", want, err)
+	}
+	if got := crc32.Checksum(data, crc32cTable); got != uint32(w) {
+		return fmt.Errorf("payload crc32c %d, expected %d: %w", got, w, ErrChecksumMismatch)
+	}
+	return nil
+}
+
+// versionID extracts the version ID from a resource name such as
+// "projects/p/secrets/s/versions/3".
+func versionID(resource string) string {
+	i := strings.LastIndex(resource, "/versions/")
+	if i < 0 {
+		return ""
+	}
+	return resource[i+len("/versions/"):]
 }
@@ -0,0 +1,121 @@
+package gsm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"sync"
+)
+
+// SecretBytes holds a secret value in a dedicated buffer that can be wiped once
+// the value is no longer needed, so long-running processes don't keep
+// credentials in memory indefinitely. Where the platform allows, the buffer is
+// allocated outside the Go heap and locked into RAM so it is never swapped to
+// disk. Like SecretString, it prints as "[REDACTED]".
+//
+// Wiping is best effort: copies made while fetching, such as the HTTP response
+// and any value cached by the client, are left to the garbage collector. Use
+// it without WithCache or WithDiskCache for the strongest guarantee.
+type SecretBytes struct {
+	free   func()
+	buf    []byte
+	mu     sync.Mutex
+	locked bool
+}
+
+// NewSecretBytes copies b into a new SecretBytes. The caller remains
+// responsible for wiping b.
+func NewSecretBytes(b []byte) *SecretBytes {
+	buf, locked, free := allocSecret(len(b))
+	copy(buf, b)
+	sb := &SecretBytes{buf: buf, locked: locked, free: free}
+	runtime.SetFinalizer(sb, (*SecretBytes).Wipe)
+	return sb
+}
+
+// Bytes returns the secret value, or nil once wiped. The slice aliases the
+// buffer: don't retain it past Wipe or copy it into longer-lived memory.
+func (sb *SecretBytes) Bytes() []byte {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.buf
+}
+
+// Locked reports whether the buffer is locked into RAM. Locking can fail, for
+// example when RLIMIT_MEMLOCK is exhausted, and is unsupported on some platforms.
+func (sb *SecretBytes) Locked() bool {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.locked
+}
+
+// Wipe overwrites the buffer with zeros and releases it. It is safe to call
+// more than once, and is called when an unwiped SecretBytes is garbage collected.
+func (sb *SecretBytes) Wipe() {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	if sb.buf == nil {
+		return
+	}
+	clear(sb.buf)
+	sb.free()
+	sb.buf, sb.locked = nil, false
+	runtime.SetFinalizer(sb, nil)
+}
+
+func (*SecretBytes) String() string {
+	return redacted
+}
+
+// GoString keeps the value out of %#v.
+func (*SecretBytes) GoString() string {
+	return redacted
+}
+
+// Format prints "[REDACTED]" for every verb.
+func (*SecretBytes) Format(f fmt.State, _ rune) {
+	fmt.Fprint(f, redacted) //nolint:errcheck // fmt.State writes can't be reported
+}
+
+// MarshalJSON encodes the secret as "[REDACTED]".
+func (*SecretBytes) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + redacted + `"`), nil
+}
+
+// LogValue keeps the value out of slog output.
+func (*SecretBytes) LogValue() slog.Value {
+	return slog.StringValue(redacted)
+}
+
+// FetchSecretBytes is Fetch returning a SecretBytes, using the default client.
+// The project ID is auto-detected from the GCP metadata server.
+func FetchSecretBytes(ctx context.Context, name string, opts ...FetchOption) (*SecretBytes, error) {
+	return defaultClient.FetchSecretBytes(ctx, name, opts...)
+}
+
+// FetchSecretBytesFromProject is FetchFromProject returning a SecretBytes,
+// using the default client.
+func FetchSecretBytesFromProject(ctx context.Context, pid, name string, opts ...FetchOption) (*SecretBytes, error) {
+	return defaultClient.FetchSecretBytesFromProject(ctx, pid, name, opts...)
+}
+
+// FetchSecretBytes is Fetch returning a SecretBytes. Call Wipe when done with it.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) FetchSecretBytes(ctx context.Context, name string, opts ...FetchOption) (*SecretBytes, error) {
+	value, err := c.Fetch(ctx, name, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewSecretBytes([]byte(value)), nil
+}
+
+// FetchSecretBytesFromProject is FetchFromProject returning a SecretBytes. Call
+// Wipe when done with it.
+func (c *Client) FetchSecretBytesFromProject(ctx context.Context, pid, name string, opts ...FetchOption) (*SecretBytes, error) {
+	value, err := c.FetchFromProject(ctx, pid, name, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewSecretBytes([]byte(value)), nil
+}
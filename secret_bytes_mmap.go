@@ -0,0 +1,25 @@
+//go:build linux || darwin
+
+package gsm
+
+import "syscall"
+
+// allocSecret returns an n-byte buffer mapped outside the Go heap and, if
+// possible, locked into RAM, with a function that releases it. It falls back
+// to the heap if the mapping fails.
+func allocSecret(n int) (buf []byte, locked bool, free func()) {
+	if n == 0 {
+		return []byte{}, false, func() {}
+	}
+	buf, err := syscall.Mmap(-1, 0, n, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return make([]byte, n), false, func() {}
+	}
+	locked = syscall.Mlock(buf) == nil
+	return buf, locked, func() {
+		if locked {
+			syscall.Munlock(buf) //nolint:errcheck,gosec // unmapping releases the lock anyway
+		}
+		syscall.Munmap(buf) //nolint:errcheck,gosec // nothing to do if it fails
+	}
+}
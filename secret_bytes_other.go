@@ -0,0 +1,8 @@
+//go:build !(linux || darwin)
+
+package gsm
+
+// allocSecret returns an n-byte heap buffer; buffers aren't locked into RAM here.
+func allocSecret(n int) (buf []byte, locked bool, free func()) {
+	return make([]byte, n), false, func() {}
+}
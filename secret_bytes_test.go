@@ -0,0 +1,48 @@
+package gsm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSecretBytes(t *testing.T) {
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		writePayload(w, "projects/test-project/secrets/s/versions/1", "hunter2")
+	})
+
+	sb, err := FetchSecretBytes(context.Background(), "s")
+	if err != nil {
+		t.Fatalf("FetchSecretBytes() unexpected error = %v", err)
+	}
+	if string(sb.Bytes()) != "hunter2" {
+		t.Errorf("Bytes() = %q, want hunter2", sb.Bytes())
+	}
+	data, err := json.Marshal(map[string]any{"password": sb})
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error = %v", err)
+	}
+	for _, o := range []string{fmt.Sprintf("%v %s %q %x %#v", sb, sb, sb, sb, sb), string(data)} {
+		if strings.Contains(o, "hunter2") || !strings.Contains(o, "[REDACTED]") {
+			t.Errorf("output %q leaks the secret or lacks [REDACTED]", o)
+		}
+	}
+
+	sb.Wipe()
+	sb.Wipe()
+	if sb.Bytes() != nil || sb.Locked() {
+		t.Errorf("after Wipe, Bytes() = %q, Locked() = %v, want nil and false", sb.Bytes(), sb.Locked())
+	}
+
+	// Mapped buffers are gone once wiped, so check the zeroing on a heap buffer.
+	buf := []byte("hunter2")
+	freed := false
+	hb := &SecretBytes{buf: buf, free: func() { freed = true }}
+	hb.Wipe()
+	if string(buf) != "\x00\x00\x00\x00\x00\x00\x00" || !freed {
+		t.Errorf("wiped buffer = %q, freed = %v, want zeros and freed", buf, freed)
+	}
+}
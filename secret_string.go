@@ -0,0 +1,81 @@
+package gsm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// redacted replaces secret values in output.
+const redacted = "[REDACTED]"
+
+// SecretString holds a secret value that stays out of logs and dumps: String,
+// fmt verbs, JSON and text marshaling, and slog all print "[REDACTED]". Call
+// Reveal where the value is actually needed.
+type SecretString struct {
+	value string
+}
+
+// NewSecretString wraps value, e.g. one obtained from another source.
+func NewSecretString(value string) SecretString {
+	return SecretString{value: value}
+}
+
+// Reveal returns the secret value.
+func (s SecretString) Reveal() string {
+	return s.value
+}
+
+func (SecretString) String() string {
+	return redacted
+}
+
+// GoString keeps the value out of %#v.
+func (SecretString) GoString() string {
+	return redacted
+}
+
+// Format prints "[REDACTED]" for every verb, including %s, %v, %q, and %x.
+func (SecretString) Format(f fmt.State, _ rune) {
+	fmt.Fprint(f, redacted) //nolint:errcheck // fmt.State writes can't be reported
+}
+
+// MarshalJSON encodes the secret as "[REDACTED]".
+func (SecretString) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + redacted + `"`), nil
+}
+
+// MarshalText encodes the secret as [REDACTED], e.g. in YAML or as a map key.
+func (SecretString) MarshalText() ([]byte, error) {
+	return []byte(redacted), nil
+}
+
+// LogValue keeps the value out of slog output.
+func (SecretString) LogValue() slog.Value {
+	return slog.StringValue(redacted)
+}
+
+// FetchSecretString is Fetch returning a SecretString, using the default client.
+// The project ID is auto-detected from the GCP metadata server.
+func FetchSecretString(ctx context.Context, name string, opts ...FetchOption) (SecretString, error) {
+	return defaultClient.FetchSecretString(ctx, name, opts...)
+}
+
+// FetchSecretStringFromProject is FetchFromProject returning a SecretString,
+// using the default client.
+func FetchSecretStringFromProject(ctx context.Context, pid, name string, opts ...FetchOption) (SecretString, error) {
+	return defaultClient.FetchSecretStringFromProject(ctx, pid, name, opts...)
+}
+
+// FetchSecretString is Fetch returning a SecretString.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) FetchSecretString(ctx context.Context, name string, opts ...FetchOption) (SecretString, error) {
+	value, err := c.Fetch(ctx, name, opts...)
+	return SecretString{value: value}, err
+}
+
+// FetchSecretStringFromProject is FetchFromProject returning a SecretString.
+func (c *Client) FetchSecretStringFromProject(ctx context.Context, pid, name string, opts ...FetchOption) (SecretString, error) {
+	value, err := c.FetchFromProject(ctx, pid, name, opts...)
+	return SecretString{value: value}, err
+}
@@ -0,0 +1,46 @@
+package gsm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSecretString(t *testing.T) {
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		writePayload(w, "projects/test-project/secrets/s/versions/1", "hunter2")
+	})
+
+	s, err := FetchSecretString(context.Background(), "s")
+	if err != nil {
+		t.Fatalf("FetchSecretString() unexpected error = %v", err)
+	}
+	if s.Reveal() != "hunter2" {
+		t.Errorf("Reveal() = %q, want hunter2", s.Reveal())
+	}
+
+	var out []string
+	for _, verb := range []string{"%s", "%v", "%+v", "%#v", "%q", "%x"} {
+		out = append(out, fmt.Sprintf(verb, s))
+	}
+	out = append(out, fmt.Sprint(struct{ Password SecretString }{s}), s.String())
+	data, err := json.Marshal(map[string]any{"password": s})
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error = %v", err)
+	}
+	out = append(out, string(data))
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("loaded", "password", s)
+	out = append(out, buf.String())
+
+	for _, o := range out {
+		if strings.Contains(o, "hunter2") || !strings.Contains(o, "[REDACTED]") {
+			t.Errorf("output %q leaks the secret or lacks [REDACTED]", o)
+		}
+	}
+}
@@ -782,6 +782,41 @@ func TestURLConstruction(t *testing.T) {
 			t.Errorf("URL = %v, want %v", capturedURL, expectedPath)
 		}
 	})
+
+	t.Run("endpoint options", func(t *testing.T) {
+		var capturedURL string
+		apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			capturedURL = r.URL.String()
+			writePayload(w, "projects/test-project/secrets/my-secret/versions/1", "secret-value")
+		}))
+		defer apiServer.Close()
+
+		metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasSuffix(r.URL.Path, "/project/project-id") {
+				_, _ = w.Write([]byte("test-project")) //nolint:errcheck // test mock server
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "test-token"}) //nolint:errcheck // test mock server
+		}))
+		defer metadataServer.Close()
+
+		// The package defaults must not be used.
+		oldMetadataURL, oldAPIURL := metadataURL, apiURL
+		defer func() { metadataURL, apiURL = oldMetadataURL, oldAPIURL }()
+		metadataURL, apiURL = "http://127.0.0.1:1", "http://127.0.0.1:1"
+
+		c := New(WithEndpoint(apiServer.URL+"/psc/v1/"), WithMetadataEndpoint(metadataServer.URL))
+		got, err := c.Fetch(context.Background(), "my-secret")
+		if err != nil {
+			t.Fatalf("Fetch() unexpected error = %v", err)
+		}
+		if got != "secret-value" {
+			t.Errorf("Fetch() = %q, want %q", got, "secret-value")
+		}
+		if want := "/psc/v1/projects/test-project/secrets/my-secret/versions/latest:access"; capturedURL != want {
+			t.Errorf("URL = %v, want %v", capturedURL, want)
+		}
+	})
 }
 
 func TestStore(t *testing.T) { //nolint:gocognit // table-driven test
@@ -1537,3 +1572,270 @@ func TestReadErrors(t *testing.T) {
 		}
 	})
 }
+
+func TestSleepDeadline(t *testing.T) {
+	oldRetryDelay := retryDelay
+	retryDelay = 5 * time.Second
+	defer func() { retryDelay = oldRetryDelay }()
+
+	t.Run("deadline too short for another attempt", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		start := time.Now()
+		err := sleep(ctx, retryDelay)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("sleep() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+		if !strings.Contains(err.Error(), "exceeds remaining deadline") {
+			t.Errorf("sleep() error = %v, want descriptive deadline error", err)
+		}
+		if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+			t.Errorf("sleep() took %v, want immediate return", elapsed)
+		}
+	})
+
+	t.Run("deadline leaves room for another attempt", func(t *testing.T) {
+		retryDelay = 10 * time.Millisecond
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		if err := sleep(ctx, retryDelay); err != nil {
+			t.Errorf("sleep() unexpected error = %v", err)
+		}
+	})
+
+	t.Run("secret fetch gives up without sleeping", func(t *testing.T) {
+		retryDelay = 5 * time.Second
+		attempts := 0
+		apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer apiServer.Close()
+
+		metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "test-token"}) //nolint:errcheck // test mock server
+		}))
+		defer metadataServer.Close()
+
+		oldMetadataURL := metadataURL
+		oldAPIURL := apiURL
+		defer func() {
+			metadataURL = oldMetadataURL
+			apiURL = oldAPIURL
+		}()
+		metadataURL = metadataServer.URL
+		apiURL = apiServer.URL
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		start := time.Now()
+		_, err := FetchFromProject(ctx, "test-project", "test-secret")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("FetchFromProject() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("FetchFromProject() took %v, want early return", elapsed)
+		}
+		if attempts != 1 {
+			t.Errorf("Expected 1 attempt, got %d", attempts)
+		}
+	})
+}
+
+func TestCRC32C(t *testing.T) {
+	const value = "123456789"
+	const sum = "3808858755" // CRC32C check value for "123456789"
+
+	t.Run("store sends checksum", func(t *testing.T) {
+		var got string
+		setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasSuffix(r.URL.Path, ":addVersion") {
+				var req struct {
+					Payload struct {
+						DataCrc32c string `json:"dataCrc32c"`
+					} `json:"payload"`
+				}
+				_ = json.NewDecoder(r.Body).Decode(&req) //nolint:errcheck // checked below
+				got = req.Payload.DataCrc32c
+			}
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{}`)) //nolint:errcheck // test mock server
+		})
+
+		if err := StoreInProject(context.Background(), "test-project", "test-secret", value); err != nil {
+			t.Fatalf("StoreInProject() unexpected error = %v", err)
+		}
+		if got != sum {
+			t.Errorf("dataCrc32c = %q, want %q", got, sum)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		checksum any
+		wantErr  bool
+	}{
+		{name: "matching string", checksum: sum},
+		{name: "matching number", checksum: 3808858755},
+		{name: "absent", checksum: nil},
+		{name: "mismatch", checksum: "12345", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run("fetch "+tt.name, func(t *testing.T) {
+			attempts := 0
+			setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+				attempts++
+				payload := map[string]any{"data": base64.StdEncoding.EncodeToString([]byte(value))}
+				if tt.checksum != nil {
+					payload["dataCrc32c"] = tt.checksum
+				}
+				_ = json.NewEncoder(w).Encode(map[string]any{"payload": payload}) //nolint:errcheck // test mock server
+			})
+
+			got, err := FetchFromProject(context.Background(), "test-project", "test-secret")
+			if tt.wantErr {
+				if !errors.Is(err, ErrChecksumMismatch) {
+					t.Errorf("FetchFromProject() error = %v, want ErrChecksumMismatch", err)
+				}
+				if attempts != maxRetries {
+					t.Errorf("attempts = %d, want %d", attempts, maxRetries)
+				}
+				return
+			}
+			if err != nil || got != value {
+				t.Errorf("FetchFromProject() = %q, %v, want %q", got, err, value)
+			}
+		})
+	}
+}
+
+func TestFetchWithTimeout(t *testing.T) {
+	release := make(chan struct{})
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		writePayload(w, "projects/test-project/secrets/s/versions/1", "v")
+	})
+	defer close(release)
+
+	start := time.Now()
+	_, err := Fetch(context.Background(), "s", WithTimeout(50*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Fetch() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Fetch() took %v, want about the 50ms timeout", elapsed)
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	tests := []struct {
+		target error
+		name   string
+		body   string
+		status int
+		want   bool
+	}{
+		{name: "not found", status: http.StatusNotFound, target: ErrNotFound, want: true},
+		{name: "permission denied", status: http.StatusForbidden, target: ErrPermissionDenied, want: true},
+		{name: "already exists", status: http.StatusConflict, body: `{"error":{"code":409,"status":"ALREADY_EXISTS"}}`, target: ErrAlreadyExists, want: true},
+		{name: "aborted", status: http.StatusConflict, body: `{"error":{"code":409,"status":"ABORTED"}}`, target: ErrAlreadyExists},
+		{name: "bad request", status: http.StatusBadRequest, target: ErrNotFound},
+		{name: "forbidden is not missing", status: http.StatusForbidden, target: ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body)) //nolint:errcheck // test mock server
+			})
+
+			_, err := FetchFromProject(context.Background(), "test-project", "s")
+			if err == nil {
+				t.Fatal("FetchFromProject() succeeded, want error")
+			}
+			if got := errors.Is(err, tt.target); got != tt.want {
+				t.Errorf("errors.Is(%v, %v) = %v, want %v", err, tt.target, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("store denied", func(t *testing.T) {
+		setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		})
+		if err := StoreInProject(context.Background(), "test-project", "s", "v"); !errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("StoreInProject() error = %v, want ErrPermissionDenied", err)
+		}
+	})
+}
+
+func TestAPIError(t *testing.T) {
+	body := `{"error":{"code":403,"message":"Permission 'secretmanager.versions.access' denied","status":"PERMISSION_DENIED"}}`
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(body)) //nolint:errcheck // test mock server
+	})
+
+	_, err := FetchFromProject(context.Background(), "test-project", "s")
+	var ae *APIError
+	if !errors.As(err, &ae) {
+		t.Fatalf("FetchFromProject() error = %v, want *APIError", err)
+	}
+	if ae.StatusCode != http.StatusForbidden || ae.Status != "PERMISSION_DENIED" ||
+		ae.Message != "Permission 'secretmanager.versions.access' denied" || string(ae.Body) != body {
+		t.Errorf("APIError = %+v, want the parsed 403 response", ae)
+	}
+	if want := "failed to access secret: status 403 PERMISSION_DENIED: Permission 'secretmanager.versions.access' denied"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("not json")) //nolint:errcheck // test mock server
+	})
+	_, err = FetchFromProject(context.Background(), "test-project", "s")
+	if !errors.As(err, &ae) || ae.StatusCode != http.StatusBadRequest || ae.Status != "" || string(ae.Body) != "not json" {
+		t.Errorf("FetchFromProject() error = %v, want an APIError with the raw body", err)
+	}
+}
+
+func TestAPIErrorClassification(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		code         int
+		wantNoVer    bool
+		wantConflict bool
+	}{
+		{name: "not found", code: 404, body: `{"error":{"status":"NOT_FOUND"}}`, wantNoVer: true},
+		{name: "disabled version", code: 400, body: `{"error":{"status":"FAILED_PRECONDITION","message":"Secret Version is in DISABLED state."}}`, wantNoVer: true},
+		{name: "invalid argument", code: 400, body: `{"error":{"status":"INVALID_ARGUMENT"}}`},
+		{name: "etag mismatch", code: 400, body: `{"error":{"status":"FAILED_PRECONDITION","message":"The etag provided does not match."}}`, wantNoVer: true, wantConflict: true},
+		{name: "aborted", code: 409, body: `{"error":{"status":"ABORTED"}}`, wantConflict: true},
+		{name: "already exists", code: 409, body: `{"error":{"status":"ALREADY_EXISTS","details":[{"@type":"type.googleapis.com/google.rpc.ErrorInfo"}]}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := error(newAPIError("op", tt.code, []byte(tt.body)))
+			if got := noVersion(err); got != tt.wantNoVer {
+				t.Errorf("noVersion() = %v, want %v", got, tt.wantNoVer)
+			}
+			var ce *ConflictError
+			if got := errors.As(conflictError(err, "s", `"e"`), &ce); got != tt.wantConflict {
+				t.Errorf("conflictError() is conflict = %v, want %v", got, tt.wantConflict)
+			}
+		})
+	}
+
+	ae := newAPIError("op", 409, []byte(`{"error":{"status":"ALREADY_EXISTS","details":[{"@type":"type.googleapis.com/google.rpc.ErrorInfo"}]}}`))
+	if len(ae.Details) != 1 || !strings.Contains(string(ae.Details[0]), "ErrorInfo") {
+		t.Errorf("Details = %s, want the ErrorInfo detail", ae.Details)
+	}
+}
@@ -0,0 +1,360 @@
+package gsm
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	envNameRegex     = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	specVersionRegex = regexp.MustCompile(`^(latest|[1-9][0-9]*)$`)
+)
+
+// Spec transforms.
+const (
+	TransformTrim         = "trim"
+	TransformBase64Decode = "base64-decode"
+)
+
+// Spec declares an application's entire secret surface: which environment
+// variables it needs and which secrets they come from. Specs are usually
+// loaded from a secrets.yaml file with LoadSpecFile:
+//
+//	project: my-project            # optional; defaults to the current project
+//	env:
+//	  DB_PASSWORD: db-password     # NAME or PROJECT/NAME
+//	  API_KEY:
+//	    secret: other-project/api-key
+//	    version: 3                 # optional; defaults to latest
+//	    default: ""                # optional; used if the secret does not exist
+//	    transform: [trim, base64-decode]
+type Spec struct {
+	Project string
+	Env     []EnvSpec // sorted by Name
+}
+
+// EnvSpec maps one environment variable to a secret.
+type EnvSpec struct {
+	Name    string // environment variable name
+	Project string // empty means Spec.Project, then the current project
+	Secret  string
+	Version string // empty means latest
+	Default string
+	// HasDefault reports whether Default is used when the secret or version does not exist.
+	HasDefault bool
+	// Transforms are applied in order to fetched values, but not to defaults.
+	Transforms []string
+}
+
+// LoadSpecFile reads and parses a spec file.
+func LoadSpecFile(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := ParseSpec(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// ParseSpec parses a spec from YAML. See Spec for the format.
+func ParseSpec(data []byte) (*Spec, error) {
+	doc, err := parseYAMLText(data)
+	if err != nil {
+		return nil, err
+	}
+	top, ok := doc.(map[string]any)
+	if !ok {
+		return nil, errors.New("spec must be a mapping")
+	}
+
+	s := &Spec{}
+	for k, v := range top {
+		switch k {
+		case "project":
+			s.Project = yamlString(v)
+		case "env":
+		default:
+			return nil, fmt.Errorf("unknown spec key %q", k)
+		}
+	}
+	if s.Project != "" && !projectIDRegex.MatchString(s.Project) {
+		return nil, fmt.Errorf("invalid project ID format: %q", s.Project)
+	}
+
+	env, ok := top["env"].(map[string]any)
+	if !ok {
+		return nil, errors.New("spec must have an env mapping")
+	}
+	for name, v := range env {
+		e, err := parseEnvSpec(name, v)
+		if err != nil {
+			return nil, fmt.Errorf("env %s: %w", name, err)
+		}
+		s.Env = append(s.Env, e)
+	}
+	slices.SortFunc(s.Env, func(a, b EnvSpec) int { return strings.Compare(a.Name, b.Name) })
+	return s, nil
+}
+
+// ParseEnvMapping parses a NAME=[PROJECT/]SECRET[#VERSION] mapping, the
+// command-line form of a spec entry, e.g. "DB_PASSWORD=db-password#3".
+func ParseEnvMapping(mapping string) (EnvSpec, error) {
+	name, ref, ok := strings.Cut(mapping, "=")
+	if !ok {
+		return EnvSpec{}, fmt.Errorf("mapping %q is not NAME=SECRET", mapping)
+	}
+	v := map[string]any{"secret": ref}
+	if ref, version, ok := strings.Cut(ref, "#"); ok {
+		v = map[string]any{"secret": ref, "version": version}
+	}
+	e, err := parseEnvSpec(name, v)
+	if err != nil {
+		return e, fmt.Errorf("env %s: %w", name, err)
+	}
+	return e, nil
+}
+
+// parseEnvSpec parses either a bare secret reference or a mapping with a secret key.
+func parseEnvSpec(name string, v any) (EnvSpec, error) {
+	e := EnvSpec{Name: name}
+	if !envNameRegex.MatchString(name) {
+		return e, errors.New("invalid environment variable name")
+	}
+
+	var ref string
+	switch v := v.(type) {
+	case string:
+		ref = v
+	case map[string]any:
+		for k, f := range v {
+			switch k {
+			case "secret":
+				ref = yamlString(f)
+			case "version":
+				e.Version = yamlString(f)
+			case "default":
+				if f == nil {
+					return e, errors.New(`default is null; use "" for an empty default`)
+				}
+				e.Default, e.HasDefault = yamlString(f), true
+			case "transform":
+				ts, err := parseTransforms(f)
+				if err != nil {
+					return e, err
+				}
+				e.Transforms = ts
+			default:
+				return e, fmt.Errorf("unknown key %q", k)
+			}
+		}
+	default:
+		return e, errors.New("must be a secret reference or a mapping")
+	}
+
+	return e, e.setRef(ref)
+}
+
+// setRef sets e's secret from a [PROJECT/]SECRET reference and validates it
+// along with e's version.
+func (e *EnvSpec) setRef(ref string) error {
+	if p, n, ok := strings.Cut(ref, "/"); ok {
+		e.Project, e.Secret = p, n
+	} else {
+		e.Secret = ref
+	}
+	if e.Project != "" && !projectIDRegex.MatchString(e.Project) {
+		return fmt.Errorf("invalid project ID format: %q", e.Project)
+	}
+	if !secretNameRegex.MatchString(e.Secret) {
+		return errors.New("invalid secret name format")
+	}
+	if e.Version != "" && !specVersionRegex.MatchString(e.Version) {
+		return fmt.Errorf("invalid version %q", e.Version)
+	}
+	return nil
+}
+
+// parseTransforms accepts a single transform name or a list of them.
+func parseTransforms(v any) ([]string, error) {
+	var items []any
+	switch v := v.(type) {
+	case string:
+		items = []any{v}
+	case []any:
+		items = v
+	default:
+		return nil, errors.New("transform must be a name or a list of names")
+	}
+
+	ts := make([]string, 0, len(items))
+	for _, it := range items {
+		t := yamlString(it)
+		if t != TransformTrim && t != TransformBase64Decode {
+			return nil, fmt.Errorf("unknown transform %q", t)
+		}
+		ts = append(ts, t)
+	}
+	return ts, nil
+}
+
+// applyTransforms applies ts to value in order.
+func applyTransforms(value string, ts []string) (string, error) {
+	for _, t := range ts {
+		switch t {
+		case TransformTrim:
+			value = strings.TrimSpace(value)
+		case TransformBase64Decode:
+			b, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", t, err)
+			}
+			value = string(b)
+		default:
+			return "", fmt.Errorf("unknown transform %q", t)
+		}
+	}
+	return value, nil
+}
+
+// ResolveSpec fetches every secret in s using the default client, returning
+// values keyed by environment variable name.
+func ResolveSpec(ctx context.Context, s *Spec) (map[string]string, error) {
+	return defaultClient.ResolveSpec(ctx, s)
+}
+
+// LoadEnv resolves s using the default client and sets the resulting
+// environment variables in the current process.
+func LoadEnv(ctx context.Context, s *Spec) error {
+	return defaultClient.LoadEnv(ctx, s)
+}
+
+// ResolveSpec fetches every secret in s concurrently, sharing one access token,
+// and returns values keyed by environment variable name. Secrets that do not
+// exist resolve to their default if one is declared. If any variable cannot be
+// resolved, the errors for every failed variable are returned together.
+func (c *Client) ResolveSpec(ctx context.Context, s *Spec) (map[string]string, error) {
+	values, err := c.resolveSpec(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range s.Env {
+		if _, ok := values[e.Name]; !ok {
+			values[e.Name] = e.Default
+		}
+	}
+	return values, nil
+}
+
+// resolveSpec is ResolveSpec, except that variables whose secret does not exist
+// but which have a default are left out of the result.
+func (c *Client) resolveSpec(ctx context.Context, s *Spec) (map[string]string, error) {
+	var current string
+	for _, e := range s.Env {
+		if e.Project == "" && s.Project == "" {
+			p, err := c.projectID(ctx)
+			if err != nil {
+				return nil, err
+			}
+			current = p
+			break
+		}
+	}
+
+	t, err := c.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		errs   = map[string]error{}
+		sem    = make(chan struct{}, maxConcurrentFetches)
+		values = make(map[string]string, len(s.Env))
+	)
+	for _, e := range s.Env {
+		pid := cmp.Or(e.Project, s.Project, current)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			value, ok, err := c.resolveEnv(ctx, t, pid, e)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[e.Name] = fmt.Errorf("%s: %w", e.Name, err)
+				return
+			}
+			if ok {
+				values[e.Name] = value
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		// Report failures in a stable order, sorted by variable name.
+		joined := make([]error, 0, len(errs))
+		for _, name := range slices.Sorted(maps.Keys(errs)) {
+			joined = append(joined, errs[name])
+		}
+		return nil, errors.Join(joined...)
+	}
+	return values, nil
+}
+
+// resolveEnv fetches and transforms the value of a single environment variable.
+// It returns ok=false if the secret does not exist and e has a default.
+func (c *Client) resolveEnv(ctx context.Context, t, pid string, e EnvSpec) (value string, ok bool, err error) {
+	if e.Version == "" || e.Version == "latest" {
+		var cached bool
+		value, cached, err = c.cached(ctx, pid, e.Secret)
+		if !cached && err == nil {
+			value, err = c.fetch(ctx, t, pid, e.Secret, fetchOptions{})
+		}
+	} else {
+		var got string
+		value, got, err = c.accessVersion(ctx, t, pid, e.Secret, e.Version)
+		if err == nil {
+			c.emitAudit(ctx, "fetch", pid, e.Secret, got)
+		}
+	}
+	if err != nil {
+		if e.HasDefault && hasStatus(err, http.StatusNotFound) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	value, err = applyTransforms(value, e.Transforms)
+	return value, err == nil, err
+}
+
+// LoadEnv resolves s and sets the resulting environment variables in the
+// current process. Nothing is set unless every variable resolves.
+func (c *Client) LoadEnv(ctx context.Context, s *Spec) error {
+	values, err := c.ResolveSpec(ctx, s)
+	if err != nil {
+		return err
+	}
+	for k, v := range values {
+		if err := os.Setenv(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package gsm
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		want        *Spec
+		errContains string
+	}{
+		{
+			name: "short and long forms",
+			in: `project: my-project
+env:
+  DB_PASSWORD: db-password
+  API_KEY:
+    secret: other-project/api-key
+    version: 3
+    default: ""
+    transform: [trim, base64-decode]
+  TOKEN:
+    secret: token
+    transform: trim
+`,
+			want: &Spec{Project: "my-project", Env: []EnvSpec{
+				{Name: "API_KEY", Project: "other-project", Secret: "api-key", Version: "3", HasDefault: true, Transforms: []string{"trim", "base64-decode"}},
+				{Name: "DB_PASSWORD", Secret: "db-password"},
+				{Name: "TOKEN", Secret: "token", Transforms: []string{"trim"}},
+			}},
+		},
+		{
+			name: "plain default kept as written",
+			in:   "env:\n  PIN:\n    secret: pin\n    default: 0123\n",
+			want: &Spec{Env: []EnvSpec{{Name: "PIN", Secret: "pin", Default: "0123", HasDefault: true}}},
+		},
+		{name: "null default", in: "env:\n  A:\n    secret: a\n    default: ~\n", errContains: "default is null"},
+		{name: "missing env", in: "project: my-project\n", errContains: "env mapping"},
+		{name: "unknown top-level key", in: "env:\n  A: a\nsecrets: x\n", errContains: `unknown spec key "secrets"`},
+		{name: "unknown env key", in: "env:\n  A:\n    secret: a\n    optional: true\n", errContains: `env A: unknown key "optional"`},
+		{name: "invalid variable name", in: "env:\n  1A: a\n", errContains: "invalid environment variable name"},
+		{name: "invalid secret name", in: "env:\n  A: a.b\n", errContains: "invalid secret name format"},
+		{name: "invalid project", in: "env:\n  A: X/a\n", errContains: "invalid project ID format"},
+		{name: "invalid version", in: "env:\n  A:\n    secret: a\n    version: v2\n", errContains: `invalid version "v2"`},
+		{name: "unknown transform", in: "env:\n  A:\n    secret: a\n    transform: [rot13]\n", errContains: `unknown transform "rot13"`},
+		{name: "not a mapping", in: "- a\n", errContains: "spec must be a mapping"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSpec([]byte(tt.in))
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("ParseSpec() error = %v, want error containing %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSpec() unexpected error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSpec() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEnvMapping(t *testing.T) {
+	tests := []struct {
+		in          string
+		want        EnvSpec
+		errContains string
+	}{
+		{in: "DB_PASS=db-password", want: EnvSpec{Name: "DB_PASS", Secret: "db-password"}},
+		{in: "KEY=other-project/api-key#3", want: EnvSpec{Name: "KEY", Project: "other-project", Secret: "api-key", Version: "3"}},
+		{in: "db-password", errContains: "is not NAME=SECRET"},
+		{in: "1A=a", errContains: "env 1A: invalid environment variable name"},
+		{in: "A=a#v2", errContains: `invalid version "v2"`},
+		{in: "A=my-project/b/c", errContains: "invalid secret name format"},
+	}
+	for _, tt := range tests {
+		got, err := ParseEnvMapping(tt.in)
+		if tt.errContains != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("ParseEnvMapping(%q) error = %v, want error containing %q", tt.in, err, tt.errContains)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseEnvMapping(%q) = %+v, %v, want %+v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(r.URL.Path, "/")
+		project, name, version := parts[2], parts[4], strings.TrimSuffix(parts[6], ":access")
+		switch {
+		case name == "missing":
+			w.WriteHeader(http.StatusNotFound)
+		case name == "encoded":
+			writePayload(w, r.URL.Path, " "+base64.StdEncoding.EncodeToString([]byte("decoded"))+"\n")
+		default:
+			writePayload(w, "projects/"+project+"/secrets/"+name+"/versions/1", project+":"+name+":"+version)
+		}
+	})
+
+	path := filepath.Join(t.TempDir(), "secrets.yaml")
+	spec := `env:
+  PLAIN: plain
+  PINNED:
+    secret: other-project/pinned
+    version: 2
+  ENCODED:
+    secret: encoded
+    transform: [trim, base64-decode]
+  FALLBACK:
+    secret: missing
+    default: fallback
+`
+	if err := os.WriteFile(path, []byte(spec), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadSpecFile(path)
+	if err != nil {
+		t.Fatalf("LoadSpecFile() unexpected error = %v", err)
+	}
+
+	for _, e := range s.Env {
+		t.Setenv(e.Name, "")
+	}
+	if err := LoadEnv(context.Background(), s); err != nil {
+		t.Fatalf("LoadEnv() unexpected error = %v", err)
+	}
+	want := map[string]string{
+		"PLAIN":    "test-project:plain:latest",
+		"PINNED":   "other-project:pinned:2",
+		"ENCODED":  "decoded",
+		"FALLBACK": "fallback",
+	}
+	for k, v := range want {
+		if got := os.Getenv(k); got != v {
+			t.Errorf("$%s = %q, want %q", k, got, v)
+		}
+	}
+
+	s.Env = append(s.Env, EnvSpec{Name: "REQUIRED", Secret: "missing"})
+	if _, err := ResolveSpec(context.Background(), s); err == nil || !strings.Contains(err.Error(), "REQUIRED:") {
+		t.Errorf("ResolveSpec() error = %v, want error naming REQUIRED", err)
+	}
+
+	// Errors are ordered by variable name: REQUIRED before REQUIRED2, though
+	// "REQUIRED2:" sorts before "REQUIRED:" as text.
+	s.Env = append(s.Env, EnvSpec{Name: "REQUIRED2", Secret: "missing"})
+	_, err = ResolveSpec(context.Background(), s)
+	if msg := fmt.Sprint(err); strings.Index(msg, "REQUIRED:") > strings.Index(msg, "REQUIRED2:") {
+		t.Errorf("ResolveSpec() error = %v, want REQUIRED reported first", err)
+	}
+}
+
+func TestResolveSpecCached(t *testing.T) {
+	var accesses atomic.Int32
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		accesses.Add(1)
+		if strings.Contains(r.URL.Path, "/secrets/missing/") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writePayload(w, "projects/test-project/secrets/plain/versions/1", "value")
+	})
+
+	ctx := context.Background()
+	c := New(WithCache(time.Hour), WithNegativeCache(time.Hour))
+	s := &Spec{Project: "test-project", Env: []EnvSpec{
+		{Name: "PLAIN", Secret: "plain"},
+		{Name: "FALLBACK", Secret: "missing", Default: "fallback", HasDefault: true},
+	}}
+	for range 3 {
+		values, err := c.ResolveSpec(ctx, s)
+		if err != nil || values["PLAIN"] != "value" || values["FALLBACK"] != "fallback" {
+			t.Fatalf("ResolveSpec() = %v, %v", values, err)
+		}
+	}
+	if n := accesses.Load(); n != 2 {
+		t.Errorf("got %d API accesses, want 2 with caching", n)
+	}
+	if st := c.CacheStats(); st.Hits != 2 || st.NegativeHits != 2 {
+		t.Errorf("CacheStats() = %+v, want 2 hits and 2 negative hits", st)
+	}
+}
@@ -0,0 +1,249 @@
+package gsm
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// StoreOption configures the behavior of Store and StoreInProject.
+type StoreOption func(*storeOptions)
+
+// Replica is a location for a user-managed replication policy, optionally
+// encrypted with a customer-managed Cloud KMS key in the same location.
+type Replica struct {
+	// Location is the canonical ID of the replica's location, e.g. "us-east1".
+	Location string
+	// KMSKey is the resource name of a Cloud KMS CryptoKey in Location, in the form
+	// "projects/*/locations/*/keyRings/*/cryptoKeys/*". Optional.
+	KMSKey string
+}
+
+type storeOptions struct {
+	expireTime     time.Time
+	nextRotation   time.Time
+	kmsKey         string
+	checkpoint     string
+	replicas       []Replica
+	stepTimeouts   map[StoreStep]time.Duration
+	topics         []string
+	version        VersionOptions
+	rotationPeriod time.Duration
+	ttl            time.Duration
+	writeBudget    int
+	verify         bool
+}
+
+// WithVerify reads the newly stored version back through the normal access
+// path and compares checksums before returning, confirming end-to-end
+// write/read integrity. Verification requires secretmanager.versions.access.
+func WithVerify() StoreOption {
+	return func(o *storeOptions) {
+		o.verify = true
+	}
+}
+
+// WithTTL sets a time-to-live on a newly created secret, after which Secret
+// Manager deletes it automatically. It only applies when Store creates the
+// secret; existing secrets keep their expiration. Overrides WithExpireTime.
+func WithTTL(d time.Duration) StoreOption {
+	return func(o *storeOptions) {
+		o.ttl = d
+		o.expireTime = time.Time{}
+	}
+}
+
+// WithExpireTime sets an absolute expiration time on a newly created secret,
+// after which Secret Manager deletes it automatically. It only applies when
+// Store creates the secret; existing secrets keep their expiration.
+// Overrides WithTTL.
+func WithExpireTime(t time.Time) StoreOption {
+	return func(o *storeOptions) {
+		o.expireTime = t
+		o.ttl = 0
+	}
+}
+
+// WithRotation configures a rotation schedule on a newly created secret.
+// Secret Manager publishes SECRET_ROTATE messages to the secret's Pub/Sub
+// topics at next and then every period (if non-zero), so WithTopics is
+// required alongside it. It only applies when Store creates the secret.
+func WithRotation(next time.Time, period time.Duration) StoreOption {
+	return func(o *storeOptions) {
+		o.nextRotation = next
+		o.rotationPeriod = period
+	}
+}
+
+// WithTopics sets the Pub/Sub topics, in the form "projects/*/topics/*", that
+// receive change and rotation notifications for a newly created secret.
+// It only applies when Store creates the secret.
+func WithTopics(topics ...string) StoreOption {
+	return func(o *storeOptions) {
+		o.topics = append(o.topics, topics...)
+	}
+}
+
+// WithKMSKey encrypts a newly created, automatically replicated secret with a
+// customer-managed Cloud KMS key. Keys for automatic replication must be global,
+// in the form "projects/*/locations/global/keyRings/*/cryptoKeys/*".
+// For user-managed replication set Replica.KMSKey instead.
+// It only applies when Store creates the secret.
+func WithKMSKey(key string) StoreOption {
+	return func(o *storeOptions) {
+		o.kmsKey = key
+	}
+}
+
+// WithReplicas creates the secret with a user-managed replication policy
+// instead of automatic replication. It only applies when Store creates the secret.
+func WithReplicas(replicas ...Replica) StoreOption {
+	return func(o *storeOptions) {
+		o.replicas = append(o.replicas, replicas...)
+	}
+}
+
+func newStoreOptions(opts []StoreOption) storeOptions {
+	var o storeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// secretBody returns the Secret resource used when creating a new secret.
+func (o storeOptions) secretBody() map[string]any {
+	body := map[string]any{
+		"replication": o.replication(),
+	}
+	if o.ttl > 0 {
+		body["ttl"] = formatDuration(o.ttl)
+	}
+	if !o.expireTime.IsZero() {
+		body["expireTime"] = o.expireTime.UTC().Format(time.RFC3339Nano)
+	}
+	if !o.nextRotation.IsZero() || o.rotationPeriod > 0 {
+		rotation := map[string]string{}
+		if !o.nextRotation.IsZero() {
+			rotation["nextRotationTime"] = o.nextRotation.UTC().Format(time.RFC3339Nano)
+		}
+		if o.rotationPeriod > 0 {
+			rotation["rotationPeriod"] = formatDuration(o.rotationPeriod)
+		}
+		body["rotation"] = rotation
+	}
+	if len(o.topics) > 0 {
+		topics := make([]map[string]string, len(o.topics))
+		for i, t := range o.topics {
+			topics[i] = map[string]string{"name": t}
+		}
+		body["topics"] = topics
+	}
+	return body
+}
+
+// replication returns the Replication policy for a new secret.
+func (o storeOptions) replication() map[string]any {
+	if len(o.replicas) == 0 {
+		automatic := map[string]any{}
+		if o.kmsKey != "" {
+			automatic["customerManagedEncryption"] = map[string]string{"kmsKeyName": o.kmsKey}
+		}
+		return map[string]any{"automatic": automatic}
+	}
+
+	replicas := make([]map[string]any, len(o.replicas))
+	for i, r := range o.replicas {
+		replica := map[string]any{"location": r.Location}
+		if r.KMSKey != "" {
+			replica["customerManagedEncryption"] = map[string]string{"kmsKeyName": r.KMSKey}
+		}
+		replicas[i] = replica
+	}
+	return map[string]any{"userManaged": map[string]any{"replicas": replicas}}
+}
+
+// formatDuration encodes d in the protobuf JSON Duration format, e.g. "3600s" or "1.5s".
+func formatDuration(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
+
+// StoreStep identifies a step of a Store operation.
+type StoreStep string
+
+// Steps of a Store operation, in the order they run.
+const (
+	StepToken      StoreStep = "token"       // fetching an access token
+	StepCompare    StoreStep = "compare"     // reading the latest version (StoreIfChanged)
+	StepCreate     StoreStep = "create"      // creating the secret if it does not exist
+	StepAddVersion StoreStep = "add version" // adding the new version
+	StepMetadata   StoreStep = "metadata"    // recording version metadata (WithVersionOptions)
+	StepVerify     StoreStep = "verify"      // reading the version back (WithVerify)
+)
+
+// StoreError reports which step of a Store operation failed. If Created is true,
+// the secret was created but holds no new version; pass the error to ResumeStore
+// to finish the operation with only the version add.
+type StoreError struct {
+	Err     error
+	Project string
+	Secret  string
+	Step    StoreStep
+	Created bool
+}
+
+func (e *StoreError) Error() string {
+	if e.Created {
+		return fmt.Sprintf("%s step (secret created): %v", e.Step, e.Err)
+	}
+	return fmt.Sprintf("%s step: %v", e.Step, e.Err)
+}
+
+func (e *StoreError) Unwrap() error {
+	return e.Err
+}
+
+// MaxPayloadSize is the largest secret value, in bytes, that Secret Manager accepts.
+const MaxPayloadSize = 64 * 1024
+
+// PayloadTooLargeError is returned when a value to store exceeds MaxPayloadSize.
+// Values are checked before any request is made.
+type PayloadTooLargeError struct {
+	Secret string
+	// Size is the value's length, or MaxPayloadSize+1 for values read by
+	// StoreFromReader, which stops reading there.
+	Size  int
+	Limit int
+}
+
+func (e *PayloadTooLargeError) Error() string {
+	return fmt.Sprintf("secret %s: value is %d bytes, over the %d-byte limit", e.Secret, e.Size, e.Limit)
+}
+
+// checkPayloadSize returns a *PayloadTooLargeError if value is too large to store.
+func checkPayloadSize(name, value string) error {
+	if len(value) > MaxPayloadSize {
+		return &PayloadTooLargeError{Secret: name, Size: len(value), Limit: MaxPayloadSize}
+	}
+	return nil
+}
+
+// WithStepTimeout bounds a single step of the Store, independent of the other
+// steps. The overall context deadline still applies.
+func WithStepTimeout(step StoreStep, d time.Duration) StoreOption {
+	return func(o *storeOptions) {
+		if o.stepTimeouts == nil {
+			o.stepTimeouts = map[StoreStep]time.Duration{}
+		}
+		o.stepTimeouts[step] = d
+	}
+}
+
+// stepContext returns the context for running step.
+func (o storeOptions) stepContext(ctx context.Context, step StoreStep) (context.Context, context.CancelFunc) {
+	if d := o.stepTimeouts[step]; d > 0 {
+		return context.WithTimeout(ctx, d)
+	}
+	return context.WithCancel(ctx)
+}
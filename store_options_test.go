@@ -0,0 +1,213 @@
+package gsm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStoreWithVerify(t *testing.T) {
+	tests := []struct {
+		name        string
+		readBack    string
+		wantErr     bool
+		errContains string
+	}{
+		{name: "matching checksum", readBack: "secret-value"},
+		{name: "checksum mismatch", readBack: "corrupted", wantErr: true, errContains: "checksum mismatch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var accessedPath string
+			setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Query().Get("secretId") != "":
+					w.WriteHeader(http.StatusConflict)
+				case strings.HasSuffix(r.URL.Path, ":addVersion"):
+					var req struct {
+						Payload struct {
+							Data string `json:"data"`
+						} `json:"payload"`
+					}
+					if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+						t.Errorf("decoding addVersion body: %v", err)
+					}
+					if got, _ := base64.StdEncoding.DecodeString(req.Payload.Data); string(got) != "secret-value" { //nolint:errcheck // compared below
+						t.Errorf("stored payload = %q, want %q", got, "secret-value")
+					}
+					_ = json.NewEncoder(w).Encode(map[string]string{"name": "projects/test-project/secrets/test-secret/versions/7"}) //nolint:errcheck // test mock server
+				case strings.HasSuffix(r.URL.Path, ":access"):
+					accessedPath = r.URL.Path
+					writePayload(w, "projects/test-project/secrets/test-secret/versions/7", tt.readBack)
+				}
+			})
+
+			err := StoreInProject(context.Background(), "test-project", "test-secret", "secret-value", WithVerify())
+			if accessedPath != "/projects/test-project/secrets/test-secret/versions/7:access" {
+				t.Errorf("verification read path = %q, want the newly added version", accessedPath)
+			}
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("StoreInProject() error = %v, want error containing %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("StoreInProject() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestVersionID(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"projects/p/secrets/s/versions/3", "3"},
+		{"projects/p/secrets/s", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := versionID(tt.in); got != tt.want {
+			t.Errorf("versionID(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStoreSecretBody(t *testing.T) {
+	expire := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name string
+		opts []StoreOption
+		want string
+	}{
+		{name: "no expiration", want: `{"replication":{"automatic":{}}}`},
+		{name: "ttl", opts: []StoreOption{WithTTL(time.Hour)}, want: `{"replication":{"automatic":{}},"ttl":"3600s"}`},
+		{name: "fractional ttl", opts: []StoreOption{WithTTL(1500 * time.Millisecond)}, want: `{"replication":{"automatic":{}},"ttl":"1.5s"}`},
+		{name: "expire time", opts: []StoreOption{WithExpireTime(expire)}, want: `{"expireTime":"2030-01-02T03:04:05Z","replication":{"automatic":{}}}`},
+		{
+			name: "rotation and topics",
+			opts: []StoreOption{WithRotation(expire, 30*24*time.Hour), WithTopics("projects/p/topics/a", "projects/p/topics/b")},
+			want: `{"replication":{"automatic":{}},"rotation":{"nextRotationTime":"2030-01-02T03:04:05Z","rotationPeriod":"2592000s"},"topics":[{"name":"projects/p/topics/a"},{"name":"projects/p/topics/b"}]}`,
+		},
+		{
+			name: "automatic replication with cmek",
+			opts: []StoreOption{WithKMSKey("projects/p/locations/global/keyRings/r/cryptoKeys/k")},
+			want: `{"replication":{"automatic":{"customerManagedEncryption":{"kmsKeyName":"projects/p/locations/global/keyRings/r/cryptoKeys/k"}}}}`,
+		},
+		{
+			name: "user-managed replication with cmek",
+			opts: []StoreOption{WithReplicas(
+				Replica{Location: "us-east1", KMSKey: "projects/p/locations/us-east1/keyRings/r/cryptoKeys/k"},
+				Replica{Location: "us-west1"},
+			)},
+			want: `{"replication":{"userManaged":{"replicas":[{"customerManagedEncryption":{"kmsKeyName":"projects/p/locations/us-east1/keyRings/r/cryptoKeys/k"},"location":"us-east1"},{"location":"us-west1"}]}}}`,
+		},
+		{name: "last option wins", opts: []StoreOption{WithExpireTime(expire), WithTTL(time.Minute)}, want: `{"replication":{"automatic":{}},"ttl":"60s"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var captured string
+			setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("secretId") != "" {
+					body, _ := io.ReadAll(r.Body) //nolint:errcheck // compared below
+					captured = string(body)
+					w.WriteHeader(http.StatusCreated)
+					return
+				}
+				_ = json.NewEncoder(w).Encode(map[string]string{"name": "projects/test-project/secrets/test-secret/versions/1"}) //nolint:errcheck // test mock server
+			})
+
+			if err := StoreInProject(context.Background(), "test-project", "test-secret", "v", tt.opts...); err != nil {
+				t.Fatalf("StoreInProject() unexpected error = %v", err)
+			}
+			if captured != tt.want {
+				t.Errorf("create body = %s, want %s", captured, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoreError(t *testing.T) {
+	tests := []struct {
+		name        string
+		createDelay time.Duration
+		createCode  int
+		addCode     int
+		wantStep    StoreStep
+		wantCreated bool
+		wantErr     error
+	}{
+		{name: "create denied", createCode: http.StatusForbidden, wantStep: StepCreate},
+		{name: "created but add failed", createCode: http.StatusOK, addCode: http.StatusForbidden, wantStep: StepAddVersion, wantCreated: true},
+		{name: "existing but add failed", createCode: http.StatusConflict, addCode: http.StatusForbidden, wantStep: StepAddVersion},
+		{name: "create step timeout", createDelay: 200 * time.Millisecond, createCode: http.StatusOK, wantStep: StepCreate, wantErr: context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasSuffix(r.URL.Path, ":addVersion") {
+					w.WriteHeader(tt.addCode)
+					return
+				}
+				select {
+				case <-time.After(tt.createDelay):
+				case <-r.Context().Done():
+					return
+				}
+				w.WriteHeader(tt.createCode)
+			})
+
+			err := StoreInProject(context.Background(), "test-project", "test-secret", "v",
+				WithStepTimeout(StepCreate, 50*time.Millisecond))
+			var se *StoreError
+			if !errors.As(err, &se) {
+				t.Fatalf("StoreInProject() error = %v, want *StoreError", err)
+			}
+			if se.Step != tt.wantStep || se.Created != tt.wantCreated {
+				t.Errorf("StoreError = {Step: %q, Created: %v}, want {Step: %q, Created: %v}", se.Step, se.Created, tt.wantStep, tt.wantCreated)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("StoreInProject() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPayloadTooLarge(t *testing.T) {
+	var requests atomic.Int32
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(`{"name":"projects/test-project/secrets/big/versions/1"}`)) //nolint:errcheck // test mock server
+	})
+
+	ctx := context.Background()
+	big := strings.Repeat("x", MaxPayloadSize+1)
+	err := StoreInProject(ctx, "test-project", "big", big)
+	var pe *PayloadTooLargeError
+	if !errors.As(err, &pe) || pe.Size != MaxPayloadSize+1 || pe.Limit != MaxPayloadSize || pe.Secret != "big" {
+		t.Fatalf("StoreInProject() error = %v, want a PayloadTooLargeError with sizes", err)
+	}
+	results, err := StoreManyInProject(ctx, "test-project", map[string]string{"big": big})
+	if err != nil || !errors.As(results["big"], &pe) {
+		t.Errorf("StoreManyInProject() = %v, %v, want a PayloadTooLargeError for big", results, err)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("API requests = %d, want none", n)
+	}
+
+	if err := StoreInProject(ctx, "test-project", "big", big[:MaxPayloadSize]); err != nil {
+		t.Errorf("StoreInProject() at the limit error = %v", err)
+	}
+}
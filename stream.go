@@ -0,0 +1,107 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FetchReader retrieves the latest version of a secret from the current
+// project using the default client, as a reader over its value.
+// The project ID is auto-detected from the GCP metadata server.
+func FetchReader(ctx context.Context, name string, opts ...FetchOption) (io.ReadCloser, error) {
+	return defaultClient.FetchReader(ctx, name, opts...)
+}
+
+// FetchReaderFromProject retrieves the latest version of a secret from a
+// specific project using the default client, as a reader over its value.
+func FetchReaderFromProject(ctx context.Context, pid, name string, opts ...FetchOption) (io.ReadCloser, error) {
+	return defaultClient.FetchReaderFromProject(ctx, pid, name, opts...)
+}
+
+// StoreFromReader stores the contents of r as a secret in the current project
+// using the default client. See Client.StoreFromReader.
+// The project ID is auto-detected from the GCP metadata server.
+func StoreFromReader(ctx context.Context, name string, r io.Reader, opts ...StoreOption) error {
+	return defaultClient.StoreFromReader(ctx, name, r, opts...)
+}
+
+// StoreFromReaderInProject stores the contents of r as a secret in a specific
+// project using the default client.
+func StoreFromReaderInProject(ctx context.Context, pid, name string, r io.Reader, opts ...StoreOption) error {
+	return defaultClient.StoreFromReaderInProject(ctx, pid, name, r, opts...)
+}
+
+// FetchReader is Fetch returning a reader over the value, for piping it to a
+// file or process with io.Copy. The reader shares the fetched value rather
+// than copying it. It implements io.Seeker and io.ReaderAt as well, for callers
+// that type-assert for them.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) FetchReader(ctx context.Context, name string, opts ...FetchOption) (io.ReadCloser, error) {
+	value, err := c.Fetch(ctx, name, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &secretReader{Reader: strings.NewReader(value)}, nil
+}
+
+// FetchReaderFromProject is FetchFromProject returning a reader over the value.
+func (c *Client) FetchReaderFromProject(ctx context.Context, pid, name string, opts ...FetchOption) (io.ReadCloser, error) {
+	value, err := c.FetchFromProject(ctx, pid, name, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &secretReader{Reader: strings.NewReader(value)}, nil
+}
+
+// secretReader is a fetched value. It supports Seek and ReadAt as well as Read.
+type secretReader struct {
+	*strings.Reader
+}
+
+func (*secretReader) Close() error { return nil }
+
+// StoreFromReader is Store with the value read from r until EOF, for piping
+// it from a file or process. The value is read into memory in full and then
+// stored as Store would store it, so it is held in memory more than once while
+// the request is built. r is read at most one byte past
+// MaxPayloadSize, so a larger value fails with a *PayloadTooLargeError without
+// being read in full. The name is validated before r is read.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) StoreFromReader(ctx context.Context, name string, r io.Reader, opts ...StoreOption) error {
+	if !secretNameRegex.MatchString(name) {
+		return errors.New("invalid secret name format")
+	}
+	value, err := readValue(r)
+	if err != nil {
+		return err
+	}
+	return c.Store(ctx, name, value, opts...)
+}
+
+// StoreFromReaderInProject is StoreInProject with the value read from r until EOF.
+func (c *Client) StoreFromReaderInProject(ctx context.Context, pid, name string, r io.Reader, opts ...StoreOption) error {
+	if !projectIDRegex.MatchString(pid) {
+		return fmt.Errorf("invalid project ID format: %q", pid)
+	}
+	if !secretNameRegex.MatchString(name) {
+		return errors.New("invalid secret name format")
+	}
+	value, err := readValue(r)
+	if err != nil {
+		return err
+	}
+	return c.StoreInProject(ctx, pid, name, value, opts...)
+}
+
+// readValue reads a value to store from r, stopping one byte past
+// MaxPayloadSize so that oversized values fail checkPayloadSize.
+func readValue(r io.Reader) (string, error) {
+	var b strings.Builder
+	if _, err := io.Copy(&b, io.LimitReader(r, MaxPayloadSize+1)); err != nil {
+		return "", fmt.Errorf("reading value: %w", err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,101 @@
+package gsm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestStreaming(t *testing.T) {
+	fake := &fakeProjects{values: map[string]string{}}
+	setupFakes(t, fake.ServeHTTP)
+	ctx := context.Background()
+
+	value := strings.Repeat("0123456789abcdef", MaxPayloadSize/16)
+	if err := StoreFromReader(ctx, "big", strings.NewReader(value)); err != nil {
+		t.Fatalf("StoreFromReader() error = %v", err)
+	}
+	if err := StoreFromReaderInProject(ctx, "other-project", "small", strings.NewReader("v")); err != nil {
+		t.Fatalf("StoreFromReaderInProject() error = %v", err)
+	}
+
+	r, err := FetchReader(ctx, "big")
+	if err != nil {
+		t.Fatalf("FetchReader() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, r); err != nil || buf.String() != value {
+		t.Errorf("FetchReader() read %d bytes, %v; want %d", buf.Len(), err, len(value))
+	}
+	r.Close() //nolint:errcheck,gosec // no-op close
+
+	r, err = FetchReaderFromProject(ctx, "other-project", "small")
+	if err != nil {
+		t.Fatalf("FetchReaderFromProject() error = %v", err)
+	}
+	if got, err := io.ReadAll(r); err != nil || string(got) != "v" {
+		t.Errorf("FetchReaderFromProject() = %q, %v", got, err)
+	}
+
+	// The reader supports seeking and reading at offsets.
+	if _, ok := r.(io.Seeker); !ok {
+		t.Errorf("FetchReaderFromProject() reader %T does not implement io.Seeker", r)
+	}
+	ra, ok := r.(io.ReaderAt)
+	if !ok {
+		t.Fatalf("FetchReaderFromProject() reader %T does not implement io.ReaderAt", r)
+	}
+	b := make([]byte, 1)
+	if n, err := ra.ReadAt(b, 0); n != 1 || string(b) != "v" {
+		t.Errorf("ReadAt(0) = %d, %q, %v; want 1, %q", n, b, err, "v")
+	}
+
+	// Oversized values fail without being read past the limit.
+	over := &countingReader{r: strings.NewReader(value + strings.Repeat("x", 1<<20))}
+	var tooLarge *PayloadTooLargeError
+	if err := StoreFromReader(ctx, "huge", over); !errors.As(err, &tooLarge) {
+		t.Errorf("StoreFromReader(huge) error = %v, want *PayloadTooLargeError", err)
+	}
+	if over.n > MaxPayloadSize+1 {
+		t.Errorf("StoreFromReader(huge) read %d bytes, want at most %d", over.n, MaxPayloadSize+1)
+	}
+	if _, ok := fake.values["test-project/huge"]; ok {
+		t.Error("StoreFromReader(huge) created the secret")
+	}
+
+	// A bad name fails before the reader is consumed.
+	unread := &countingReader{r: strings.NewReader("v")}
+	if err := StoreFromReader(ctx, "bad name", unread); err == nil || unread.n != 0 {
+		t.Errorf("StoreFromReader(bad name) error = %v after reading %d bytes", err, unread.n)
+	}
+
+	readErr := errors.New("pipe broken")
+	if err := StoreFromReader(ctx, "broken", io.MultiReader(strings.NewReader("partial"), &errReader{readErr})); !errors.Is(err, readErr) {
+		t.Errorf("StoreFromReader(broken) error = %v, want %v", err, readErr)
+	}
+	if _, ok := fake.values["test-project/broken"]; ok {
+		t.Error("StoreFromReader(broken) stored a partial value")
+	}
+
+	if _, err := FetchReader(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("FetchReader(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+type errReader struct{ err error }
+
+func (e *errReader) Read([]byte) (int, error) { return 0, e.err }
@@ -0,0 +1,259 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// SyncConfig describes a set of secrets to mirror from one project to others.
+type SyncConfig struct {
+	// Source is the project secrets are read from.
+	Source string
+	// Destinations are the projects secrets are mirrored to.
+	Destinations []string
+	// Secrets names the secrets to mirror. If empty, every source secret
+	// matching Filter is mirrored.
+	Secrets []string
+	// Filter is a Secret Manager list filter selecting the secrets to mirror when
+	// Secrets is empty, e.g. "labels.mirror=true". Empty selects every secret.
+	Filter string
+	// Delete removes destination secrets that are no longer in the source: named
+	// secrets missing from the source, or, with Filter, destination secrets matching
+	// Filter that the source doesn't have.
+	Delete bool
+	// DryRun reports what would change without writing anything.
+	DryRun bool
+}
+
+// SyncAction is what a sync did, or with DryRun would do, to one destination secret.
+type SyncAction string
+
+// Sync actions.
+const (
+	SyncUnchanged SyncAction = "unchanged"
+	SyncUpdated   SyncAction = "updated" // created, or a new version added
+	SyncDeleted   SyncAction = "deleted"
+	SyncFailed    SyncAction = "failed"
+)
+
+// SyncResult is the outcome for one secret in one destination project.
+type SyncResult struct {
+	Err         error
+	Destination string
+	Secret      string
+	Action      SyncAction
+}
+
+// SyncReport is the status of one sync pass.
+type SyncReport struct {
+	Time    time.Time
+	Results []SyncResult
+	DryRun  bool
+}
+
+// Err returns the errors of every failed result, or nil if none failed.
+func (r *SyncReport) Err() error {
+	var errs []error
+	for _, res := range r.Results {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("%s/%s: %w", res.Destination, res.Secret, res.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SyncOnce mirrors secrets once using the default client. See Client.SyncOnce.
+func SyncOnce(ctx context.Context, cfg SyncConfig) (*SyncReport, error) {
+	return defaultClient.SyncOnce(ctx, cfg)
+}
+
+// Sync mirrors secrets every interval using the default client. See Client.Sync.
+func Sync(ctx context.Context, cfg SyncConfig, interval time.Duration, report func(*SyncReport)) error {
+	return defaultClient.Sync(ctx, cfg, interval, report)
+}
+
+// Sync runs SyncOnce immediately and then every interval until ctx is done,
+// passing each report to report if it is non-nil. A pass that fails outright
+// (e.g. the source can't be listed) is logged and retried on the next interval.
+// It returns ctx's error.
+func (c *Client) Sync(ctx context.Context, cfg SyncConfig, interval time.Duration, report func(*SyncReport)) error {
+	if interval <= 0 {
+		return errors.New("sync interval must be positive")
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		r, err := c.SyncOnce(ctx, cfg)
+		switch {
+		case err != nil:
+			c.log().Warn("secret sync failed", "source", cfg.Source, "error", err)
+		case report != nil:
+			report(r)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// SyncOnce mirrors the configured secrets from the source project to each
+// destination: missing secrets are created, and a new version is added wherever
+// the destination's latest value differs from the source's. Only latest values
+// are mirrored, not metadata or version history. Per-secret failures are
+// reported in the result rather than returned; see SyncReport.Err.
+func (c *Client) SyncOnce(ctx context.Context, cfg SyncConfig) (*SyncReport, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	tok, err := c.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := append([]string(nil), cfg.Secrets...)
+	if len(names) == 0 {
+		secrets, err := c.listSecrets(ctx, tok, cfg.Source, cfg.Filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range secrets {
+			names = append(names, s.Name)
+		}
+	}
+	slices.Sort(names)
+
+	// Read each source value once. Secrets without an accessible latest version
+	// are skipped; secrets that no longer exist are candidates for deletion.
+	values := map[string]string{}
+	var removed []string
+	for _, name := range names {
+		v, _, err := c.accessVersion(ctx, tok, cfg.Source, name, "latest")
+		switch {
+		case noVersion(err):
+			u := fmt.Sprintf("%s/projects/%s/secrets/%s", c.apiEndpoint(), cfg.Source, name)
+			err := c.call(ctx, tok, "get secret", http.MethodGet, u, nil, nil)
+			if hasStatus(err, http.StatusNotFound) {
+				removed = append(removed, name)
+			} else if err != nil {
+				return nil, fmt.Errorf("reading %s/%s: %w", cfg.Source, name, err)
+			}
+		case err != nil:
+			return nil, fmt.Errorf("reading %s/%s: %w", cfg.Source, name, err)
+		default:
+			values[name] = v
+		}
+	}
+
+	r := &SyncReport{Time: time.Now(), DryRun: cfg.DryRun}
+	for _, dest := range cfg.Destinations {
+		for _, name := range names {
+			if v, ok := values[name]; ok {
+				r.Results = append(r.Results, c.syncSecret(ctx, tok, dest, name, v, cfg.DryRun))
+			}
+		}
+		if !cfg.Delete {
+			continue
+		}
+
+		stale := removed
+		if len(cfg.Secrets) == 0 {
+			existing, err := c.listSecrets(ctx, tok, dest, cfg.Filter)
+			if err != nil {
+				r.Results = append(r.Results, SyncResult{Destination: dest, Action: SyncFailed, Err: err})
+				continue
+			}
+			stale = nil
+			for _, s := range existing {
+				if _, ok := values[s.Name]; !ok {
+					stale = append(stale, s.Name)
+				}
+			}
+			slices.Sort(stale)
+		}
+		for _, name := range stale {
+			r.Results = append(r.Results, c.syncDelete(ctx, tok, dest, name, cfg.DryRun))
+		}
+	}
+	return r, nil
+}
+
+// syncSecret brings one destination secret up to date with value.
+func (c *Client) syncSecret(ctx context.Context, tok, dest, name, value string, dryRun bool) SyncResult {
+	res := SyncResult{Destination: dest, Secret: name, Action: SyncUnchanged}
+
+	current, _, err := c.accessVersion(ctx, tok, dest, name, "latest")
+	switch {
+	case err == nil && current == value:
+		return res
+	case err != nil && !hasStatus(err, http.StatusNotFound):
+		res.Action, res.Err = SyncFailed, err
+		return res
+	}
+
+	res.Action = SyncUpdated
+	if dryRun {
+		return res
+	}
+	if _, err := c.storeWithToken(ctx, tok, dest, name, value, storeOptions{}, storeAlways); err != nil {
+		res.Action, res.Err = SyncFailed, err
+		return res
+	}
+	c.log().Info("synced secret", "destination", dest, "secret", name)
+	return res
+}
+
+// syncDelete removes a destination secret that is no longer in the source.
+func (c *Client) syncDelete(ctx context.Context, tok, dest, name string, dryRun bool) SyncResult {
+	res := SyncResult{Destination: dest, Secret: name, Action: SyncDeleted}
+	if dryRun {
+		return res
+	}
+
+	u := fmt.Sprintf("%s/projects/%s/secrets/%s", c.apiEndpoint(), dest, name)
+	err := c.call(ctx, tok, "delete secret", http.MethodDelete, u, nil, nil)
+	switch {
+	case hasStatus(err, http.StatusNotFound):
+		res.Action = SyncUnchanged
+	case err != nil:
+		res.Action, res.Err = SyncFailed, err
+	default:
+		c.invalidateCached(dest, name)
+		c.log().Info("deleted synced secret", "destination", dest, "secret", name)
+	}
+	return res
+}
+
+func (cfg *SyncConfig) validate() error {
+	if !projectIDRegex.MatchString(cfg.Source) {
+		return fmt.Errorf("invalid project ID format: %q", cfg.Source)
+	}
+	if len(cfg.Destinations) == 0 {
+		return errors.New("sync requires at least one destination project")
+	}
+	for _, d := range cfg.Destinations {
+		if !projectIDRegex.MatchString(d) {
+			return fmt.Errorf("invalid project ID format: %q", d)
+		}
+		if d == cfg.Source {
+			return fmt.Errorf("destination %q is the source project", d)
+		}
+	}
+	for _, name := range cfg.Secrets {
+		if !secretNameRegex.MatchString(name) {
+			return errors.New("invalid secret name format")
+		}
+	}
+	return nil
+}
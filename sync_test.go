@@ -0,0 +1,140 @@
+package gsm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeProjects is an in-memory Secret Manager holding the latest value of each
+// secret, keyed by "project/name".
+type fakeProjects struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func (f *fakeProjects) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/") // projects/P/secrets[/NAME[/versions/V:access]]
+	project := parts[1]
+	switch {
+	case len(parts) == 3 && r.Method == http.MethodGet:
+		var secrets []map[string]string
+		for k := range f.values {
+			if p, n, _ := strings.Cut(k, "/"); p == project {
+				secrets = append(secrets, map[string]string{"name": "projects/" + p + "/secrets/" + n})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"secrets": secrets}) //nolint:errcheck // test mock server
+	case len(parts) == 3 && r.Method == http.MethodPost:
+		key := project + "/" + r.URL.Query().Get("secretId")
+		if _, ok := f.values[key]; ok {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.values[key] = ""
+		_, _ = w.Write([]byte(`{}`)) //nolint:errcheck // test mock server
+	case len(parts) == 4 && strings.HasSuffix(parts[3], ":addVersion"):
+		var body struct {
+			Payload struct {
+				Data string `json:"data"`
+			} `json:"payload"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)                     //nolint:errcheck // test mock server
+		data, _ := base64.StdEncoding.DecodeString(body.Payload.Data) //nolint:errcheck // test mock server
+		f.values[project+"/"+strings.TrimSuffix(parts[3], ":addVersion")] = string(data)
+		_, _ = w.Write([]byte(`{"name":"projects/` + project + `/secrets/x/versions/2"}`)) //nolint:errcheck // test mock server
+	default:
+		v, ok := f.values[project+"/"+parts[3]]
+		switch {
+		case !ok:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodDelete:
+			delete(f.values, project+"/"+parts[3])
+			_, _ = w.Write([]byte(`{}`)) //nolint:errcheck // test mock server
+		case len(parts) == 4:
+			_, _ = w.Write([]byte(`{"name":"projects/` + project + `/secrets/` + parts[3] + `"}`)) //nolint:errcheck // test mock server
+		default:
+			writePayload(w, "projects/"+project+"/secrets/"+parts[3]+"/versions/1", v)
+		}
+	}
+}
+
+func TestSyncOnce(t *testing.T) {
+	fake := &fakeProjects{values: map[string]string{
+		"source-project/a":    "a1",
+		"source-project/b":    "b1",
+		"dest-project-1/a":    "a1",
+		"dest-project-1/b":    "old",
+		"dest-project-1/gone": "x",
+	}}
+	setupFakes(t, fake.ServeHTTP)
+
+	ctx := context.Background()
+	cfg := SyncConfig{Source: "source-project", Destinations: []string{"dest-project-1", "dest-project-2"}, Delete: true, DryRun: true}
+
+	r, err := SyncOnce(ctx, cfg)
+	if err != nil {
+		t.Fatalf("SyncOnce() unexpected error = %v", err)
+	}
+	want := []string{
+		"dest-project-1/a unchanged", "dest-project-1/b updated", "dest-project-1/gone deleted",
+		"dest-project-2/a updated", "dest-project-2/b updated",
+	}
+	if got := syncSummary(r); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("dry run results = %v, want %v", got, want)
+	}
+	if fake.values["dest-project-1/b"] != "old" || fake.values["dest-project-1/gone"] != "x" {
+		t.Error("dry run modified destination secrets")
+	}
+
+	cfg.DryRun = false
+	r, err = SyncOnce(ctx, cfg)
+	if err != nil || r.Err() != nil {
+		t.Fatalf("SyncOnce() unexpected error = %v, %v", err, r.Err())
+	}
+	if got := syncSummary(r); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("results = %v, want %v", got, want)
+	}
+	for _, k := range []string{"dest-project-1/b", "dest-project-2/b"} {
+		if fake.values[k] != "b1" {
+			t.Errorf("%s = %q, want b1", k, fake.values[k])
+		}
+	}
+	if _, ok := fake.values["dest-project-1/gone"]; ok {
+		t.Error("stale destination secret was not deleted")
+	}
+
+	// Named secrets: only those listed are touched, and a secret removed from
+	// the source is deleted from destinations.
+	delete(fake.values, "source-project/a")
+	fake.values["dest-project-2/other"] = "keep"
+	r, err = SyncOnce(ctx, SyncConfig{Source: "source-project", Destinations: []string{"dest-project-2"}, Secrets: []string{"a", "b"}, Delete: true})
+	if err != nil {
+		t.Fatalf("SyncOnce() unexpected error = %v", err)
+	}
+	if got, want := strings.Join(syncSummary(r), ","), "dest-project-2/b unchanged,dest-project-2/a deleted"; got != want {
+		t.Errorf("named results = %v, want %v", got, want)
+	}
+	if fake.values["dest-project-2/other"] != "keep" {
+		t.Error("unnamed destination secret was modified")
+	}
+
+	if _, err := SyncOnce(ctx, SyncConfig{Source: "source-project", Destinations: []string{"source-project"}}); err == nil {
+		t.Error("SyncOnce() into the source project succeeded, want error")
+	}
+}
+
+func syncSummary(r *SyncReport) []string {
+	var s []string
+	for _, res := range r.Results {
+		s = append(s, res.Destination+"/"+res.Secret+" "+string(res.Action))
+	}
+	return s
+}
@@ -0,0 +1,77 @@
+package gsm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupFakes starts a fake metadata server (project "test-project", token "test-token",
+// instance ID "1234567890", identity tokens from fakeIdentityToken)
+// and a fake Secret Manager API backed by api, pointing the package at both for the
+// duration of the test.
+func setupFakes(t testing.TB, api http.HandlerFunc) {
+	t.Helper()
+
+	metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/project/project-id"):
+			_, _ = w.Write([]byte("test-project")) //nolint:errcheck // test mock server
+		case strings.HasSuffix(r.URL.Path, "/instance/id"):
+			_, _ = w.Write([]byte("1234567890")) //nolint:errcheck // test mock server
+		case strings.HasSuffix(r.URL.Path, "/service-accounts/default/email"):
+			_, _ = w.Write([]byte("sa@test-project.iam.gserviceaccount.com\n")) //nolint:errcheck // test mock server
+		case strings.HasSuffix(r.URL.Path, "/identity"):
+			_, _ = w.Write([]byte(fakeIdentityToken(r.URL.Query().Get("audience")))) //nolint:errcheck // test mock server
+		case strings.Contains(r.URL.Path, "/token"):
+			_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "test-token"}) //nolint:errcheck // test mock server
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(metadataServer.Close)
+
+	apiServer := httptest.NewServer(api)
+	t.Cleanup(apiServer.Close)
+
+	oldMetadataURL, oldAPIURL, oldRetryDelay := metadataURL, apiURL, retryDelay
+	t.Cleanup(func() {
+		metadataURL, apiURL, retryDelay = oldMetadataURL, oldAPIURL, oldRetryDelay
+	})
+	metadataURL, apiURL, retryDelay = metadataServer.URL, apiServer.URL, 10*time.Millisecond
+}
+
+// writePayload writes a Secret Manager access response containing value.
+func writePayload(w http.ResponseWriter, name, value string) {
+	_ = json.NewEncoder(w).Encode(map[string]any{ //nolint:errcheck // test mock server
+		"name":    name,
+		"payload": map[string]string{"data": base64.StdEncoding.EncodeToString([]byte(value))},
+	})
+}
+
+// fakeIdentityToken returns an unsigned metadata-server-style identity token for audience.
+func fakeIdentityToken(audience string) string {
+	claims, _ := json.Marshal(map[string]any{ //nolint:errcheck // static claims always marshal
+		"aud":   audience,
+		"email": "sa@test-project.iam.gserviceaccount.com",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iss":   "https://accounts.google.com",
+		"sub":   "1001",
+		"google": map[string]any{"compute_engine": map[string]any{
+			"instance_id":   "1234567890",
+			"instance_name": "test-vm",
+			"project_id":    "test-project",
+			"zone":          "us-central1-a",
+		}},
+	})
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"RS256"}`)) + "." + enc.EncodeToString(claims) + "." + enc.EncodeToString([]byte("sig"))
+}
@@ -0,0 +1,97 @@
+package gsm
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrPinMismatch is returned (wrapped) when the Secret Manager endpoint presents
+// no certificate matching the keys pinned with WithPinnedKeys.
+var ErrPinMismatch = errors.New("certificate pin mismatch")
+
+// WithMinTLSVersion refuses connections to the Secret Manager endpoint below
+// version v, e.g. tls.VersionTLS13, regardless of the system defaults.
+func WithMinTLSVersion(v uint16) Option {
+	return func(c *Client) {
+		c.tlsMinVersion = v
+	}
+}
+
+// WithPinnedKeys accepts the Secret Manager endpoint only if some certificate
+// in its verified chain has one of the given public keys, each the base64
+// SHA-256 of the certificate's SubjectPublicKeyInfo, optionally prefixed with
+// "sha256/" as in HPKP. The usual chain verification still applies. Pin an
+// intermediate or root key, with a backup, rather than the leaf, which Google
+// rotates frequently. Pins don't apply to the metadata server or an emulator.
+//
+// A pin can be computed with:
+//
+//	openssl x509 -in cert.pem -pubkey -noout | openssl pkey -pubin -outform der |
+//	  openssl dgst -sha256 -binary | base64
+func WithPinnedKeys(hashes ...string) Option {
+	return func(c *Client) {
+		for _, h := range hashes {
+			c.tlsPins = append(c.tlsPins, strings.TrimPrefix(h, "sha256/"))
+		}
+	}
+}
+
+// WithTLSConfig uses a copy of cfg for TLS connections to the Secret Manager
+// and OAuth token endpoints, e.g. with RootCAs holding a TLS-intercepting
+// proxy's CA, or with Certificates or GetClientCertificate for mutual TLS.
+// For mTLS, also set WithEndpoint("https://secretmanager.mtls.googleapis.com/v1").
+// WithMinTLSVersion and WithPinnedKeys apply on top of cfg. It configures a
+// copy of the HTTP client's transport, like WithMinTLSVersion.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *Client) {
+		c.tlsConfig = cfg.Clone()
+	}
+}
+
+// apiHTTP returns the HTTP client for Secret Manager API requests.
+func (c *Client) apiHTTP() *http.Client {
+	if c.apiClient != nil {
+		return c.apiClient
+	}
+	return c.baseHTTP()
+}
+
+// hardenedClient returns a copy of the client's HTTP client enforcing its
+// minimum TLS version and key pins.
+func (c *Client) hardenedClient() *http.Client {
+	return withTransport(c.baseHTTP(), func(t *http.Transport) {
+		cfg := t.TLSClientConfig
+		cfg.MinVersion = max(cfg.MinVersion, c.tlsMinVersion)
+		if len(c.tlsPins) > 0 {
+			pins, verify := c.tlsPins, cfg.VerifyConnection
+			cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+				if verify != nil {
+					if err := verify(cs); err != nil {
+						return err
+					}
+				}
+				return verifyPins(cs, pins)
+			}
+		}
+	})
+}
+
+// verifyPins checks that a verified chain of cs contains a pinned key.
+func verifyPins(cs tls.ConnectionState, pins []string) error {
+	for _, chain := range cs.VerifiedChains {
+		for _, cert := range chain {
+			sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+			got := base64.StdEncoding.EncodeToString(sum[:])
+			for _, pin := range pins {
+				if got == pin {
+					return nil
+				}
+			}
+		}
+	}
+	return ErrPinMismatch
+}
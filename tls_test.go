@@ -0,0 +1,112 @@
+package gsm
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHardenedTLS(t *testing.T) {
+	setupFakes(t, nil)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writePayload(w, "projects/test-project/secrets/s/versions/1", "value")
+	})
+	tls12 := httptest.NewUnstartedServer(handler)
+	tls12.TLS = &tls.Config{MaxVersion: tls.VersionTLS12} //nolint:gosec // simulates a legacy endpoint
+	tls12.StartTLS()
+	t.Cleanup(tls12.Close)
+	tls13 := httptest.NewTLSServer(handler)
+	t.Cleanup(tls13.Close)
+
+	// Trust the test servers' certificate, as the system roots trust Google's.
+	oldClient := httpClient
+	t.Cleanup(func() { httpClient = oldClient })
+	httpClient = tls13.Client()
+
+	sum := sha256.Sum256(tls13.Certificate().RawSubjectPublicKeyInfo)
+	pin := base64.StdEncoding.EncodeToString(sum[:])
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		server  *httptest.Server
+		opts    []Option
+		wantErr error
+	}{
+		{name: "tls 1.2 allowed by default", server: tls12},
+		{name: "tls 1.2 refused", server: tls12, opts: []Option{WithMinTLSVersion(tls.VersionTLS13)}, wantErr: errAny},
+		{name: "tls 1.3 required", server: tls13, opts: []Option{WithMinTLSVersion(tls.VersionTLS13)}},
+		{name: "pinned key", server: tls13, opts: []Option{WithPinnedKeys("sha256/AAAA", "sha256/"+pin)}},
+		{name: "pin mismatch", server: tls13, opts: []Option{WithPinnedKeys(base64.StdEncoding.EncodeToString(make([]byte, 32)))}, wantErr: ErrPinMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(append(tt.opts, WithEndpoint(tt.server.URL), WithAPIRetry(RetryPolicy{Attempts: 1}))...)
+			v, err := c.FetchFromProject(ctx, "test-project", "s")
+			switch {
+			case tt.wantErr == nil && (err != nil || v != "value"):
+				t.Errorf("FetchFromProject() = %q, %v, want value", v, err)
+			case tt.wantErr == errAny && err == nil:
+				t.Error("FetchFromProject() succeeded, want error")
+			case tt.wantErr != nil && tt.wantErr != errAny && !errors.Is(err, tt.wantErr):
+				t.Errorf("FetchFromProject() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// errAny matches any error in table tests.
+var errAny = errors.New("any error")
+
+func TestWithTLSConfig(t *testing.T) {
+	setupFakes(t, nil)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		writePayload(w, "projects/test-project/secrets/s/versions/1", "value")
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequestClientCert} //nolint:gosec // test server
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	// The test server's CA stands in for a TLS-intercepting proxy's.
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		cfg     *tls.Config
+		wantErr bool
+	}{
+		{name: "untrusted", wantErr: true},
+		{name: "no client certificate", cfg: &tls.Config{RootCAs: roots}, wantErr: true},     //nolint:gosec // test config
+		{name: "mtls", cfg: &tls.Config{RootCAs: roots, Certificates: srv.TLS.Certificates}}, //nolint:gosec // test config
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []Option{WithEndpoint(srv.URL), WithAPIRetry(RetryPolicy{Attempts: 1}), WithMinTLSVersion(tls.VersionTLS12)}
+			if tt.cfg != nil {
+				opts = append(opts, WithTLSConfig(tt.cfg))
+			}
+			v, err := New(opts...).FetchFromProject(ctx, "test-project", "s")
+			if tt.wantErr {
+				if err == nil {
+					t.Error("FetchFromProject() succeeded, want error")
+				}
+				return
+			}
+			if err != nil || v != "value" {
+				t.Errorf("FetchFromProject() = %q, %v, want value", v, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package gsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// tokenRefreshMargin is how long before expiry a cached access token is refreshed.
+const tokenRefreshMargin = 3 * time.Minute
+
+// TokenCache stores access tokens so that clients, including clients in other
+// processes on the same host, can share one token instead of each fetching
+// their own from the metadata server. Implementations must be safe for concurrent use.
+type TokenCache interface {
+	// Get returns the token stored under key and its expiry, or ok=false if there is none.
+	Get(ctx context.Context, key string) (token string, expires time.Time, ok bool)
+	// Put stores token under key until expires.
+	Put(ctx context.Context, key, token string, expires time.Time) error
+}
+
+// WithTokenCache shares access tokens through cache. Tokens are reused until
+// a few minutes before they expire. See FileTokenCache for a cache that fleets of
+// short-lived processes on one host can share.
+func WithTokenCache(cache TokenCache) Option {
+	return func(c *Client) {
+		c.tokenCache = cache
+	}
+}
+
+// tokenCacheKey identifies the credentials and scopes a cached token belongs to.
+func (c *Client) tokenCacheKey() string {
+	return c.metadataEndpoint() + c.metadataTokenPath()
+}
+
+// metadataTokenPath is the metadata server path of the client's access token.
+func (c *Client) metadataTokenPath() string {
+	p := c.serviceAccountPath() + "/token"
+	if len(c.scopes) > 0 {
+		p += "?" + url.Values{"scopes": {strings.Join(c.scopes, ",")}}.Encode()
+	}
+	return p
+}
+
+// cachedToken returns a token from the client's token cache that is not about to expire.
+func (c *Client) cachedToken(ctx context.Context) (string, bool) {
+	if c.tokenCache == nil {
+		return "", false
+	}
+	t, expires, ok := c.tokenCache.Get(ctx, c.tokenCacheKey())
+	if !ok || t == "" || time.Until(expires) < tokenRefreshMargin {
+		return "", false
+	}
+	return t, true
+}
+
+// FileTokenCache is a TokenCache backed by a JSON file readable only by its owner
+// (mode 0600). Access is serialized between processes with an advisory lock on
+// PATH.lock where the platform supports it, and updates replace the file atomically.
+type FileTokenCache struct {
+	path string
+}
+
+type fileTokenEntry struct {
+	Expires time.Time `json:"expires"`
+	Token   string    `json:"token"`
+}
+
+// NewFileTokenCache returns a FileTokenCache stored at path.
+// The file and its lock are created on first use.
+func NewFileTokenCache(path string) *FileTokenCache {
+	return &FileTokenCache{path: path}
+}
+
+// Get implements TokenCache. Unreadable or corrupt cache files are treated as empty.
+func (fc *FileTokenCache) Get(_ context.Context, key string) (token string, expires time.Time, ok bool) {
+	unlock, err := lockFile(fc.path+".lock", false)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	defer unlock()
+
+	entries, err := fc.read()
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	e, ok := entries[key]
+	return e.Token, e.Expires, ok
+}
+
+// Put implements TokenCache. Expired entries for other keys are dropped.
+func (fc *FileTokenCache) Put(_ context.Context, key, token string, expires time.Time) error {
+	unlock, err := lockFile(fc.path+".lock", true)
+	if err != nil {
+		return fmt.Errorf("failed to lock token cache: %w", err)
+	}
+	defer unlock()
+
+	entries, err := fc.read()
+	if err != nil {
+		entries = map[string]fileTokenEntry{}
+	}
+	now := time.Now()
+	for k, e := range entries {
+		if now.After(e.Expires) {
+			delete(entries, k)
+		}
+	}
+	entries[key] = fileTokenEntry{Token: token, Expires: expires}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(fc.path, data)
+}
+
+func (fc *FileTokenCache) read() (map[string]fileTokenEntry, error) {
+	data, err := os.ReadFile(fc.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]fileTokenEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries map[string]fileTokenEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = map[string]fileTokenEntry{}
+	}
+	return entries, nil
+}
+
+// writeFileAtomic replaces the file at path with data, readable only by its owner.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp) //nolint:errcheck // best effort cleanup; fails harmlessly after rename
+
+	if err := f.Chmod(0o600); err != nil {
+		f.Close() //nolint:errcheck,gosec // already failing
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close() //nolint:errcheck,gosec // already failing
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
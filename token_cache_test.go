@@ -0,0 +1,123 @@
+package gsm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFileTokenCache(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	fc := NewFileTokenCache(path)
+
+	if _, _, ok := fc.Get(ctx, "k"); ok {
+		t.Fatal("Get() on missing file returned ok")
+	}
+
+	expires := time.Now().Add(time.Hour).Round(0)
+	if err := fc.Put(ctx, "k", "tok", expires); err != nil {
+		t.Fatalf("Put() unexpected error = %v", err)
+	}
+	if err := fc.Put(ctx, "stale", "old", time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("Put() unexpected error = %v", err)
+	}
+
+	tok, exp, ok := NewFileTokenCache(path).Get(ctx, "k")
+	if !ok || tok != "tok" || !exp.Equal(expires) {
+		t.Errorf("Get() = %q, %v, %v, want %q, %v, true", tok, exp, ok, "tok", expires)
+	}
+
+	if runtime.GOOS != "windows" {
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != 0o600 {
+			t.Errorf("cache file mode = %v, want 0600", fi.Mode().Perm())
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := fc.Get(ctx, "k"); ok {
+		t.Error("Get() on corrupt file returned ok")
+	}
+}
+
+func TestWithTokenCache(t *testing.T) {
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		writePayload(w, "projects/test-project/secrets/s/versions/1", "v")
+	})
+
+	tokenRequests := 0
+	metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		tokenRequests++
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "test-token", "expires_in": 3599}) //nolint:errcheck // test mock server
+	}))
+	t.Cleanup(metadataServer.Close)
+	metadataURL = metadataServer.URL
+
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	for range 3 {
+		// Separate clients stand in for separate short-lived processes.
+		c := New(WithTokenCache(NewFileTokenCache(path)))
+		if _, err := c.FetchFromProject(context.Background(), "test-project", "s"); err != nil {
+			t.Fatalf("FetchFromProject() unexpected error = %v", err)
+		}
+	}
+	if tokenRequests != 1 {
+		t.Errorf("token requests = %d, want 1", tokenRequests)
+	}
+}
+
+func TestAccessTokenReuse(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresIn int
+		want      int32
+	}{
+		{name: "reused until near expiry", expiresIn: 3599, want: 1},
+		{name: "no expiry", expiresIn: 0, want: 10},
+		{name: "about to expire", expiresIn: 60, want: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+				writePayload(w, "projects/test-project/secrets/s/versions/1", "v")
+			})
+			var tokenRequests atomic.Int32
+			metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				tokenRequests.Add(1)
+				_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "test-token", "expires_in": tt.expiresIn}) //nolint:errcheck // test mock server
+			}))
+			t.Cleanup(metadataServer.Close)
+			metadataURL = metadataServer.URL
+
+			c := New()
+			var wg sync.WaitGroup
+			for range 10 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := c.FetchFromProject(context.Background(), "test-project", "s"); err != nil {
+						t.Errorf("FetchFromProject() unexpected error = %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+			if n := tokenRequests.Load(); n != tt.want {
+				t.Errorf("token requests = %d, want %d", n, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Tracer starts spans for a client's work, so secret access shows up in
+// distributed traces. It is the small part of a tracing API the client needs,
+// keeping this package free of dependencies; an OpenTelemetry trace.Tracer is
+// adapted in a few lines (see the README). Implementations must be safe for
+// concurrent use.
+type Tracer interface {
+	// Start starts a span named name as a child of any span in ctx, and returns
+	// a context holding the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is one traced operation.
+type Span interface {
+	// SetAttribute records an attribute; value is a string, int, or bool.
+	SetAttribute(key string, value any)
+	// End completes the span, recording err if the operation failed.
+	End(err error)
+}
+
+// WithTracer traces the client's project ID lookups ("gsm.ProjectID"), access
+// token fetches ("gsm.AccessToken"), and every attempt of each metadata server,
+// token endpoint, and Secret Manager request ("gsm " plus the operation, e.g.
+// "gsm access secret"). Attempt spans carry the HTTP method, the attempt
+// number, the response status, and, for requests about one secret, a hash of
+// its name (as logged by WithRedactedLogs) rather than the name itself.
+func WithTracer(t Tracer) Option {
+	return func(c *Client) {
+		c.tracer = t
+	}
+}
+
+// startSpan starts a span if the client has a tracer, returning a function that ends it.
+func (c *Client) startSpan(ctx context.Context, name string) (context.Context, func(error)) {
+	if c.tracer == nil {
+		return ctx, func(error) {}
+	}
+	ctx, span := c.tracer.Start(ctx, name)
+	return ctx, span.End
+}
+
+// startSpan starts the span for an attempt at req, returning req with the
+// span's context so instrumented transports nest beneath it.
+func (l *retryLog) startSpan(req *http.Request) (*http.Request, Span) {
+	ctx, span := l.tracer.Start(req.Context(), "gsm "+l.op)
+	span.SetAttribute("http.request.method", req.Method)
+	span.SetAttribute("gsm.attempt", len(l.attempts)+1)
+	if m := resourceRegex.FindStringSubmatch(req.URL.Path); m != nil {
+		span.SetAttribute("gsm.secret", redact(m[2]))
+	}
+	return req.WithContext(ctx), span
+}
+
+// endAttemptSpan ends an attempt's span, failing it for errors and error responses.
+func endAttemptSpan(span Span, a RetryAttempt) {
+	switch {
+	case a.Error != "":
+		span.End(errors.New(a.Error))
+	case a.Status >= 400:
+		span.SetAttribute("http.response.status_code", a.Status)
+		span.End(fmt.Errorf("status %d", a.Status))
+	default:
+		span.SetAttribute("http.response.status_code", a.Status)
+		span.End(nil)
+	}
+}
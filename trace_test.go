@@ -0,0 +1,92 @@
+package gsm
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordingTracer records finished spans.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+type recordedSpan struct {
+	tracer *recordingTracer
+	attrs  map[string]any
+	err    error
+	name   string
+	parent string
+}
+
+type spanKey struct{}
+
+func (rt *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent, _ := ctx.Value(spanKey{}).(string) //nolint:errcheck // absent for root spans
+	s := &recordedSpan{tracer: rt, name: name, parent: parent, attrs: map[string]any{}}
+	return context.WithValue(ctx, spanKey{}, name), s
+}
+
+func (s *recordedSpan) SetAttribute(key string, value any) {
+	s.attrs[key] = value
+}
+
+func (s *recordedSpan) End(err error) {
+	s.err = err
+	s.tracer.mu.Lock()
+	s.tracer.spans = append(s.tracer.spans, s)
+	s.tracer.mu.Unlock()
+}
+
+func TestWithTracer(t *testing.T) {
+	var calls atomic.Int32
+	setupFakes(t, func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writePayload(w, "projects/test-project/secrets/db-password/versions/1", "v")
+	})
+
+	rt := &recordingTracer{}
+	c := New(WithTracer(rt), WithAPIRetry(RetryPolicy{Attempts: 2, Delay: time.Millisecond}))
+	if _, err := c.Fetch(context.Background(), "db-password"); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	var names []string
+	var attempts []*recordedSpan
+	for _, s := range rt.spans {
+		names = append(names, s.name)
+		if s.name == "gsm access secret" {
+			attempts = append(attempts, s)
+		}
+	}
+	want := []string{"gsm get project ID", "gsm.ProjectID", "gsm get access token", "gsm.AccessToken", "gsm access secret", "gsm access secret"}
+	if len(names) != len(want) {
+		t.Fatalf("spans = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("spans = %v, want %v", names, want)
+		}
+	}
+	if rt.spans[0].parent != "gsm.ProjectID" || rt.spans[2].parent != "gsm.AccessToken" {
+		t.Errorf("metadata attempt parents = %q, %q, want the lookup spans", rt.spans[0].parent, rt.spans[2].parent)
+	}
+
+	first, second := attempts[0], attempts[1]
+	if first.err == nil || first.attrs["http.response.status_code"] != http.StatusServiceUnavailable || first.attrs["gsm.attempt"] != 1 {
+		t.Errorf("first attempt = %+v, want a failed 503", first)
+	}
+	if second.err != nil || second.attrs["http.response.status_code"] != http.StatusOK || second.attrs["gsm.attempt"] != 2 {
+		t.Errorf("second attempt = %+v, want a successful 200", second)
+	}
+	if second.attrs["gsm.secret"] != redact("db-password") || second.attrs["http.request.method"] != http.MethodGet {
+		t.Errorf("second attempt attributes = %v, want the hashed secret name and method", second.attrs)
+	}
+}
@@ -0,0 +1,192 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultCredentialRefresh is how long CredentialTransport reuses a fetched
+// credential if WithCredentialRefresh does not say otherwise.
+const defaultCredentialRefresh = 5 * time.Minute
+
+// TransportOption configures a CredentialTransport.
+type TransportOption func(*transportOptions)
+
+type transportOptions struct {
+	header  string
+	prefix  string
+	refresh time.Duration
+}
+
+// WithCredentialHeader sets the header carrying the credential and the prefix
+// written before it, e.g. ("X-API-Key", ""). The default is
+// ("Authorization", "Bearer ").
+func WithCredentialHeader(header, prefix string) TransportOption {
+	return func(o *transportOptions) {
+		o.header = header
+		o.prefix = prefix
+	}
+}
+
+// WithCredentialRefresh sets how long a fetched credential is reused before
+// the secret is fetched again. The default is 5 minutes.
+func WithCredentialRefresh(d time.Duration) TransportOption {
+	return func(o *transportOptions) {
+		o.refresh = d
+	}
+}
+
+// CredentialTransport returns an http.RoundTripper that sends requests through
+// base, or http.DefaultTransport if base is nil, with a credential from a
+// secret in the current project added, using the default client.
+// See Client.CredentialTransport.
+func CredentialTransport(base http.RoundTripper, name string, opts ...TransportOption) http.RoundTripper {
+	return defaultClient.CredentialTransport(base, name, opts...)
+}
+
+// CredentialTransportFromProject is CredentialTransport with the secret read
+// from a specific project.
+func CredentialTransportFromProject(base http.RoundTripper, pid, name string, opts ...TransportOption) http.RoundTripper {
+	return defaultClient.CredentialTransportFromProject(base, pid, name, opts...)
+}
+
+// CredentialTransport returns an http.RoundTripper that sends requests through
+// base, or http.DefaultTransport if base is nil, with the latest value of a
+// secret in the current project set as a header, so services calling
+// third-party APIs never hold the key in their own config:
+//
+//	hc := &http.Client{Transport: gsm.CredentialTransport(nil, "partner-api-key",
+//		gsm.WithCredentialHeader("X-API-Key", ""))}
+//
+// The secret is fetched on the first request, with that request's context;
+// requests arriving meanwhile wait for that fetch unless their own context
+// ends first. The value is reused until the WithCredentialRefresh interval
+// passes or a response is 401 Unauthorized or 403 Forbidden, which suggests the
+// key was rotated. If a refresh fails, the previous value is used and the fetch
+// is retried within a minute; requests fail only while no value has been
+// fetched. Surrounding whitespace is trimmed from the value.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) CredentialTransport(base http.RoundTripper, name string, opts ...TransportOption) http.RoundTripper {
+	return c.credentialTransport(base, "", name, opts)
+}
+
+// CredentialTransportFromProject is CredentialTransport with the secret read
+// from a specific project.
+func (c *Client) CredentialTransportFromProject(base http.RoundTripper, pid, name string, opts ...TransportOption) http.RoundTripper {
+	return c.credentialTransport(base, pid, name, opts)
+}
+
+func (c *Client) credentialTransport(base http.RoundTripper, pid, name string, opts []TransportOption) *credentialTransport {
+	o := transportOptions{header: "Authorization", prefix: "Bearer ", refresh: defaultCredentialRefresh}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &credentialTransport{c: c, base: base, pid: pid, name: name, opts: o}
+}
+
+// credentialTransport adds a credential from a secret to outgoing requests.
+type credentialTransport struct {
+	fetched time.Time
+	base    http.RoundTripper
+	c       *Client
+	// refreshing is closed when the fetch in progress ends; nil if there is none.
+	refreshing chan struct{}
+	pid        string
+	name       string
+	value      string
+	opts       transportOptions
+	mu         sync.Mutex
+}
+
+func (t *credentialTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	value, err := t.credential(req.Context())
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close() //nolint:errcheck,gosec // RoundTrip must close the body
+		}
+		return nil, fmt.Errorf("credential %s: %w", t.name, err)
+	}
+
+	// RoundTrippers must not modify the caller's request.
+	req = req.Clone(req.Context())
+	req.Header.Set(t.opts.header, t.opts.prefix+value)
+	resp, err := t.base.RoundTrip(req)
+	if err == nil && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
+		t.mu.Lock()
+		if t.value == value {
+			t.fetched = time.Time{}
+		}
+		t.mu.Unlock()
+	}
+	return resp, err
+}
+
+// credential returns the cached credential, fetching it if it is due for a
+// refresh. Concurrent requests share a single fetch, but stop waiting for it
+// when their own context ends.
+func (t *credentialTransport) credential(ctx context.Context) (string, error) {
+	for {
+		t.mu.Lock()
+		if t.value != "" && time.Since(t.fetched) < t.opts.refresh {
+			value := t.value
+			t.mu.Unlock()
+			return value, nil
+		}
+		if wait := t.refreshing; wait != nil {
+			t.mu.Unlock()
+			select {
+			case <-wait:
+				continue
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		}
+		done := make(chan struct{})
+		t.refreshing = done
+		t.mu.Unlock()
+		return t.refresh(ctx, done)
+	}
+}
+
+// refresh fetches the credential without holding t.mu, then records it and
+// closes done to wake the requests waiting for it.
+func (t *credentialTransport) refresh(ctx context.Context, done chan struct{}) (string, error) {
+	var value string
+	var err error
+	if t.pid == "" {
+		value, err = t.c.Fetch(ctx, t.name)
+	} else {
+		value, err = t.c.FetchFromProject(ctx, t.pid, t.name)
+	}
+	value = strings.TrimSpace(value)
+	if err == nil && (value == "" || strings.ContainsAny(value, "\r\n")) {
+		err = errors.New("value is empty or spans multiple lines")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.refreshing = nil
+	close(done)
+	if err != nil {
+		// A fetch cut short by this request's context says nothing about the
+		// secret; waiting requests will fetch it again.
+		if t.value != "" && ctx.Err() == nil {
+			// Keep the previous value, and retry within a minute rather than
+			// on every request.
+			t.fetched = time.Now().Add(min(t.opts.refresh, time.Minute) - t.opts.refresh)
+			t.c.log().Warn("credential refresh failed; using previous value", "secret", t.name, "error", err)
+			return t.value, nil
+		}
+		return "", err
+	}
+	t.value, t.fetched = value, time.Now()
+	return value, nil
+}
@@ -0,0 +1,145 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCredentialTransport(t *testing.T) {
+	var mu sync.Mutex
+	key, fetches, fail := "key-1\n", 0, false
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		fetches++
+		switch {
+		case fail:
+			w.WriteHeader(http.StatusForbidden)
+		case strings.Contains(r.URL.Path, "/secrets/missing/"):
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			writePayload(w, r.URL.Path, key)
+		}
+	})
+
+	// The upstream API accepts only the current key.
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		want := strings.TrimSpace(key)
+		mu.Unlock()
+		if r.Header.Get("X-API-Key") != want {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer upstream.Close()
+
+	hc := &http.Client{Transport: CredentialTransport(nil, "partner-api-key", WithCredentialHeader("X-API-Key", ""), WithCredentialRefresh(time.Hour))}
+	get := func() int {
+		t.Helper()
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, upstream.URL, http.NoBody)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := hc.Do(req)
+		if err != nil {
+			t.Fatalf("request error = %v", err)
+		}
+		resp.Body.Close() //nolint:errcheck,gosec // test
+		if req.Header.Get("X-API-Key") != "" {
+			t.Error("transport modified the caller's request")
+		}
+		return resp.StatusCode
+	}
+
+	for range 3 {
+		if code := get(); code != http.StatusOK {
+			t.Errorf("status = %d, want 200", code)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("fetches = %d, want 1 within the refresh interval", fetches)
+	}
+
+	// After rotation, one request fails and the next uses the new key.
+	mu.Lock()
+	key = "key-2"
+	mu.Unlock()
+	if code := get(); code != http.StatusUnauthorized {
+		t.Errorf("status after rotation = %d, want 401", code)
+	}
+	if code := get(); code != http.StatusOK || fetches != 2 {
+		t.Errorf("status after refetch = %d with %d fetches, want 200 with 2", code, fetches)
+	}
+
+	// A failed refresh keeps the previous value.
+	hc.Transport = CredentialTransportFromProject(nil, "test-project", "partner-api-key", WithCredentialHeader("X-API-Key", ""), WithCredentialRefresh(0))
+	if code := get(); code != http.StatusOK {
+		t.Errorf("status = %d, want 200", code)
+	}
+	mu.Lock()
+	fail = true
+	mu.Unlock()
+	if code := get(); code != http.StatusOK {
+		t.Errorf("status with failed refresh = %d, want 200", code)
+	}
+
+	// With no value yet, requests fail.
+	rt := CredentialTransport(nil, "missing")
+	req := httptest.NewRequest(http.MethodGet, upstream.URL, http.NoBody)
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+	if _, err := rt.RoundTrip(req); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RoundTrip(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCredentialTransportWaitCanceled(t *testing.T) {
+	release := make(chan struct{})
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		writePayload(w, r.URL.Path, "key")
+	})
+	upstream := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer upstream.Close()
+
+	rt := CredentialTransport(nil, "partner-api-key")
+	do := func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL, http.NoBody)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := rt.RoundTrip(req)
+		if err == nil {
+			resp.Body.Close() //nolint:errcheck,gosec // test
+		}
+		return err
+	}
+
+	// The first request fetches the secret, which stalls until released.
+	first := make(chan error, 1)
+	go func() { first <- do(context.Background()) }()
+	time.Sleep(20 * time.Millisecond)
+
+	// A second request waiting for that fetch gives up when its context ends.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := do(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("waiting request error = %v, want context.DeadlineExceeded", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("waiting request returned after %v, want promptly", d)
+	}
+
+	close(release)
+	if err := <-first; err != nil {
+		t.Errorf("fetching request error = %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package gsm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// vaultValueKey is the key under which VaultStore keeps a secret's value
+// unless the name selects another key.
+const vaultValueKey = "value"
+
+// VaultStore is a SecretStore backed by a HashiCorp Vault KV version 2 secrets engine.
+// Each secret's value is kept under the "value" key of its KV entry; fetch
+// another key of an existing entry with "PATH#KEY".
+type VaultStore struct {
+	addr  string
+	mount string
+	token string
+	retry RetryPolicy
+}
+
+// NewVaultStore returns a VaultStore for the KV v2 engine mounted at mount on the
+// Vault server at addr (e.g. "https://vault.example.com:8200"), authenticating with token.
+func NewVaultStore(addr, mount, token string) *VaultStore {
+	return &VaultStore{
+		addr:  strings.TrimSuffix(addr, "/"),
+		mount: strings.Trim(mount, "/"),
+		token: token,
+	}
+}
+
+// Fetch retrieves the latest version of a secret.
+func (v *VaultStore) Fetch(ctx context.Context, name string) (string, error) {
+	path, key, _ := strings.Cut(name, "#")
+	if key == "" {
+		key = vaultValueKey
+	}
+	if err := validVaultPath(path); err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := v.do(ctx, "read secret", http.MethodGet, path, nil, &resp); err != nil {
+		return "", err
+	}
+	value, ok := resp.Data.Data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no string key %q", path, key)
+	}
+	return value, nil
+}
+
+// Store writes a new version of a secret, creating it if needed.
+func (v *VaultStore) Store(ctx context.Context, name, value string) error {
+	if strings.Contains(name, "#") {
+		return errors.New("vault store: writing a single key of a secret is not supported")
+	}
+	if err := validVaultPath(name); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(map[string]any{"data": map[string]string{vaultValueKey: value}})
+	if err != nil {
+		return err
+	}
+	return v.do(ctx, "write secret", http.MethodPost, name, body, nil)
+}
+
+// validVaultPath rejects paths that could escape the KV mount.
+func validVaultPath(path string) error {
+	if path == "" || strings.HasPrefix(path, "/") || strings.HasSuffix(path, "/") {
+		return errors.New("invalid vault secret path")
+	}
+	for _, seg := range strings.Split(path, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return errors.New("invalid vault secret path")
+		}
+	}
+	return nil
+}
+
+// do performs a KV v2 data request, retrying transient failures.
+func (v *VaultStore) do(ctx context.Context, op, method, path string, body []byte, out any) error {
+	segs := strings.Split(path, "/")
+	for i, s := range segs {
+		segs[i] = url.PathEscape(s)
+	}
+	u := fmt.Sprintf("%s/v1/%s/data/%s", v.addr, v.mount, strings.Join(segs, "/"))
+
+	var lastErr error
+	rl := newRetryLog(v.retry, "vault "+op)
+	for attempt := range v.retry.attempts() {
+		if attempt > 0 {
+			slog.Info("retrying vault "+op, "attempt", attempt+1)
+			if err := rl.wait(ctx, attempt, lastErr); err != nil {
+				return rl.fail(err)
+			}
+		}
+
+		var r io.Reader = http.NoBody
+		if body != nil {
+			r = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, u, r)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("X-Vault-Token", v.token)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := rl.do(httpClient, req)
+		if err != nil {
+			lastErr = err
+			slog.Warn("vault "+op+" failed", "attempt", attempt+1, "error", err)
+			continue
+		}
+		respBody, err := readBody(ctx, resp.Body)
+		resp.Body.Close() //nolint:errcheck,gosec // best effort close
+
+		if clientError(resp.StatusCode) {
+			return rl.fail(fmt.Errorf("vault: failed to %s: status %d", op, resp.StatusCode))
+		}
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			lastErr = fmt.Errorf("status %d", resp.StatusCode)
+			slog.Warn("vault "+op+" failed", "attempt", attempt+1, "status", resp.StatusCode)
+			continue
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if out == nil {
+			return nil
+		}
+		if err := json.Unmarshal(respBody, out); err != nil {
+			lastErr = err
+			continue
+		}
+		return nil
+	}
+
+	return rl.fail(fmt.Errorf("vault: failed to %s: %w", op, lastErr))
+}
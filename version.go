@@ -0,0 +1,218 @@
+package gsm
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// versionMetadataPrefix starts the annotation keys holding version metadata,
+// e.g. "gsm.v7.git-sha" for key "git-sha" of version 7.
+const versionMetadataPrefix = "gsm.v"
+
+// maxVersionMetadata is how many versions keep their metadata. Annotations are
+// limited to 16 KiB per secret, so metadata of older versions is dropped.
+const maxVersionMetadata = 20
+
+// versionMetadataKeyRegex matches metadata keys that form valid annotation keys.
+var versionMetadataKeyRegex = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9._-]{0,40}[a-zA-Z0-9])?$`)
+
+// VersionOptions describes the version a Store adds.
+type VersionOptions struct {
+	// Metadata records provenance for the new version, such as a git SHA or
+	// pipeline ID, returned later by ListVersions. Secret Manager has no
+	// version-level labels, so it is kept in the secret's annotations under
+	// "gsm.v<ID>.<key>", for the 20 most recent versions that have any. Keys
+	// are letters, digits, '.', '_', and '-', at most 42 characters, starting
+	// and ending with a letter or digit.
+	Metadata map[string]string
+}
+
+// WithVersionOptions attaches metadata to the version the Store adds. Writing
+// it requires secretmanager.secrets.update and runs as the StepMetadata step.
+// Every version is added with a client-specified CRC32C payload checksum
+// regardless; see Version.ClientSpecifiedPayloadChecksum.
+func WithVersionOptions(v VersionOptions) StoreOption {
+	return func(o *storeOptions) {
+		o.version = v
+	}
+}
+
+func (v VersionOptions) validate() error {
+	for k := range v.Metadata {
+		if !versionMetadataKeyRegex.MatchString(k) {
+			return fmt.Errorf("invalid version metadata key %q", k)
+		}
+	}
+	return nil
+}
+
+// Version describes one version of a secret.
+type Version struct {
+	CreateTime  time.Time
+	DestroyTime time.Time
+	// Metadata is the provenance attached with WithVersionOptions, if any.
+	Metadata map[string]string
+	// ID is the version number, e.g. "3".
+	ID string
+	// State is "ENABLED", "DISABLED", or "DESTROYED".
+	State string
+	// ClientSpecifiedPayloadChecksum reports whether the payload was added with
+	// a checksum for the server to verify, as gsm always does.
+	ClientSpecifiedPayloadChecksum bool
+}
+
+// ListVersions returns the versions of a secret in the current project using
+// the default client. See Client.ListVersionsInProject.
+// The project ID is auto-detected from the GCP metadata server.
+func ListVersions(ctx context.Context, name string) ([]*Version, error) {
+	return defaultClient.ListVersions(ctx, name)
+}
+
+// ListVersionsInProject returns the versions of a secret in a specific project
+// using the default client. See Client.ListVersionsInProject.
+func ListVersionsInProject(ctx context.Context, pid, name string) ([]*Version, error) {
+	return defaultClient.ListVersionsInProject(ctx, pid, name)
+}
+
+// ListVersions returns the versions of a secret in the current project.
+// See ListVersionsInProject.
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) ListVersions(ctx context.Context, name string) ([]*Version, error) {
+	if !secretNameRegex.MatchString(name) {
+		return nil, errors.New("invalid secret name format")
+	}
+
+	p, err := c.projectID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.ListVersionsInProject(ctx, p, name)
+}
+
+// ListVersionsInProject returns the versions of a secret in a specific project,
+// newest first, with any metadata attached when they were stored. Listing
+// requires secretmanager.versions.list and secretmanager.secrets.get.
+func (c *Client) ListVersionsInProject(ctx context.Context, pid, name string) ([]*Version, error) {
+	if !projectIDRegex.MatchString(pid) {
+		return nil, fmt.Errorf("invalid project ID format: %q", pid)
+	}
+	if !secretNameRegex.MatchString(name) {
+		return nil, errors.New("invalid secret name format")
+	}
+
+	tok, err := c.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var r secretResource
+	u := fmt.Sprintf("%s/projects/%s/secrets/%s", c.apiEndpoint(), pid, name)
+	if err := c.call(ctx, tok, "get secret", http.MethodGet, u, nil, &r); err != nil {
+		return nil, err
+	}
+	listed, err := c.listVersions(ctx, tok, pid, name, "")
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(listed, func(a, b secretVersion) int { return cmp.Compare(versionNumber(b), versionNumber(a)) })
+
+	versions := make([]*Version, len(listed))
+	for i, v := range listed {
+		versions[i] = &Version{
+			CreateTime:                     v.CreateTime,
+			DestroyTime:                    v.DestroyTime,
+			Metadata:                       versionMetadata(r.Annotations, versionID(v.Name)),
+			ID:                             versionID(v.Name),
+			State:                          v.State,
+			ClientSpecifiedPayloadChecksum: v.ClientSpecifiedPayloadChecksum,
+		}
+	}
+	return versions, nil
+}
+
+// versionMetadata extracts the metadata of version id from a secret's annotations.
+func versionMetadata(annotations map[string]string, id string) map[string]string {
+	prefix := versionMetadataPrefix + id + "."
+	var md map[string]string
+	for k, v := range annotations {
+		if key, ok := strings.CutPrefix(k, prefix); ok {
+			if md == nil {
+				md = map[string]string{}
+			}
+			md[key] = v
+		}
+	}
+	return md
+}
+
+// annotateVersion records the metadata of version id in the secret's
+// annotations, dropping the metadata of older versions beyond
+// maxVersionMetadata. The read-modify-write is etag-guarded and retried if
+// another writer changes the secret in between.
+func (c *Client) annotateVersion(ctx context.Context, tok, pid, name, id string, md map[string]string) error {
+	u := fmt.Sprintf("%s/projects/%s/secrets/%s", c.apiEndpoint(), pid, name)
+	var err error
+	for range maxRetries {
+		var r secretResource
+		if err = c.call(ctx, tok, "get secret", http.MethodGet, u, nil, &r); err != nil {
+			return err
+		}
+
+		annotations := make(map[string]string, len(r.Annotations)+len(md))
+		maps.Copy(annotations, r.Annotations)
+		for k, v := range md {
+			annotations[versionMetadataPrefix+id+"."+k] = v
+		}
+		pruneVersionMetadata(annotations)
+
+		data, merr := json.Marshal(map[string]any{"annotations": annotations, "etag": r.Etag})
+		if merr != nil {
+			return merr
+		}
+		err = c.call(ctx, tok, "update secret", http.MethodPatch, u+"?updateMask=annotations", data, nil)
+		err = conflictError(err, "projects/"+pid+"/secrets/"+name, r.Etag)
+		var conflict *ConflictError
+		if !errors.As(err, &conflict) {
+			return err
+		}
+	}
+	return err
+}
+
+// pruneVersionMetadata deletes the metadata of all but the newest
+// maxVersionMetadata versions from annotations.
+func pruneVersionMetadata(annotations map[string]string) {
+	byVersion := map[int][]string{}
+	for k := range annotations {
+		rest, ok := strings.CutPrefix(k, versionMetadataPrefix)
+		if !ok {
+			continue
+		}
+		id, _, _ := strings.Cut(rest, ".")
+		if n, err := strconv.Atoi(id); err == nil {
+			byVersion[n] = append(byVersion[n], k)
+		}
+	}
+	if len(byVersion) <= maxVersionMetadata {
+		return
+	}
+
+	ids := slices.Sorted(maps.Keys(byVersion))
+	slices.Reverse(ids)
+	for _, n := range ids[maxVersionMetadata:] {
+		for _, k := range byVersion[n] {
+			delete(annotations, k)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package gsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestStoreWithVersionOptions(t *testing.T) {
+	const base = "projects/test-project/secrets/s/versions/"
+	var (
+		mu          sync.Mutex
+		annotations = map[string]string{"owner": "team-a", "gsm.v1.git-sha": "old"}
+		etag        = 1
+		conflicts   = 1 // the first update loses a race with another writer
+	)
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/secrets"):
+			w.WriteHeader(http.StatusConflict)
+		case strings.HasSuffix(r.URL.Path, ":addVersion"):
+			_, _ = w.Write([]byte(`{"name":"` + base + `2"}`)) //nolint:errcheck // test mock server
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/versions"):
+			_ = json.NewEncoder(w).Encode(map[string]any{ //nolint:errcheck // test mock server
+				"versions": []map[string]any{
+					{"name": base + "1", "state": "DISABLED"},
+					{"name": base + "2", "state": "ENABLED", "clientSpecifiedPayloadChecksum": true},
+				},
+			})
+		case r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(map[string]any{ //nolint:errcheck // test mock server
+				"name": "projects/test-project/secrets/s", "etag": fmt.Sprint(etag), "annotations": annotations,
+			})
+		case r.Method == http.MethodPatch:
+			var body struct {
+				Annotations map[string]string `json:"annotations"`
+				Etag        string            `json:"etag"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body) //nolint:errcheck // test mock server
+			if body.Etag != fmt.Sprint(etag) || r.URL.Query().Get("updateMask") != "annotations" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if conflicts > 0 {
+				conflicts--
+				etag++
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			annotations, etag = body.Annotations, etag+1
+			_, _ = w.Write([]byte(`{}`)) //nolint:errcheck // test mock server
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	ctx := context.Background()
+	md := map[string]string{"git-sha": "abc123", "pipeline": "build-42"}
+	if err := Store(ctx, "s", "v", WithVersionOptions(VersionOptions{Metadata: md})); err != nil {
+		t.Fatalf("Store() unexpected error = %v", err)
+	}
+	if annotations["owner"] != "team-a" || annotations["gsm.v2.pipeline"] != "build-42" {
+		t.Errorf("annotations = %v", annotations)
+	}
+
+	versions, err := ListVersions(ctx, "s")
+	if err != nil {
+		t.Fatalf("ListVersions() unexpected error = %v", err)
+	}
+	if len(versions) != 2 || versions[0].ID != "2" || !reflect.DeepEqual(versions[0].Metadata, md) ||
+		!versions[0].ClientSpecifiedPayloadChecksum || versions[1].Metadata["git-sha"] != "old" {
+		t.Errorf("ListVersions() = %+v, %+v", versions[0], versions[1])
+	}
+
+	err = Store(ctx, "s", "v", WithVersionOptions(VersionOptions{Metadata: map[string]string{"bad key": "x"}}))
+	if err == nil || !strings.Contains(err.Error(), "invalid version metadata key") {
+		t.Errorf("Store() with a bad metadata key = %v, want error", err)
+	}
+
+	mu.Lock()
+	conflicts = 10
+	mu.Unlock()
+	err = Store(ctx, "s", "v", WithVersionOptions(VersionOptions{Metadata: md}))
+	var se *StoreError
+	if !errors.As(err, &se) || se.Step != StepMetadata {
+		t.Errorf("Store() with persistent conflicts = %v, want a StepMetadata error", err)
+	}
+}
+
+func TestPruneVersionMetadata(t *testing.T) {
+	annotations := map[string]string{"owner": "x", "gsm.vx.k": "not a version"}
+	for n := 1; n <= maxVersionMetadata+2; n++ {
+		annotations[fmt.Sprintf("gsm.v%d.a", n)] = "1"
+		annotations[fmt.Sprintf("gsm.v%d.b", n)] = "2"
+	}
+	pruneVersionMetadata(annotations)
+	for _, k := range []string{"gsm.v1.a", "gsm.v2.b"} {
+		if _, ok := annotations[k]; ok {
+			t.Errorf("pruneVersionMetadata() kept %s", k)
+		}
+	}
+	for _, k := range []string{"owner", "gsm.vx.k", "gsm.v3.a", fmt.Sprintf("gsm.v%d.b", maxVersionMetadata+2)} {
+		if _, ok := annotations[k]; !ok {
+			t.Errorf("pruneVersionMetadata() dropped %s", k)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Watch polls a secret in the current project every interval using the default client,
+// calling onChange with the value and version ID initially and after each new version.
+// It blocks until ctx is done and then returns ctx.Err().
+// The project ID is auto-detected from the GCP metadata server.
+func Watch(ctx context.Context, name string, interval time.Duration, onChange func(value, version string)) error {
+	return defaultClient.Watch(ctx, name, interval, onChange)
+}
+
+// WatchInProject polls a secret in a specific project every interval using the default
+// client, calling onChange with the value and version ID initially and after each new version.
+// It blocks until ctx is done and then returns ctx.Err().
+func WatchInProject(ctx context.Context, pid, name string, interval time.Duration, onChange func(value, version string)) error {
+	return defaultClient.WatchInProject(ctx, pid, name, interval, onChange)
+}
+
+// Watch polls a secret in the current project every interval, calling onChange with the
+// value and version ID initially and after each new version.
+// It blocks until ctx is done and then returns ctx.Err().
+// The project ID is auto-detected from the GCP metadata server.
+func (c *Client) Watch(ctx context.Context, name string, interval time.Duration, onChange func(value, version string)) error {
+	if !secretNameRegex.MatchString(name) {
+		return errors.New("invalid secret name format")
+	}
+
+	p, err := c.projectID(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.WatchInProject(ctx, p, name, interval, onChange)
+}
+
+// WatchInProject polls a secret in a specific project every interval, calling onChange
+// with the value and version ID initially and after each new version.
+//
+// Polls read only the latest version's metadata, conditionally on the etag of the
+// previous response (If-None-Match), so an unchanged secret costs a 304 rather than
+// a full payload; the payload is accessed only when the version changes. Poll errors
+// are logged and retried at the next interval. With WithWatchState, the watch
+// resumes from the last version delivered before a restart.
+// It blocks until ctx is done and then returns ctx.Err().
+func (c *Client) WatchInProject(ctx context.Context, pid, name string, interval time.Duration, onChange func(value, version string)) error {
+	if !projectIDRegex.MatchString(pid) {
+		return fmt.Errorf("invalid project ID format: %q", pid)
+	}
+	if !secretNameRegex.MatchString(name) {
+		return errors.New("invalid secret name format")
+	}
+	if interval <= 0 {
+		return errors.New("watch interval must be positive")
+	}
+
+	var etag, current string
+	if c.watchState != nil {
+		current, _ = c.watchState.Get(ctx, watchStateKey(pid, name))
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if err := c.pollOnce(ctx, pid, name, &etag, &current, onChange); err != nil && ctx.Err() == nil {
+			c.log().Warn("secret watch poll failed", "secret", name, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// pollOnce checks the latest version of a secret, calling onChange if it differs from *current.
+func (c *Client) pollOnce(ctx context.Context, pid, name string, etag, current *string, onChange func(value, version string)) error {
+	tok, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	v, notModified, err := c.latestVersionIfChanged(ctx, tok, pid, name, *etag)
+	if err != nil {
+		return err
+	}
+	if notModified {
+		return nil
+	}
+
+	id := versionID(v.Name)
+	if id == "" || id == *current {
+		*etag = v.Etag
+		return nil
+	}
+
+	// The etag is kept only once the new value has been read, so a failed
+	// access is retried on the next poll rather than answered with 304.
+	value, got, err := c.accessVersion(ctx, tok, pid, name, id)
+	if err != nil {
+		return err
+	}
+	*etag = v.Etag
+	c.remember(pid, name, value, got)
+	c.log().Info("secret version changed", "secret", name, "version", got, "previous", *current)
+	*current = got
+	onChange(value, got)
+	if c.watchState != nil {
+		if err := c.watchState.Put(ctx, watchStateKey(pid, name), got); err != nil {
+			c.log().Warn("failed to record watched version", "secret", name, "version", got, "error", err)
+		}
+	}
+	return nil
+}
+
+// latestVersionIfChanged reads the latest version's metadata with If-None-Match set to etag,
+// reporting notModified if the server answers 304. A single attempt is made;
+// the caller's polling provides the retries.
+func (c *Client) latestVersionIfChanged(ctx context.Context, tok, pid, name, etag string) (*secretVersion, bool, error) {
+	u := fmt.Sprintf("%s/projects/%s/secrets/%s/versions/latest", c.apiEndpoint(), pid, name)
+	req, err := c.apiRequest(ctx, http.MethodGet, u, tok, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := c.apiHTTP().Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close() //nolint:errcheck // best effort close
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, true, nil
+	case http.StatusOK:
+	default:
+		body, _ := readBody(ctx, resp.Body) //nolint:errcheck // best effort
+		return nil, false, newAPIError("get secret version", resp.StatusCode, body)
+	}
+
+	var v secretVersion
+	if err := decodeBody(ctx, resp.Body, &v); err != nil {
+		return nil, false, err
+	}
+	// Prefer the HTTP validator; the resource etag is the fallback.
+	if h := resp.Header.Get("ETag"); h != "" {
+		v.Etag = h
+	}
+	return &v, false, nil
+}
@@ -0,0 +1,104 @@
+package gsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// WatchState remembers the last version a watcher delivered for each secret, so
+// a restarted process resumes where it left off. Implementations must be safe
+// for concurrent use.
+type WatchState interface {
+	// Get returns the version stored under key, or ok=false if there is none.
+	Get(ctx context.Context, key string) (version string, ok bool)
+	// Put stores version under key.
+	Put(ctx context.Context, key, version string) error
+}
+
+// WithWatchState makes watchers resume from the versions recorded in state,
+// keyed by "projects/PROJECT/secrets/NAME". A watcher whose secret was rotated
+// while the process was down calls onChange once with the new version; one whose
+// secret is still at the recorded version does not call onChange at startup, so
+// load the initial value with Fetch. Each version is recorded after onChange
+// returns, so a crash mid-callback redelivers it. See FileWatchState.
+func WithWatchState(state WatchState) Option {
+	return func(c *Client) {
+		c.watchState = state
+	}
+}
+
+// watchStateKey identifies a watched secret in a WatchState.
+func watchStateKey(pid, name string) string {
+	return "projects/" + pid + "/secrets/" + name
+}
+
+// FileWatchState is a WatchState backed by a JSON file readable only by its
+// owner (mode 0600), locked and replaced atomically like FileTokenCache.
+type FileWatchState struct {
+	path string
+}
+
+// NewFileWatchState returns a FileWatchState stored at path.
+// The file and its lock are created on first use.
+func NewFileWatchState(path string) *FileWatchState {
+	return &FileWatchState{path: path}
+}
+
+// Get implements WatchState. Unreadable or corrupt state files are treated as empty.
+func (ws *FileWatchState) Get(_ context.Context, key string) (version string, ok bool) {
+	unlock, err := lockFile(ws.path+".lock", false)
+	if err != nil {
+		return "", false
+	}
+	defer unlock()
+
+	versions, err := ws.read()
+	if err != nil {
+		return "", false
+	}
+	version, ok = versions[key]
+	return version, ok
+}
+
+// Put implements WatchState.
+func (ws *FileWatchState) Put(_ context.Context, key, version string) error {
+	unlock, err := lockFile(ws.path+".lock", true)
+	if err != nil {
+		return fmt.Errorf("failed to lock watch state: %w", err)
+	}
+	defer unlock()
+
+	versions, err := ws.read()
+	if err != nil {
+		versions = map[string]string{}
+	}
+	versions[key] = version
+
+	data, err := json.Marshal(versions)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(ws.path, data)
+}
+
+func (ws *FileWatchState) read() (map[string]string, error) {
+	data, err := os.ReadFile(ws.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var versions map[string]string
+	if err := json.Unmarshal(data, &versions); err != nil {
+		return nil, err
+	}
+	if versions == nil {
+		versions = map[string]string{}
+	}
+	return versions, nil
+}
@@ -0,0 +1,159 @@
+package gsm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		version     = "1"
+		notModified int
+		accesses    int
+	)
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		etag := `"etag-` + version + `"`
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/versions/latest"):
+			if r.Header.Get("If-None-Match") == etag {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			_, _ = w.Write([]byte(`{"name":"projects/test-project/secrets/s/versions/` + version + `"}`)) //nolint:errcheck // test mock server
+		case strings.HasSuffix(r.URL.Path, ":access"):
+			accesses++
+			v := strings.TrimSuffix(strings.Split(r.URL.Path, "/")[6], ":access")
+			writePayload(w, "projects/test-project/secrets/s/versions/"+v, "value-"+v)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var got []string
+	err := Watch(ctx, "s", 10*time.Millisecond, func(value, v string) {
+		got = append(got, v+"="+value)
+		if len(got) == 1 {
+			// Let a few polls come back unmodified, then rotate.
+			time.AfterFunc(50*time.Millisecond, func() {
+				mu.Lock()
+				version = "2"
+				mu.Unlock()
+			})
+			return
+		}
+		cancel()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Watch() error = %v, want context.Canceled", err)
+	}
+	if strings.Join(got, ",") != "1=value-1,2=value-2" {
+		t.Errorf("Watch() changes = %v, want versions 1 then 2", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if notModified == 0 {
+		t.Error("Watch() never received 304 Not Modified for unchanged polls")
+	}
+	if accesses != 2 {
+		t.Errorf("payload accesses = %d, want 2", accesses)
+	}
+}
+
+func TestWatchState(t *testing.T) {
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/versions/latest"):
+			_, _ = w.Write([]byte(`{"name":"projects/test-project/secrets/s/versions/2"}`)) //nolint:errcheck // test mock server
+		case strings.HasSuffix(r.URL.Path, ":access"):
+			writePayload(w, "projects/test-project/secrets/s/versions/2", "value-2")
+		}
+	})
+
+	ctx := context.Background()
+	state := NewFileWatchState(filepath.Join(t.TempDir(), "watch.json"))
+	key := "projects/test-project/secrets/s"
+	if err := state.Put(ctx, key, "1"); err != nil {
+		t.Fatalf("Put() unexpected error = %v", err)
+	}
+	c := New(WithWatchState(state))
+
+	// Rotated while down: the new version is delivered once and recorded.
+	var got []string
+	wctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	err := c.WatchInProject(wctx, "test-project", "s", 10*time.Millisecond, func(value, v string) {
+		got = append(got, v+"="+value)
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WatchInProject() error = %v, want context.DeadlineExceeded", err)
+	}
+	if strings.Join(got, ",") != "2=value-2" {
+		t.Errorf("WatchInProject() changes = %v, want version 2 once", got)
+	}
+	if v, ok := state.Get(ctx, key); !ok || v != "2" {
+		t.Errorf("recorded version = %q, %v, want 2", v, ok)
+	}
+
+	// Unchanged since the last run: nothing is delivered.
+	got = nil
+	wctx, cancel = context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := c.WatchInProject(wctx, "test-project", "s", 10*time.Millisecond, func(value, v string) {
+		got = append(got, v+"="+value)
+	}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WatchInProject() error = %v, want context.DeadlineExceeded", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("WatchInProject() changes = %v, want none", got)
+	}
+}
+
+func TestWatchRetriesFailedAccess(t *testing.T) {
+	var failed atomic.Bool
+	setupFakes(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/versions/latest"):
+			if r.Header.Get("If-None-Match") == `"etag-2"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"etag-2"`)
+			_, _ = w.Write([]byte(`{"name":"projects/test-project/secrets/s/versions/2"}`)) //nolint:errcheck // test mock server
+		case strings.HasSuffix(r.URL.Path, ":access"):
+			// The first read of the new version fails.
+			if failed.CompareAndSwap(false, true) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			writePayload(w, "projects/test-project/secrets/s/versions/2", "value-2")
+		}
+	})
+
+	ctx := context.Background()
+	c := New(WithAPIRetry(RetryPolicy{Attempts: 1}))
+	etag, current := `"etag-1"`, "1"
+	var got []string
+	onChange := func(value, v string) { got = append(got, v+"="+value) }
+	if err := c.pollOnce(ctx, "test-project", "s", &etag, &current, onChange); err == nil {
+		t.Fatal("pollOnce() succeeded although the access failed")
+	}
+	if err := c.pollOnce(ctx, "test-project", "s", &etag, &current, onChange); err != nil {
+		t.Fatalf("pollOnce() unexpected error = %v", err)
+	}
+	if strings.Join(got, ",") != "2=value-2" || current != "2" || etag != `"etag-2"` {
+		t.Errorf("changes = %v, current = %q, etag = %q; want version 2 delivered", got, current, etag)
+	}
+}
@@ -0,0 +1,629 @@
+package gsm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// This file implements the subset of YAML used by spec files, imports, and YAML
+// payloads, keeping the package free of dependencies: block mappings and sequences,
+// flow sequences and mappings of scalars, plain and quoted scalars, literal (|) and
+// folded (>) block scalars, and comments. Anchors, aliases, tags, and multi-document
+// streams are not supported.
+//
+// Values decode to map[string]any, []any, string, bool, json.Number, or nil,
+// so a decoded document can be re-encoded as JSON without loss.
+
+var (
+	yamlNumberRegex = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+	jsonNumberRegex = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+)
+
+type yamlParser struct {
+	lines []string
+	i     int
+	// text keeps plain scalars other than null as their source text.
+	text bool
+}
+
+// parseYAML decodes a YAML document, resolving plain scalars with the YAML 1.2
+// core schema.
+func parseYAML(data []byte) (any, error) {
+	return newYAMLParser(data, false).parse()
+}
+
+// parseYAMLText decodes a YAML document whose scalars are strings, such as
+// secret values, keeping plain scalars as written: "0123" stays "0123" rather
+// than becoming 123. Null scalars still decode to nil.
+func parseYAMLText(data []byte) (any, error) {
+	return newYAMLParser(data, true).parse()
+}
+
+func newYAMLParser(data []byte, text bool) *yamlParser {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	return &yamlParser{lines: lines, text: text}
+}
+
+func (p *yamlParser) parse() (any, error) {
+	p.skipBlank()
+	if p.i < len(p.lines) && strings.TrimSpace(p.lines[p.i]) == "---" {
+		p.i++
+		p.skipBlank()
+	}
+	if p.i >= len(p.lines) {
+		return nil, nil
+	}
+
+	v, err := p.parseBlock(indentOf(p.lines[p.i]))
+	if err != nil {
+		return nil, err
+	}
+	p.skipBlank()
+	if p.i < len(p.lines) {
+		return nil, p.errorf("unexpected content")
+	}
+	return v, nil
+}
+
+func (p *yamlParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("yaml: line %d: %s", p.i+1, fmt.Sprintf(format, args...))
+}
+
+// skipBlank advances past empty and comment-only lines.
+func (p *yamlParser) skipBlank() {
+	for p.i < len(p.lines) {
+		t := strings.TrimSpace(p.lines[p.i])
+		if t != "" && !strings.HasPrefix(t, "#") {
+			return
+		}
+		p.i++
+	}
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// content returns the current line without indentation or trailing comment.
+func (p *yamlParser) content() string {
+	return strings.TrimSpace(stripYAMLComment(p.lines[p.i]))
+}
+
+// checkTabs rejects tab characters in the current line's indentation.
+func (p *yamlParser) checkTabs() error {
+	if strings.HasPrefix(p.lines[p.i][indentOf(p.lines[p.i]):], "\t") {
+		return p.errorf("tabs are not allowed in indentation")
+	}
+	return nil
+}
+
+// parseBlock parses the mapping, sequence, or scalar starting at the current line.
+func (p *yamlParser) parseBlock(indent int) (any, error) {
+	if err := p.checkTabs(); err != nil {
+		return nil, err
+	}
+	c := p.content()
+	if c == "-" || strings.HasPrefix(c, "- ") {
+		return p.parseSeq(indent)
+	}
+	if _, _, ok := splitYAMLKey(c); ok {
+		return p.parseMap(indent)
+	}
+	p.i++
+	return parseYAMLInline(c, p.text)
+}
+
+func (p *yamlParser) parseMap(indent int) (map[string]any, error) {
+	m := map[string]any{}
+	for {
+		p.skipBlank()
+		if p.i >= len(p.lines) {
+			return m, nil
+		}
+		if err := p.checkTabs(); err != nil {
+			return nil, err
+		}
+		ind := indentOf(p.lines[p.i])
+		if ind < indent {
+			return m, nil
+		}
+		if ind > indent {
+			return nil, p.errorf("unexpected indentation")
+		}
+
+		c := p.content()
+		key, rest, ok := splitYAMLKey(c)
+		if !ok {
+			return nil, p.errorf("expected \"key: value\", got %q", c)
+		}
+		if _, dup := m[key]; dup {
+			return nil, p.errorf("duplicate key %q", key)
+		}
+		p.i++
+
+		v, err := p.parseValue(indent, rest, true)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+}
+
+func (p *yamlParser) parseSeq(indent int) ([]any, error) {
+	s := []any{}
+	for {
+		p.skipBlank()
+		if p.i >= len(p.lines) {
+			return s, nil
+		}
+		if err := p.checkTabs(); err != nil {
+			return nil, err
+		}
+		ind := indentOf(p.lines[p.i])
+		if ind < indent {
+			return s, nil
+		}
+		if ind > indent {
+			return nil, p.errorf("unexpected indentation")
+		}
+		c := p.content()
+		if c != "-" && !strings.HasPrefix(c, "- ") {
+			return s, nil
+		}
+
+		rest := strings.TrimLeft(c[1:], " ")
+		if rest == "" {
+			p.i++
+			v, err := p.parseValue(indent, "", false)
+			if err != nil {
+				return nil, err
+			}
+			s = append(s, v)
+			continue
+		}
+
+		// "- key: value" and "- - item" start a nested block at the item's column.
+		_, _, isMap := splitYAMLKey(rest)
+		if isMap || rest == "-" || strings.HasPrefix(rest, "- ") {
+			line := p.lines[p.i]
+			itemIndent := ind + 1 + (len(line[ind+1:]) - len(strings.TrimLeft(line[ind+1:], " ")))
+			p.lines[p.i] = strings.Repeat(" ", itemIndent) + line[itemIndent:]
+			v, err := p.parseBlock(itemIndent)
+			if err != nil {
+				return nil, err
+			}
+			s = append(s, v)
+			continue
+		}
+
+		p.i++
+		v, err := p.parseValue(indent, rest, false)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, v)
+	}
+}
+
+// parseValue parses the value following "key:" or "-". An empty rest means the
+// value is a nested block on the following lines; inMap allows that block to be a
+// sequence at the same indentation as its key.
+func (p *yamlParser) parseValue(indent int, rest string, inMap bool) (any, error) {
+	if strings.HasPrefix(rest, "|") || strings.HasPrefix(rest, ">") {
+		return p.parseBlockScalar(indent, rest)
+	}
+	if rest != "" {
+		return parseYAMLInline(rest, p.text)
+	}
+
+	p.skipBlank()
+	if p.i >= len(p.lines) {
+		return nil, nil
+	}
+	ind := indentOf(p.lines[p.i])
+	c := p.content()
+	if ind > indent || (inMap && ind == indent && (c == "-" || strings.HasPrefix(c, "- "))) {
+		return p.parseBlock(ind)
+	}
+	return nil, nil
+}
+
+// parseBlockScalar parses a literal (|) or folded (>) scalar with optional chomping indicator.
+func (p *yamlParser) parseBlockScalar(indent int, header string) (any, error) {
+	style, chomp := header[0], byte(0)
+	if len(header) > 1 {
+		chomp = header[1]
+	}
+	if chomp != 0 && chomp != '-' && chomp != '+' {
+		return nil, p.errorf("unsupported block scalar header %q", header)
+	}
+
+	var lines []string
+	blockIndent := -1
+	for p.i < len(p.lines) {
+		line := p.lines[p.i]
+		if strings.TrimSpace(line) == "" {
+			lines = append(lines, "")
+			p.i++
+			continue
+		}
+		ind := indentOf(line)
+		if ind <= indent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = ind
+		}
+		if ind < blockIndent {
+			return nil, p.errorf("block scalar line is less indented than the first")
+		}
+		lines = append(lines, line[blockIndent:])
+		p.i++
+	}
+
+	// Trailing blank lines belong to chomping, not content.
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+	// Un-consume trailing blank lines so comments and blanks after the block are skipped normally.
+	p.i -= trailing
+
+	var b strings.Builder
+	for i, line := range lines {
+		switch {
+		case i == 0:
+		case style == '|' || line == "":
+			b.WriteByte('\n')
+		case lines[i-1] == "":
+			// The preceding blank line already produced the line break.
+		default:
+			b.WriteByte(' ')
+		}
+		b.WriteString(line)
+	}
+	s := b.String()
+	switch chomp {
+	case '-':
+	case '+':
+		if len(lines) > 0 {
+			s += "\n"
+		}
+		s += strings.Repeat("\n", trailing)
+	default:
+		if len(lines) > 0 {
+			s += "\n"
+		}
+	}
+	return s, nil
+}
+
+// splitYAMLKey splits "key: value" or "key:" into key and value.
+func splitYAMLKey(s string) (key, rest string, ok bool) {
+	if s == "" || s[0] == '[' || s[0] == '{' || s == "-" || strings.HasPrefix(s, "- ") {
+		return "", "", false
+	}
+	if s[0] == '"' || s[0] == '\'' {
+		end := quotedEnd(s)
+		if end < 0 || end+1 >= len(s) || s[end+1] != ':' {
+			return "", "", false
+		}
+		k, err := parseYAMLQuoted(s[:end+1])
+		if err != nil {
+			return "", "", false
+		}
+		rest = s[end+2:]
+		if rest != "" && rest[0] != ' ' {
+			return "", "", false
+		}
+		return k, strings.TrimSpace(rest), true
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] == ':' && (i+1 == len(s) || s[i+1] == ' ') {
+			return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// quotedEnd returns the index of the closing quote of the quoted scalar at the start of s, or -1.
+func quotedEnd(s string) int {
+	q := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case q == '"' && s[i] == '\\':
+			i++
+		case s[i] == q && q == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == q:
+			return i
+		}
+	}
+	return -1
+}
+
+// stripYAMLComment removes a trailing "# comment" that is outside quotes.
+func stripYAMLComment(line string) string {
+	var q byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case q == 0 && (c == '"' || c == '\'') && (i == 0 || strings.ContainsRune(" \t[{,:-", rune(line[i-1]))):
+			q = c
+		case q == '"' && c == '\\':
+			i++
+		case q != 0 && c == q:
+			if q == '\'' && i+1 < len(line) && line[i+1] == '\'' {
+				i++
+				continue
+			}
+			q = 0
+		case q == 0 && c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseYAMLInline parses a scalar or flow collection that fits on one line.
+// If text is set, plain scalars are resolved as by resolveYAMLText.
+func parseYAMLInline(s string, text bool) (any, error) {
+	v, rest, err := parseYAMLFlow(s, false, text)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(rest) != "" {
+		return nil, fmt.Errorf("yaml: unexpected %q after value", rest)
+	}
+	return v, nil
+}
+
+// parseYAMLFlow parses one value from the start of s, returning the unparsed remainder.
+// Inside flow collections, plain scalars end at ',', ']' or '}'.
+func parseYAMLFlow(s string, inFlow, text bool) (any, string, error) {
+	s = strings.TrimLeft(s, " ")
+	if s == "" {
+		return nil, "", nil
+	}
+
+	switch s[0] {
+	case '[':
+		var items []any
+		s = strings.TrimLeft(s[1:], " ")
+		if strings.HasPrefix(s, "]") {
+			return []any{}, s[1:], nil
+		}
+		for {
+			v, rest, err := parseYAMLFlow(s, true, text)
+			if err != nil {
+				return nil, "", err
+			}
+			items = append(items, v)
+			rest = strings.TrimLeft(rest, " ")
+			switch {
+			case strings.HasPrefix(rest, ","):
+				s = rest[1:]
+			case strings.HasPrefix(rest, "]"):
+				return items, rest[1:], nil
+			default:
+				return nil, "", errors.New("yaml: unterminated flow sequence")
+			}
+		}
+	case '{':
+		m := map[string]any{}
+		s = strings.TrimLeft(s[1:], " ")
+		if strings.HasPrefix(s, "}") {
+			return m, s[1:], nil
+		}
+		for {
+			k, rest, err := parseYAMLFlow(s, true, text)
+			if err != nil {
+				return nil, "", err
+			}
+			rest = strings.TrimLeft(rest, " ")
+			if !strings.HasPrefix(rest, ":") {
+				return nil, "", errors.New("yaml: expected ':' in flow mapping")
+			}
+			v, rest, err := parseYAMLFlow(rest[1:], true, text)
+			if err != nil {
+				return nil, "", err
+			}
+			m[fmt.Sprint(k)] = v
+			rest = strings.TrimLeft(rest, " ")
+			switch {
+			case strings.HasPrefix(rest, ","):
+				s = rest[1:]
+			case strings.HasPrefix(rest, "}"):
+				return m, rest[1:], nil
+			default:
+				return nil, "", errors.New("yaml: unterminated flow mapping")
+			}
+		}
+	case '"', '\'':
+		end := quotedEnd(s)
+		if end < 0 {
+			return nil, "", errors.New("yaml: unterminated quoted string")
+		}
+		v, err := parseYAMLQuoted(s[:end+1])
+		return v, s[end+1:], err
+	}
+
+	end := len(s)
+	if inFlow {
+		if i := strings.IndexAny(s, ",]}"); i >= 0 {
+			end = i
+		}
+		// A ':' followed by a space ends a key in a flow mapping.
+		if i := strings.Index(s[:end], ": "); i >= 0 {
+			end = i
+		}
+		if strings.HasSuffix(s[:end], ":") {
+			end--
+		}
+	}
+	if text {
+		return resolveYAMLText(strings.TrimSpace(s[:end])), s[end:], nil
+	}
+	return resolveYAMLScalar(strings.TrimSpace(s[:end])), s[end:], nil
+}
+
+// parseYAMLQuoted decodes a complete single- or double-quoted scalar.
+func parseYAMLQuoted(s string) (string, error) {
+	if s[0] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	// YAML double-quoted escapes are a superset of Go's for the common cases;
+	// normalize the few that differ.
+	v, err := strconv.Unquote(strings.ReplaceAll(s, `\/`, `/`))
+	if err != nil {
+		return "", fmt.Errorf("yaml: invalid double-quoted string %s", s)
+	}
+	return v, nil
+}
+
+// resolveYAMLScalar applies the YAML 1.2 core schema to a plain scalar.
+func resolveYAMLScalar(s string) any {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if yamlNumberRegex.MatchString(s) {
+		n := strings.TrimPrefix(s, "+")
+		if !jsonNumberRegex.MatchString(n) {
+			// Forms like "1." or "007" are numbers in YAML but not in JSON.
+			f, err := strconv.ParseFloat(n, 64)
+			if err != nil {
+				return s
+			}
+			n = strconv.FormatFloat(f, 'f', -1, 64)
+		}
+		return json.Number(n)
+	}
+	return s
+}
+
+// resolveYAMLText resolves a plain scalar to nil if it is null, and otherwise
+// to its text.
+func resolveYAMLText(s string) any {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	}
+	return s
+}
+
+// yamlString converts a decoded scalar to its string form.
+func yamlString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+var yamlPlainKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// encodeYAML encodes v, a value decoded from JSON with json.Decoder.UseNumber,
+// as a block-style YAML document. Mapping keys are sorted and strings are always
+// double-quoted, so no value can be misread as another type.
+func encodeYAML(v any) []byte {
+	var b strings.Builder
+	switch v := v.(type) {
+	case map[string]any:
+		if len(v) > 0 {
+			writeYAMLMap(&b, v, 0)
+			return []byte(b.String())
+		}
+	case []any:
+		if len(v) > 0 {
+			writeYAMLSeq(&b, v, 0)
+			return []byte(b.String())
+		}
+	}
+	b.WriteString(yamlScalar(v))
+	b.WriteByte('\n')
+	return []byte(b.String())
+}
+
+func writeYAMLMap(b *strings.Builder, m map[string]any, indent int) {
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		b.WriteString(strings.Repeat(" ", indent))
+		if yamlPlainKeyRegex.MatchString(k) && resolveYAMLScalar(k) == k {
+			b.WriteString(k)
+		} else {
+			b.WriteString(strconv.Quote(k))
+		}
+		b.WriteByte(':')
+		writeYAMLValue(b, m[k], indent+2)
+	}
+}
+
+func writeYAMLSeq(b *strings.Builder, s []any, indent int) {
+	for _, item := range s {
+		var nested strings.Builder
+		writeYAMLValue(&nested, item, indent+2)
+		line := nested.String()
+		if line[0] == '\n' {
+			// Put the first entry of a nested collection on the dash line.
+			line = line[1+indent+2:]
+		} else {
+			line = line[1:]
+		}
+		b.WriteString(strings.Repeat(" ", indent))
+		b.WriteString("- ")
+		b.WriteString(line)
+	}
+}
+
+// writeYAMLValue writes the part of a mapping entry or sequence item after its
+// key or dash: " scalar\n", or "\n" followed by a nested collection at indent.
+func writeYAMLValue(b *strings.Builder, v any, indent int) {
+	switch v := v.(type) {
+	case map[string]any:
+		if len(v) > 0 {
+			b.WriteByte('\n')
+			writeYAMLMap(b, v, indent)
+			return
+		}
+	case []any:
+		if len(v) > 0 {
+			b.WriteByte('\n')
+			writeYAMLSeq(b, v, indent)
+			return
+		}
+	}
+	b.WriteByte(' ')
+	b.WriteString(yamlScalar(v))
+	b.WriteByte('\n')
+}
+
+// yamlScalar formats a scalar or empty collection.
+func yamlScalar(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(v)
+	case map[string]any:
+		return "{}"
+	case []any:
+		return "[]"
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,104 @@
+package gsm
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    string // JSON encoding of the decoded value
+		wantErr bool
+	}{
+		{name: "empty", in: "# nothing\n", want: `null`},
+		{name: "scalars", in: "a: 1\nb: true\nc: ~\nd: hello world\ne: '1'\nf: \"x\\ty\"\ng: 1.5e3\nh: 007\n",
+			want: `{"a":1,"b":true,"c":null,"d":"hello world","e":"1","f":"x\ty","g":1.5e3,"h":7}`},
+		{name: "comments", in: "---\n# header\na: b # trailing\nc: 'x # y' # real\nd: a#b\n", want: `{"a":"b","c":"x # y","d":"a#b"}`},
+		{name: "nested", in: "env:\n  A: a\n  B:\n    secret: b\n    transform: [trim, base64-decode]\n",
+			want: `{"env":{"A":"a","B":{"secret":"b","transform":["trim","base64-decode"]}}}`},
+		{name: "sequences", in: "list:\n- a\n- b: 1\n  c: 2\n-\n  - x\n  - y\nflow: {k: v, n: [1, 2]}\n",
+			want: `{"flow":{"k":"v","n":[1,2]},"list":["a",{"b":1,"c":2},["x","y"]]}`},
+		{name: "literal", in: "key: |\n  line1\n\n  line2\nnext: x\n", want: `{"key":"line1\n\nline2\n","next":"x"}`},
+		{name: "literal strip", in: "key: |-\n  a\n   b\n\n", want: `{"key":"a\n b"}`},
+		{name: "folded", in: "key: >\n  a\n  b\n\n  c\n", want: `{"key":"a b\nc\n"}`},
+		{name: "quoted key", in: "\"a: b\": c\n'd''e': f\n", want: `{"a: b":"c","d'e":"f"}`},
+		{name: "top-level sequence", in: "- 1\n- two\n", want: `[1,"two"]`},
+		{name: "duplicate key", in: "a: 1\na: 2\n", wantErr: true},
+		{name: "bad indentation", in: "a: 1\n  b: 2\n", wantErr: true},
+		{name: "not a mapping", in: "a: 1\njust text\n", wantErr: true},
+		{name: "unterminated quote", in: "a: \"x\n", wantErr: true},
+		{name: "tab indentation", in: "a:\n\tb: 1\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseYAML([]byte(tt.in))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseYAML() = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseYAML() unexpected error = %v", err)
+			}
+			var want any
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			b, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("decoded value does not encode as JSON: %v", err)
+			}
+			var gotJSON any
+			if err := json.Unmarshal(b, &gotJSON); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotJSON, want) {
+				t.Errorf("parseYAML() = %s, want %s", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeYAML(t *testing.T) {
+	in := `{"b":[{"x":1,"y":"two"},"s",[],{}],"a":{"nested":{"k":null,"t":true}},"odd key":"line1\nline2","true":"é"}`
+	var v any
+	dec := json.NewDecoder(strings.NewReader(in))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	out := encodeYAML(v)
+	want := `a:
+  nested:
+    k: null
+    t: true
+b:
+  - x: 1
+    y: "two"
+  - "s"
+  - []
+  - {}
+"odd key": "line1\nline2"
+"true": "é"
+`
+	if string(out) != want {
+		t.Errorf("encodeYAML() =\n%s\nwant\n%s", out, want)
+	}
+
+	back, err := parseYAML(out)
+	if err != nil {
+		t.Fatalf("parseYAML(encodeYAML()) error = %v", err)
+	}
+	got, _ := json.Marshal(back) //nolint:errcheck // decoded YAML always marshals
+	orig, _ := json.Marshal(v)   //nolint:errcheck // decoded JSON always marshals
+	if string(got) != string(orig) {
+		t.Errorf("round trip = %s, want %s", got, orig)
+	}
+}